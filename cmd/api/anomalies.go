@@ -0,0 +1,64 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"voice-insights-go/internal/anomaly"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/scorecard"
+)
+
+// registerAnomalyRoutes exposes issue-volume spike alerts.
+func registerAnomalyRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /anomalies — stored spike alerts with example calls and action
+	// cards; window as for scorecards (default the last 7 days)
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /anomalies", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "anomalies.list")
+		win, err := parseWindow(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		alerts, err := anomaly.List(win.From, win.To)
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list alerts", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, map[string]any{"window": win, "alerts": alerts}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /anomalies/detect?day=YYYY-MM-DD — check every issue and city of
+	// a day (default today); calls are also checked as they are processed
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /anomalies/detect", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "anomalies.detect")
+		day := time.Now()
+		if v := r.URL.Query().Get("day"); v != "" {
+			d, err := time.ParseInLocation("2006-01-02", v, scorecard.IST)
+			if err != nil {
+				writeError(w, apperr.New(apperr.InvalidInput, "", "day must be a YYYY-MM-DD date"))
+				return
+			}
+			day = d
+		}
+		alerts, err := anomaly.Detect(day, anomaly.OptionsFromEnv())
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "detect spikes", err))
+			return
+		}
+		if alerts == nil {
+			alerts = []anomaly.Alert{}
+		}
+		reqLog.WithField("alerts", len(alerts)).Info("spike detection finished")
+		if err := writeJSON(w, http.StatusOK, map[string]any{"alerts": alerts}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
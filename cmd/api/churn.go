@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/churn"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+)
+
+// registerChurnRoutes exposes the churn model: training on recorded outcomes, its
+// coefficients, and explained scores for stored calls.
+func registerChurnRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// POST /churn/train?lambda=0.1&folds=5 — fit the model on every call
+	// with a churn outcome and make it current; the response carries the
+	// cross-validated metrics
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /churn/train", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "churn.train")
+		opts := churn.DefaultTrainOptions
+		if v := r.URL.Query().Get("lambda"); v != "" {
+			l, err := strconv.ParseFloat(v, 64)
+			if err != nil || l <= 0 {
+				writeError(w, apperr.New(apperr.InvalidInput, "", "lambda must be a positive number"))
+				return
+			}
+			opts.Lambda = l
+		}
+		folds, err := queryPositiveInt(r, "folds", opts.Folds)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if folds < 2 || folds > 20 {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "folds must be between 2 and 20"))
+			return
+		}
+		opts.Folds = folds
+		m, err := churn.Train(opts)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("version", m.Version).WithField("samples", m.Metrics.Samples).WithField("auc", m.Metrics.AUC).Info("churn model trained")
+		if err := writeJSON(w, http.StatusOK, modelView(m)); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /churn/model — the current model, coefficients largest first
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /churn/model", func(w http.ResponseWriter, r *http.Request) {
+		m, err := churn.Current()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load churn model", err))
+			return
+		}
+		if m == nil {
+			writeError(w, apperr.New(apperr.NotFound, "", "no churn model trained"))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, modelView(m)); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "churn.model").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /jobs/{id}/churn — a processed call scored by the current model,
+	// with the features that contributed most
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /jobs/{id}/churn", func(w http.ResponseWriter, r *http.Request) {
+		job, err := jobs.Get(r.PathValue("id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+			return
+		}
+		if job.Status != jobs.StatusCompleted && job.Status != jobs.StatusPartial {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "job has not been processed"))
+			return
+		}
+		pred, err := churn.Score(churn.InputOf(job))
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "score churn", err))
+			return
+		}
+		if pred == nil {
+			writeError(w, apperr.New(apperr.NotFound, "", "no churn model trained"))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, pred); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "churn.job").WithError(err).Error("failed to write response")
+		}
+	})
+}
+
+// modelView is a model as served: the stored fields plus its coefficients by effect size.
+func modelView(m *churn.Model) any {
+	return struct {
+		*churn.Model
+		Coefficients []churn.Coefficient `json:"coefficients"`
+	}{m, m.Coefficients()}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/dataset"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+)
+
+type classifierTrainRequest struct {
+	Examples []classifier.Example `json:"examples"`
+	// Path is a labeled dataset under DATASET_DIR (see dataset.LoadLabeled)
+	Path    string          `json:"path"`
+	Options dataset.Options `json:"options"`
+}
+
+type classifierTrainResponse struct {
+	Examples   int `json:"examples"`
+	Categories int `json:"categories"`
+	Vocabulary int `json:"vocabulary"`
+}
+
+type classifyRequest struct {
+	Transcript string `json:"transcript"`
+	JobID      string `json:"job_id"`
+	// Classifier overrides CLASSIFIER for this request (rules|bayes|llm)
+	Classifier string `json:"classifier"`
+}
+
+// registerClassifierRoutes exposes transcript classification and naive Bayes training.
+func registerClassifierRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// POST /classifier/train — fit and persist the naive Bayes model from
+	// inline examples and/or a labeled dataset file
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /classifier/train", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "classifier.train")
+		var req classifierTrainRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		examples := req.Examples
+		if req.Path != "" {
+			path, err := dataset.ResolvePath(req.Path)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+			loaded, err := dataset.LoadLabeled(path, req.Options)
+			if err != nil {
+				writeError(w, apperr.Wrap(apperr.InvalidInput, "", "load labeled dataset", err))
+				return
+			}
+			examples = append(examples, loaded...)
+		}
+		m, err := classifier.Train(examples)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := m.Save(); err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "save model", err))
+			return
+		}
+		resp := classifierTrainResponse{Examples: m.Examples, Categories: len(m.Categories), Vocabulary: m.Vocabulary}
+		reqLog.WithField("examples", resp.Examples).WithField("categories", resp.Categories).Info("naive bayes model trained")
+		if err := writeJSON(w, http.StatusOK, resp); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /classifier/classify — category and confusion for one transcript
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /classifier/classify", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "classifier.classify")
+		var req classifyRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		text := req.Transcript
+		if req.JobID != "" {
+			job, err := jobs.Get(req.JobID)
+			if errors.Is(err, jobs.ErrNotFound) {
+				writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+				return
+			}
+			if err != nil {
+				writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+				return
+			}
+			text = job.Artifacts.Transcript
+		}
+		if text == "" {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "transcript or job_id is required"))
+			return
+		}
+
+		var c classifier.Classifier
+		var err error
+		switch req.Classifier {
+		case "":
+			c, err = classifier.FromEnv()
+		case classifier.NameRules:
+			c, err = classifier.LoadRules()
+		case classifier.NameBayes:
+			c, err = classifier.LoadBayes()
+		case classifier.NameLLM:
+			c = classifier.LLM{}
+		default:
+			err = apperr.New(apperr.InvalidInput, "", "classifier must be rules, bayes or llm")
+		}
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		res, err := c.Classify(r.Context(), text)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, res); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"mime"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/coaching"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+)
+
+// registerCoachingRoutes exposes per-agent coaching briefs.
+func registerCoachingRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /agents/{id}/coaching — recurring gaps as a brief;
+	// format=markdown|pdf for trainers, JSON otherwise
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /agents/{id}/coaching", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		reqLog := logger.New().WithRequest(r).WithField("handler", "agents.coaching").WithField("agent_id", id)
+		win, err := parseWindow(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		all, err := jobs.List()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list jobs", err))
+			return
+		}
+		brief := coaching.Build(all, id, win.From, win.To)
+		if brief.Calls == 0 {
+			writeError(w, apperr.New(apperr.NotFound, "", "no processed calls for agent in window"))
+			return
+		}
+		coaching.SuggestPhrasing(r.Context(), &brief)
+		reqLog.WithField("calls", brief.Calls).WithField("behaviors", len(brief.TopBehaviors)).Info("coaching brief built")
+
+		switch r.URL.Query().Get("format") {
+		case "markdown":
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte(coaching.Markdown(brief)))
+		case "pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "coaching-" + id + ".pdf"}))
+			_, _ = w.Write(coaching.PDF(brief))
+		default:
+			if err := writeJSON(w, http.StatusOK, brief); err != nil {
+				reqLog.WithError(err).Error("failed to write response")
+			}
+		}
+	})
+}
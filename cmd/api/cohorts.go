@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/cohort"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+)
+
+// registerCohortRoutes exposes the shared cohort configuration and KPI comparisons between
+// cohorts.
+func registerCohortRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /cohorts/config — vintage buckets, city tiers and call types
+	// PUT /cohorts/config — replace them; validated, versioned, persisted
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /cohorts/config", func(w http.ResponseWriter, r *http.Request) {
+		c, err := cohort.Current()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load cohort config", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, c); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "cohorts.config").WithError(err).Error("failed to write response")
+		}
+	})
+	mux.HandleFunc("PUT /cohorts/config", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "cohorts.config.put")
+		var next cohort.Config
+		if err := json.NewDecoder(r.Body).Decode(&next); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		c, err := cohort.Replace(next)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("version", c.Version).Info("cohort config replaced")
+		if err := writeJSON(w, http.StatusOK, c); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /cohorts/compare?dimension=vintage&a=6-12M&b=0-2M — KPI means of
+	// two cohorts and their deltas (b minus a) over the window (week,
+	// from/to or days, as for scorecards); format=markdown for a report
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /cohorts/compare", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		reqLog := logger.New().WithRequest(r).WithField("handler", "cohorts.compare").WithField("dimension", q.Get("dimension"))
+		win, err := parseWindow(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if q.Get("a") == "" || q.Get("b") == "" {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "a and b cohorts are required"))
+			return
+		}
+		c, err := cohort.Current()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load cohort config", err))
+			return
+		}
+		all, err := jobs.List()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list jobs", err))
+			return
+		}
+		cmp, err := cohort.Compare(all, c, q.Get("dimension"), q.Get("a"), q.Get("b"), win)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("a_calls", cmp.A.Calls).WithField("b_calls", cmp.B.Calls).Info("cohorts compared")
+
+		if q.Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte(cohort.Markdown(cmp)))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, cmp); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/compliance"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+)
+
+type complianceCheckRequest struct {
+	Transcript string `json:"transcript"`
+	JobID      string `json:"job_id"`
+}
+
+// registerComplianceRoutes exposes the deterministic script checker for audits.
+func registerComplianceRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /compliance/rules — the active ruleset
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /compliance/rules", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "compliance.rules")
+		rules, err := compliance.Load()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load compliance rules", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, rules); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /compliance/check — audit a transcript or a stored job's transcript
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /compliance/check", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "compliance.check")
+		var req complianceCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		text := req.Transcript
+		if req.JobID != "" {
+			job, err := jobs.Get(req.JobID)
+			if errors.Is(err, jobs.ErrNotFound) {
+				writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+				return
+			}
+			if err != nil {
+				writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+				return
+			}
+			text = job.Artifacts.Transcript
+		}
+		if text == "" {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "transcript or job_id is required"))
+			return
+		}
+
+		rules, err := compliance.Load()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load compliance rules", err))
+			return
+		}
+		rep := compliance.Check(text, rules)
+		reqLog.WithField("passed", rep.Passed).WithField("failed", rep.Failed).Info("compliance check complete")
+		if err := writeJSON(w, http.StatusOK, rep); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
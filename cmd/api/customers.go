@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/customer"
+	"voice-insights-go/internal/logger"
+)
+
+// registerCustomerRoutes exposes linked callers: repeat callers, lookup by phone or account,
+// per-customer timelines and journeys.
+func registerCustomerRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /customers?min_calls=2&limit=50 — callers with the most calls
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /customers", func(w http.ResponseWriter, r *http.Request) {
+		minCalls, err := queryPositiveInt(r, "min_calls", 2)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		limit, err := queryPositiveInt(r, "limit", 50)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		list, err := customer.List(minCalls)
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list customers", err))
+			return
+		}
+		if len(list) > limit {
+			list = list[:limit]
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "customers.list").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /customers/lookup {"phone": ..., "account": ...} — the hashed
+	// customer id for a caller, without exposing how it is derived
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /customers/lookup", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Phone   string `json:"phone"`
+			Account string `json:"account"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		id, err := customer.Lookup(req.Phone, req.Account)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, map[string]string{"customer_id": id}); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "customers.lookup").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /customers/{id}/timeline — every call of a customer with its
+	// issue and outcome, oldest first
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /customers/{id}/timeline", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "customers.timeline").WithField("customer_id", r.PathValue("id"))
+		tl, err := customer.BuildTimeline(r.PathValue("id"), customer.OptionsFromEnv())
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("calls", tl.Calls).WithField("repeat_contacts", tl.RepeatContacts).Info("timeline built")
+		if err := writeJSON(w, http.StatusOK, tl); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /customers/{id}/journey?refresh=true — processed calls in order
+	// with score trajectory, issue resolution and an account summary; the
+	// summary is cached until a call or outcome changes (refresh rebuilds it)
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /customers/{id}/journey", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "customers.journey").WithField("customer_id", r.PathValue("id"))
+		refresh := r.URL.Query().Get("refresh") == "true"
+		jr, err := customer.BuildJourney(r.Context(), r.PathValue("id"), customer.OptionsFromEnv(), refresh)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("calls", jr.Calls).WithField("summary_source", jr.Summary.Source).WithField("cached", jr.Summary.Cached).Info("journey built")
+		if err := writeJSON(w, http.StatusOK, jr); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
+
+// processCustomer reads the caller's phone and account for /process from the JSON body
+// {"customer_phone": ..., "customer_account": ...}; an empty body means no customer. They
+// are refused as query parameters, which end up in access logs and proxies.
+func processCustomer(r *http.Request) (phone, acct string, err error) {
+	q := r.URL.Query()
+	if q.Has("customer_phone") || q.Has("customer_account") {
+		return "", "", apperr.New(apperr.InvalidInput, "", "send customer_phone and customer_account in the JSON body, not the query string")
+	}
+	var body struct {
+		Phone   string `json:"customer_phone"`
+		Account string `json:"customer_account"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		return "", "", apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err)
+	}
+	return body.Phone, body.Account, nil
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/dataset"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/types"
+)
+
+type datasetRequest struct {
+	// Path is relative to DATASET_DIR
+	Path    string          `json:"path"`
+	Options dataset.Options `json:"options"`
+	// SampleRows bounds the preview of /datasets/inspect (default 5, max 50)
+	SampleRows int `json:"sample_rows"`
+}
+
+type datasetRegisterRequest struct {
+	Name    string          `json:"name"`
+	Path    string          `json:"path"`
+	Options dataset.Options `json:"options"`
+}
+
+type datasetImportResponse struct {
+	Records []types.CallRecord   `json:"records"`
+	Report  dataset.ImportReport `json:"report"`
+}
+
+// registerDatasetRoutes exposes dataset inspection for building a column mapping, imports,
+// and the registry of datasets with persisted summaries.
+func registerDatasetRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// POST /datasets/inspect — dry run: detected format/sheets, header,
+	// redacted sample rows, suggested mapping and whether the given one fits
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /datasets/inspect", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "datasets.inspect")
+		var req datasetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		path, err := dataset.ResolvePath(req.Path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		n := req.SampleRows
+		switch {
+		case n <= 0:
+			n = 5
+		case n > 50:
+			n = 50
+		}
+		res, err := dataset.Inspect(path, req.Options, n)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("path", req.Path).WithField("columns", len(res.Header)).Info("dataset inspected")
+		if err := writeJSON(w, http.StatusOK, res); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /datasets/import — load call records with the explicit mapping;
+	// the report lists every skipped or coerced row and duplicate call ids
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /datasets/import", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "datasets.import")
+		var req datasetRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		path, err := dataset.ResolvePath(req.Path)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		records, report, err := dataset.Load(path, req.Options)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if records == nil {
+			records = []types.CallRecord{}
+		}
+		reqLog.WithField("path", req.Path).WithField("imported", report.Imported).WithField("skipped", report.Skipped).Info("dataset imported")
+		if err := writeJSON(w, http.StatusOK, datasetImportResponse{Records: records, Report: report}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /datasets, GET /datasets — register a dataset and list registered
+	// datasets; registering answers 202 with status "summarizing" and the
+	// summary is built in the background (poll GET /datasets/{id}/summary)
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /datasets", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "datasets.register")
+		var req datasetRegisterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		c, err := classifier.FromEnv()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load classifier", err))
+			return
+		}
+		d, err := dataset.Register(req.Name, req.Path, req.Options, c)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("dataset_id", d.ID).Info("dataset registration accepted")
+		if err := writeJSON(w, http.StatusAccepted, d.Info()); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+	mux.HandleFunc("GET /datasets", func(w http.ResponseWriter, r *http.Request) {
+		list, err := dataset.ListRegistered()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list datasets", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "datasets.list").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /datasets/{id}/summary — the persisted summary, served at once;
+	// rows appended to the file since the last refresh are folded in by a
+	// background refresh (409 while the dataset is still being summarized
+	// or after its summary failed)
+	// POST /datasets/{id}/refresh?full=true — force an incremental or full
+	// re-summarization
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /datasets/{id}/summary", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "datasets.summary")
+		c, err := classifier.FromEnv()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load classifier", err))
+			return
+		}
+		d, err := dataset.GetAndRefresh(r.PathValue("id"), c)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, d.Summary); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+	mux.HandleFunc("POST /datasets/{id}/refresh", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "datasets.refresh")
+		c, err := classifier.FromEnv()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load classifier", err))
+			return
+		}
+		d, err := dataset.Refresh(r.Context(), r.PathValue("id"), c, r.URL.Query().Get("full") == "true")
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("dataset_id", d.ID).WithField("total_calls", d.Summary.TotalCalls).Info("dataset refreshed")
+		if err := writeJSON(w, http.StatusOK, d.Info()); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
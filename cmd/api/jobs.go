@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/processor"
+)
+
+// registerJobRoutes exposes stored jobs and resumption of failed ones.
+func registerJobRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /jobs/{id} — per-stage status, artifacts and last result
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /jobs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "jobs.get")
+		job, err := jobs.Get(r.PathValue("id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+			return
+		}
+		if err != nil {
+			reqLog.WithError(err).Error("load job failed")
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, job); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /calls/{call_id} — the newest job of an external call id
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /calls/{call_id}", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "jobs.by_call")
+		job, err := jobs.ByCallID(r.PathValue("call_id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			writeError(w, apperr.New(apperr.NotFound, "", "no job for call_id"))
+			return
+		}
+		if err != nil {
+			reqLog.WithError(err).Error("load job failed")
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, job); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /jobs/{id}/retry — resume from the last successful stage; 409
+	// unless the job failed or is partial
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /jobs/{id}/retry", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		reqLog := logger.New().WithRequest(r).WithField("handler", "jobs.retry").WithField("job_id", id)
+		reqLog.Info("retry request received")
+
+		timeoutSec, err := queryPositiveInt(r, "timeout_sec", 40)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		res, err := processor.RetryJob(r.Context(), id, time.Duration(timeoutSec)*time.Second)
+		if err != nil && res.JobID == "" {
+			// the job could not even be loaded
+			writeError(w, err)
+			return
+		}
+		status := http.StatusOK
+		if err != nil {
+			status = apperr.KindOf(err).HTTPStatus()
+			reqLog.WithError(err).WithField("failed_stage", res.FailedStage).Warn("retry returned error")
+		}
+		if err := writeJSON(w, status, res); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
@@ -1,25 +1,34 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
-    "strconv"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/customer"
+	"voice-insights-go/internal/dataset"
+	"voice-insights-go/internal/extractor"
+	"voice-insights-go/internal/jobs"
 	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/notify"
 	"voice-insights-go/internal/processor"
 )
 
+// maxCallIDLen bounds the external call id accepted by /process.
+const maxCallIDLen = 128
+
 func main() {
 	_ = godotenv.Load() // loads .env
-    fmt.Println(">> DEBUG: SEARCH_API_URL =", os.Getenv("SEARCH_API_URL"))
-
 
 	log := logger.New()
 	log.WithField("service", "voice-insights-go").Info("starting service")
+	log.WithField("search_api_url", os.Getenv("SEARCH_API_URL")).Debug("search api configured")
 
 	mux := http.NewServeMux()
 
@@ -40,46 +49,129 @@ func main() {
 
 		audioURL := r.URL.Query().Get("audio_url")
 		if audioURL == "" {
-            reqLog.Warn("missing audio_url")
-			http.Error(w, "missing audio_url", http.StatusBadRequest)
+			reqLog.Warn("missing audio_url")
+			writeError(w, apperr.New(apperr.InvalidInput, "", "missing audio_url"))
+			return
+		}
+		if u, perr := url.Parse(audioURL); perr != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			reqLog.WithField("audio_url", audioURL).Warn("invalid audio_url")
+			writeError(w, apperr.New(apperr.InvalidInput, "", "audio_url must be an absolute http(s) URL"))
+			return
+		}
+
+		k, err := queryPositiveInt(r, "k", 3)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		timeoutSec, err := queryPositiveInt(r, "timeout_sec", 40)
+		if err != nil {
+			writeError(w, err)
 			return
 		}
 
-        k := 3
-        kstr := r.URL.Query().Get("k")
-        if kstr == "" {
-            parsedk, kerr := strconv.ParseInt(kstr, 10, 64)
-            if kerr == nil {
-                k = int(parsedk) // Convert int64 to int if desired
-            }
+		mode := r.URL.Query().Get("mode")
+		switch mode {
+		case "":
+			mode = extractor.ModeFromEnv()
+		case extractor.ModeSingle, extractor.ModeMultiPass:
+		default:
+			writeError(w, apperr.New(apperr.InvalidInput, "", "mode must be single or multipass"))
 			return
 		}
 
-		timeoutSec := 40
-		if t := r.URL.Query().Get("timeout_sec"); t != "" {
-			fmt.Sscanf(t, "%d", &timeoutSec)
+		job := jobs.New(audioURL, k, mode)
+		job.AgentID = strings.TrimSpace(r.URL.Query().Get("agent_id"))
+		job.Team = strings.TrimSpace(r.URL.Query().Get("team"))
+		job.City = strings.TrimSpace(r.URL.Query().Get("city"))
+		job.CallType = strings.TrimSpace(r.URL.Query().Get("call_type"))
+		job.CallID = strings.TrimSpace(r.URL.Query().Get("call_id"))
+		if len(job.CallID) > maxCallIDLen {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "call_id is too long"))
+			return
+		}
+		if v := r.URL.Query().Get("vintage_months"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, apperr.New(apperr.InvalidInput, "", "vintage_months must be a non-negative integer"))
+				return
+			}
+			job.VintageMonths = &n
+		}
+		if v := strings.TrimSpace(r.URL.Query().Get("call_time")); v != "" {
+			t, err := dataset.ParseTimestamp(v)
+			if err != nil {
+				writeError(w, apperr.Wrap(apperr.InvalidInput, "", "call_time", err))
+				return
+			}
+			job.CallTime = t.UTC()
+		}
+		if id := strings.TrimSpace(r.URL.Query().Get("dataset_id")); id != "" {
+			// the dataset's summary is injected as historical context and the call is added to it
+			if _, err := dataset.GetRegistered(id); err != nil {
+				writeError(w, apperr.Ensure(err, ""))
+				return
+			}
+			job.DatasetID = id
+		}
+		phone, acct, err := processCustomer(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if phone != "" || acct != "" {
+			// only the keyed hash is kept; it links this call to the customer's earlier ones
+			if err := customer.Attach(job, phone, acct); err != nil {
+				writeError(w, apperr.Ensure(err, ""))
+				return
+			}
 		}
 
-		reqLog = reqLog.WithField("audio_url", audioURL).WithField("timeout_sec", timeoutSec)
+		reqLog = reqLog.WithField("audio_url", audioURL).WithField("timeout_sec", timeoutSec).WithField("mode", mode).WithField("agent_id", job.AgentID)
 
 		start := time.Now()
-		res, err := processor.ProcessSingleCall(audioURL, k, time.Duration(timeoutSec)*2*time.Second)
+		// r.Context() is cancelled when the client disconnects, so abandoned
+		// requests stop polling/retrying against the vendors.
+		res, err := processor.Process(r.Context(), job, time.Duration(timeoutSec)*time.Second)
 		duration := time.Since(start)
 		reqLog.WithField("duration_ms", duration.Milliseconds()).Info("processor finished")
 
-		w.Header().Set("Content-Type", "application/json")
+		status := http.StatusOK
 		if err != nil {
-			reqLog.WithError(err).Warn("processor returned error")
-			w.WriteHeader(http.StatusInternalServerError)
+			status = apperr.KindOf(err).HTTPStatus()
+			reqLog.WithError(err).WithField("failed_stage", res.FailedStage).WithField("status", status).Warn("processor returned error")
 		}
 
-		enc := json.NewEncoder(w)
-		enc.SetIndent("", "  ")
-		if err := enc.Encode(res); err != nil {
+		if err := writeJSON(w, status, res); err != nil {
 			reqLog.WithError(err).Error("failed to write response")
 		}
 	})
 
+	registerJobRoutes(mux)
+	registerPIIRoutes(mux)
+	registerComplianceRoutes(mux)
+	registerScorecardRoutes(mux)
+	registerCoachingRoutes(mux)
+	registerTaxonomyRoutes(mux)
+	registerClassifierRoutes(mux)
+	registerDatasetRoutes(mux)
+	registerCohortRoutes(mux)
+	registerTrendRoutes(mux)
+	registerAnomalyRoutes(mux)
+	registerNotificationRoutes(mux)
+	registerTicketRoutes(mux)
+	registerOutcomeRoutes(mux)
+	registerCustomerRoutes(mux)
+	registerChurnRoutes(mux)
+
+	// deliveries a previous process left pending would otherwise never be sent
+	if n, err := notify.Resume(); err != nil {
+		log.WithError(err).Error("failed to resume pending notifications")
+	} else if n > 0 {
+		log.WithField("deliveries", n).Info("resumed pending notifications")
+	}
+
 	// --------------------------------------------------------------------
 	// SERVER SETUP
 	// --------------------------------------------------------------------
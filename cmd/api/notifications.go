@@ -0,0 +1,65 @@
+package main
+
+import (
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/notify"
+)
+
+// registerNotificationRoutes exposes the notification delivery log, manual retries and sink
+// tests.
+func registerNotificationRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /notifications/deliveries?status=&job_id= — the delivery log
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /notifications/deliveries", func(w http.ResponseWriter, r *http.Request) {
+		list, err := notify.List(r.URL.Query().Get("status"), r.URL.Query().Get("job_id"))
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list deliveries", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "notifications.list").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /notifications/deliveries/{id}/retry — resend a failed delivery
+	// (or a pending one whose sender stopped); 409 for any other status
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /notifications/deliveries/{id}/retry", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "notifications.retry")
+		d, err := notify.Redeliver(r.PathValue("id"))
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("delivery_id", d.ID).Info("delivery retry started")
+		if err := writeJSON(w, http.StatusAccepted, d); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /notifications/test?sink=id — send one sample event now
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /notifications/test", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "notifications.test")
+		sink := r.URL.Query().Get("sink")
+		status, err := notify.Test(r.Context(), sink)
+		if err != nil {
+			if kind := apperr.KindOf(err); kind == apperr.NotFound || kind == apperr.InvalidInput {
+				writeError(w, err)
+				return
+			}
+			writeError(w, apperr.Wrap(apperr.UpstreamFailure, "", "test delivery to "+sink, err))
+			return
+		}
+		reqLog.WithField("sink", sink).WithField("http_status", status).Info("test notification delivered")
+		if err := writeJSON(w, http.StatusOK, map[string]any{"sink": sink, "delivered": true, "http_status": status}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
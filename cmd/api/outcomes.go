@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/scorecard"
+)
+
+// maxOutcomeBatch bounds POST /outcomes.
+const maxOutcomeBatch = 500
+
+// registerOutcomeRoutes exposes outcome reporting for calls and the calibration of the
+// extraction's scores against those outcomes.
+func registerOutcomeRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// POST /jobs/{id}/outcome — record what happened after a call; fields
+	// left out keep their earlier value
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /jobs/{id}/outcome", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "outcomes.record").WithField("job_id", r.PathValue("id"))
+		var u outcome.Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		o, err := recordOutcome(r.PathValue("id"), "", u)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		reqLog.WithField("status", o.Status).Info("outcome recorded")
+		if err := writeJSON(w, http.StatusOK, o); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /calls/{call_id}/outcome — the same, addressed by the external
+	// call id given to /process; it applies to the call's newest job
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /calls/{call_id}/outcome", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "outcomes.record_call").WithField("call_id", r.PathValue("call_id"))
+		var u outcome.Update
+		if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		o, err := recordOutcome("", r.PathValue("call_id"), u)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		reqLog.WithField("job_id", o.JobID).WithField("status", o.Status).Info("outcome recorded")
+		if err := writeJSON(w, http.StatusOK, o); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /jobs/{id}/outcome — the recorded outcome of a call
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /jobs/{id}/outcome", func(w http.ResponseWriter, r *http.Request) {
+		o, err := outcome.Get(r.PathValue("id"))
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, o); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "outcomes.get").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /calls/{call_id}/outcome — the recorded outcome of a call by its
+	// external id
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /calls/{call_id}/outcome", func(w http.ResponseWriter, r *http.Request) {
+		job, err := loadOutcomeJob("", r.PathValue("call_id"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		o, err := outcome.Get(job.ID)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, o); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "outcomes.get_call").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /outcomes — record a batch: [{"job_id": ..., "status": ...}],
+	// each item addressed by job_id or call_id; each item reports its own
+	// outcome or error
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /outcomes", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "outcomes.batch")
+		var items []struct {
+			JobID  string `json:"job_id"`
+			CallID string `json:"call_id"`
+			outcome.Update
+		}
+		if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		if len(items) > maxOutcomeBatch {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "too many outcomes in one batch"))
+			return
+		}
+		type result struct {
+			JobID   string           `json:"job_id,omitempty"`
+			CallID  string           `json:"call_id,omitempty"`
+			Outcome *outcome.Outcome `json:"outcome,omitempty"`
+			Error   string           `json:"error,omitempty"`
+		}
+		results := make([]result, 0, len(items))
+		failed := 0
+		for _, it := range items {
+			o, err := recordOutcome(it.JobID, it.CallID, it.Update)
+			if err != nil {
+				failed++
+				results = append(results, result{JobID: it.JobID, CallID: it.CallID, Error: err.Error()})
+				continue
+			}
+			results = append(results, result{JobID: o.JobID, CallID: it.CallID, Outcome: &o})
+		}
+		reqLog.WithField("outcomes", len(items)).WithField("failed", failed).Info("outcome batch recorded")
+		if err := writeJSON(w, http.StatusOK, results); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /outcomes?status= — recorded outcomes, most recent call first
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /outcomes", func(w http.ResponseWriter, r *http.Request) {
+		list, err := outcome.List(r.URL.Query().Get("status"))
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list outcomes", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "outcomes.list").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /outcomes/calibration?bins=10 — predicted resolution likelihood
+	// and churn risk against actual outcomes for calls in the window (last
+	// 90 days unless week, from/to or days is given); format=markdown
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /outcomes/calibration", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		reqLog := logger.New().WithRequest(r).WithField("handler", "outcomes.calibration")
+		win := scorecard.LastDays(90, time.Now().UTC())
+		if q.Has("week") || q.Has("from") || q.Has("to") || q.Has("days") {
+			var err error
+			if win, err = parseWindow(r); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		bins, err := queryPositiveInt(r, "bins", outcome.DefaultBins)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if bins > 100 {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "bins must be at most 100"))
+			return
+		}
+		all, err := outcome.List("")
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list outcomes", err))
+			return
+		}
+		rep := outcome.Calibrate(all, win, bins)
+		reqLog.WithField("outcomes", rep.Outcomes).Info("calibration built")
+
+		if q.Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte(outcome.Markdown(rep)))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, rep); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
+
+// recordOutcome loads the job, by job id or else by external call id, and records u
+// against it.
+func recordOutcome(jobID, callID string, u outcome.Update) (outcome.Outcome, error) {
+	job, err := loadOutcomeJob(jobID, callID)
+	if err != nil {
+		return outcome.Outcome{}, err
+	}
+	o, err := outcome.Record(job, u)
+	if err != nil {
+		return o, apperr.Ensure(err, "")
+	}
+	return o, nil
+}
+
+// loadOutcomeJob resolves the job an outcome is addressed to.
+func loadOutcomeJob(jobID, callID string) (*jobs.Job, error) {
+	var (
+		job *jobs.Job
+		err error
+	)
+	switch {
+	case jobID != "":
+		job, err = jobs.Get(jobID)
+	case callID != "":
+		job, err = jobs.ByCallID(callID)
+	default:
+		return nil, apperr.New(apperr.InvalidInput, "", "job_id or call_id is required")
+	}
+	if errors.Is(err, jobs.ErrNotFound) {
+		return nil, apperr.New(apperr.NotFound, "", "job not found")
+	}
+	if err != nil {
+		return nil, apperr.Wrap(apperr.Internal, "", "load job", err)
+	}
+	return job, nil
+}
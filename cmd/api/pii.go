@@ -0,0 +1,91 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/redact"
+)
+
+type detokenizeRequest struct {
+	Text  string `json:"text"`
+	JobID string `json:"job_id"`
+}
+
+type detokenizeResponse struct {
+	Text     string `json:"text"`
+	Revealed int    `json:"revealed"`
+}
+
+// registerPIIRoutes exposes reversal of tokenized PII to authorized viewers.
+func registerPIIRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// POST /pii/detokenize — reveal vault tokens in text or a job's transcript
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /pii/detokenize", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "pii.detokenize")
+		if !authorizedViewer(r) {
+			reqLog.Warn("detokenize rejected: missing or invalid viewer token")
+			writeError(w, apperr.New(apperr.Forbidden, "", "a valid PII viewer token is required"))
+			return
+		}
+
+		var req detokenizeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		text := req.Text
+		if req.JobID != "" {
+			job, err := jobs.Get(req.JobID)
+			if errors.Is(err, jobs.ErrNotFound) {
+				writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+				return
+			}
+			if err != nil {
+				writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+				return
+			}
+			text = job.Artifacts.Transcript
+		}
+		if text == "" {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "text or job_id is required"))
+			return
+		}
+
+		vault, err := redact.DefaultVault()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "open PII vault", err))
+			return
+		}
+		out, n, err := vault.Detokenize(text)
+		if err != nil {
+			reqLog.WithError(err).Error("detokenize failed")
+			writeError(w, apperr.Wrap(apperr.Internal, "", "detokenize", err))
+			return
+		}
+		// audit trail: who asked is the request log, what was revealed is only the count
+		reqLog.WithField("job_id", req.JobID).WithField("revealed", n).Info("PII detokenized for viewer")
+		if err := writeJSON(w, http.StatusOK, detokenizeResponse{Text: out, Revealed: n}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
+
+// authorizedViewer checks "Authorization: Bearer <PII_VIEWER_TOKEN>". With no token
+// configured, detokenization is disabled entirely.
+func authorizedViewer(r *http.Request) bool {
+	want := os.Getenv("PII_VIEWER_TOKEN")
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if want == "" || !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
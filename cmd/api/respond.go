@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/types"
+)
+
+// errorResponse is the body written for requests that fail before producing a result.
+// It shares the "error"/"error_info" keys with types.KPIResult so clients parse one shape.
+type errorResponse struct {
+	Error     string           `json:"error"`
+	ErrorInfo *types.ErrorInfo `json:"error_info"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	info := apperr.Info(err)
+	_ = writeJSON(w, apperr.KindOf(err).HTTPStatus(), errorResponse{Error: info.Message, ErrorInfo: info})
+}
+
+// queryPositiveInt reads an optional positive integer query parameter.
+func queryPositiveInt(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, apperr.New(apperr.InvalidInput, "", name+" must be a positive integer")
+	}
+	return n, nil
+}
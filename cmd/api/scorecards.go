@@ -0,0 +1,120 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/scorecard"
+)
+
+// registerScorecardRoutes exposes per-agent and per-team scorecards.
+func registerScorecardRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /agents/{id}/scorecard — one agent, ranked against their team
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /agents/{id}/scorecard", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		reqLog := logger.New().WithRequest(r).WithField("handler", "agents.scorecard").WithField("agent_id", id)
+		win, err := parseWindow(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		all, err := jobs.ListWhere(func(j *jobs.Job) bool { return scorecard.Scored(j, win) })
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list jobs", err))
+			return
+		}
+		card, ok := scorecard.ForAgent(all, id, win)
+		if !ok {
+			writeError(w, apperr.New(apperr.NotFound, "", "no processed calls for agent in window"))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, card); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /teams/{team}/scorecards — every agent of a team; format=markdown
+	// renders the weekly report for team leads
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /teams/{team}/scorecards", func(w http.ResponseWriter, r *http.Request) {
+		team := r.PathValue("team")
+		reqLog := logger.New().WithRequest(r).WithField("handler", "teams.scorecards").WithField("team", team)
+		win, err := parseWindow(r)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		all, err := jobs.ListWhere(func(j *jobs.Job) bool { return scorecard.Scored(j, win) })
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list jobs", err))
+			return
+		}
+		cards := scorecard.ForTeam(all, team, win)
+		reqLog.WithField("agents", len(cards)).Info("team scorecards built")
+
+		if r.URL.Query().Get("format") == "markdown" {
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			_, _ = w.Write([]byte(scorecard.TeamMarkdown(team, win, cards)))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, map[string]any{"team": team, "window": win, "agents": cards}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
+
+// parseWindow reads the aggregation window: week=<any date in the week>, or from/to
+// (YYYY-MM-DD, to inclusive, or RFC3339), or days=N. The default is the last 7 days. Dates
+// are IST calendar days, as on trend series.
+func parseWindow(r *http.Request) (scorecard.Window, error) {
+	q := r.URL.Query()
+	now := time.Now().UTC()
+	if v := q.Get("week"); v != "" {
+		d, err := time.ParseInLocation("2006-01-02", v, scorecard.IST)
+		if err != nil {
+			return scorecard.Window{}, apperr.New(apperr.InvalidInput, "", "week must be a YYYY-MM-DD date")
+		}
+		return scorecard.Week(d), nil
+	}
+	if q.Get("from") != "" || q.Get("to") != "" {
+		win := scorecard.LastDays(7, now)
+		var err error
+		if v := q.Get("from"); v != "" {
+			if win.From, _, err = parseTime(v); err != nil {
+				return win, apperr.New(apperr.InvalidInput, "", "from must be YYYY-MM-DD or RFC3339")
+			}
+		}
+		if v := q.Get("to"); v != "" {
+			var dateOnly bool
+			if win.To, dateOnly, err = parseTime(v); err != nil {
+				return win, apperr.New(apperr.InvalidInput, "", "to must be YYYY-MM-DD or RFC3339")
+			}
+			if dateOnly {
+				win.To = win.To.AddDate(0, 0, 1)
+			}
+		}
+		if !win.From.Before(win.To) {
+			return win, apperr.New(apperr.InvalidInput, "", "from must be before to")
+		}
+		return win, nil
+	}
+	days, err := queryPositiveInt(r, "days", 7)
+	if err != nil {
+		return scorecard.Window{}, err
+	}
+	return scorecard.LastDays(days, now), nil
+}
+
+func parseTime(v string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", v, scorecard.IST); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	return t, false, err
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/taxonomy"
+)
+
+// registerTaxonomyRoutes exposes the issue taxonomy and edits to it.
+func registerTaxonomyRoutes(mux *http.ServeMux) {
+	respond := func(w http.ResponseWriter, r *http.Request, handler string, t *taxonomy.Taxonomy, err error) {
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, t); err != nil {
+			logger.New().WithRequest(r).WithField("handler", handler).WithError(err).Error("failed to write response")
+		}
+	}
+	decodeNode := func(w http.ResponseWriter, r *http.Request) (taxonomy.Node, bool) {
+		var n taxonomy.Node
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return n, false
+		}
+		return n, true
+	}
+
+	// --------------------------------------------------------------------
+	// GET /taxonomy — the active taxonomy with its version
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /taxonomy", func(w http.ResponseWriter, r *http.Request) {
+		t, err := taxonomy.Current()
+		respond(w, r, "taxonomy.get", t, err)
+	})
+
+	// --------------------------------------------------------------------
+	// POST /taxonomy/nodes, PUT|DELETE /taxonomy/nodes/{id} — edits; every
+	// edit is validated, bumps the version and is persisted
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /taxonomy/nodes", func(w http.ResponseWriter, r *http.Request) {
+		n, ok := decodeNode(w, r)
+		if !ok {
+			return
+		}
+		t, err := taxonomy.AddNode(n)
+		respond(w, r, "taxonomy.add", t, err)
+	})
+	mux.HandleFunc("PUT /taxonomy/nodes/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		n, ok := decodeNode(w, r)
+		if !ok {
+			return
+		}
+		t, err := taxonomy.UpdateNode(r.PathValue("id"), n)
+		respond(w, r, "taxonomy.update", t, err)
+	})
+	mux.HandleFunc("DELETE /taxonomy/nodes/{id...}", func(w http.ResponseWriter, r *http.Request) {
+		t, err := taxonomy.DeleteNode(r.PathValue("id"))
+		respond(w, r, "taxonomy.delete", t, err)
+	})
+
+	// --------------------------------------------------------------------
+	// POST /taxonomy/normalize — map one free-form issue string
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /taxonomy/normalize", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "taxonomy.normalize")
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, apperr.Wrap(apperr.InvalidInput, "", "invalid JSON body", err))
+			return
+		}
+		res, err := taxonomy.Normalize(r.Context(), req.Text)
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, res); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/ticketing"
+)
+
+// registerTicketRoutes exposes the tickets opened for call actions and a manual sync.
+func registerTicketRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /tickets?status=&job_id= — stored tickets, newest first
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /tickets", func(w http.ResponseWriter, r *http.Request) {
+		list, err := ticketing.List(r.URL.Query().Get("job_id"), r.URL.Query().Get("status"))
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list tickets", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "tickets.list").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// GET /jobs/{id}/tickets — tickets opened for the job's call, including
+	// ones opened when the call was processed before
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /jobs/{id}/tickets", func(w http.ResponseWriter, r *http.Request) {
+		job, err := jobs.Get(r.PathValue("id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+			return
+		}
+		list, err := ticketing.ForJob(job)
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "list tickets", err))
+			return
+		}
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			logger.New().WithRequest(r).WithField("handler", "tickets.job").WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /jobs/{id}/tickets — open the call's tickets now; created ones
+	// are returned as they are, failed ones are attempted again
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /jobs/{id}/tickets", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "tickets.sync").WithField("job_id", r.PathValue("id"))
+		b, err := ticketing.Configured()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "ticketing backend", err))
+			return
+		}
+		if b == nil {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "ticketing is off; set TICKETS_BACKEND"))
+			return
+		}
+		job, err := jobs.Get(r.PathValue("id"))
+		if errors.Is(err, jobs.ErrNotFound) {
+			writeError(w, apperr.New(apperr.NotFound, "", "job not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "load job", err))
+			return
+		}
+		if job.Status != jobs.StatusCompleted && job.Status != jobs.StatusPartial {
+			writeError(w, apperr.New(apperr.InvalidInput, "", "job has not completed"))
+			return
+		}
+		list, err := ticketing.Sync(r.Context(), b, job)
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "sync tickets", err))
+			return
+		}
+		reqLog.WithField("tickets", len(list)).Info("tickets synced")
+		if err := writeJSON(w, http.StatusOK, list); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
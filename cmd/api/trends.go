@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/scorecard"
+	"voice-insights-go/internal/trends"
+)
+
+// registerTrendRoutes exposes KPI and issue-volume time series built from the daily rollups.
+func registerTrendRoutes(mux *http.ServeMux) {
+	// --------------------------------------------------------------------
+	// GET /trends — series per issue, city or team (group_by) of one metric
+	// at granularity=day|week, with moving averages (ma=N periods, at most
+	// 90 days or 52 weeks) and week-over-week change; filters issue, city,
+	// team; window as for scorecards, default the last 8 weeks
+	// --------------------------------------------------------------------
+	mux.HandleFunc("GET /trends", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		reqLog := logger.New().WithRequest(r).WithField("handler", "trends")
+		win := scorecard.LastDays(56, time.Now().UTC())
+		if q.Has("week") || q.Has("from") || q.Has("to") || q.Has("days") {
+			var err error
+			if win, err = parseWindow(r); err != nil {
+				writeError(w, err)
+				return
+			}
+		}
+		ma, err := queryPositiveInt(r, "ma", 0)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		limit, err := queryPositiveInt(r, "limit", 10)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		res, err := trends.Build(trends.Query{
+			Window:        win,
+			Granularity:   q.Get("granularity"),
+			GroupBy:       q.Get("group_by"),
+			Metric:        q.Get("metric"),
+			Issue:         q.Get("issue"),
+			City:          q.Get("city"),
+			Team:          q.Get("team"),
+			MovingAverage: ma,
+			Limit:         limit,
+		})
+		if err != nil {
+			writeError(w, apperr.Ensure(err, ""))
+			return
+		}
+		reqLog.WithField("series", len(res.Series)).WithField("metric", res.Metric).Info("trends built")
+		if err := writeJSON(w, http.StatusOK, res); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+
+	// --------------------------------------------------------------------
+	// POST /trends/rebuild — recompute every daily rollup from stored jobs
+	// --------------------------------------------------------------------
+	mux.HandleFunc("POST /trends/rebuild", func(w http.ResponseWriter, r *http.Request) {
+		reqLog := logger.New().WithRequest(r).WithField("handler", "trends.rebuild")
+		n, err := trends.Rebuild()
+		if err != nil {
+			writeError(w, apperr.Wrap(apperr.Internal, "", "rebuild trend rollups", err))
+			return
+		}
+		reqLog.WithField("calls", n).Info("trend rollups rebuilt")
+		if err := writeJSON(w, http.StatusOK, map[string]int{"calls": n}); err != nil {
+			reqLog.WithError(err).Error("failed to write response")
+		}
+	})
+}
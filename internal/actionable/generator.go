@@ -33,3 +33,26 @@ func Generate(ins aggregator.Insight) ActionCard {
 		Impact:  "Low immediate intervention",
 	}
 }
+
+// Spike is an unusual surge of calls about one issue, optionally in one city.
+type Spike struct {
+	Issue    string
+	City     string
+	Day      string
+	Observed int
+	Expected float64
+	Z        float64
+}
+
+// ForSpike turns a detected surge into a card for the team that owns the issue.
+func ForSpike(s Spike) ActionCard {
+	where := "across all cities"
+	if s.City != "" {
+		where = "in " + s.City
+	}
+	return ActionCard{
+		Insight: fmt.Sprintf("Spike in %q calls %s on %s: %d vs %.1f expected (z=%.1f)", s.Issue, where, s.Day, s.Observed, s.Expected, s.Z),
+		Action:  "Check releases, incidents and campaigns touching this issue; give agents a holding script and open an incident if it is a product bug",
+		Impact:  "Contain the surge before it turns into repeat calls and escalations",
+	}
+}
@@ -1,12 +1,28 @@
 package aggregator
 
-import "voice-insights-go/internal/types"
+import (
+	"voice-insights-go/internal/cohort"
+	"voice-insights-go/internal/types"
+)
 
 type Insight struct {
 	ConfusionByVintage map[string]float64 `json:"confusion_by_vintage"`
 	CategoryCounts     map[string]int     `json:"category_counts"`
 }
 
+// Enrich labels a call record with its vintage cohort from cfg; a record without a usable
+// vintage is Unknown, as in dataset summaries.
+func Enrich(r types.CallRecord, cls types.Classification, cfg *cohort.Config) types.EnrichedRecord {
+	return types.EnrichedRecord{
+		CallID:        r.CallID,
+		VintageBucket: cfg.VintageOf(r.VintageMonth),
+		IsConfused:    cls.Confused,
+		Category:      cls.Category,
+	}
+}
+
+// Aggregate rates confusion per vintage bucket and counts categories. Records should be
+// labelled with Enrich so buckets match dataset summaries and cohort comparisons.
 func Aggregate(records []types.EnrichedRecord) Insight {
 	total := map[string]int{}
 	confused := map[string]int{}
@@ -0,0 +1,291 @@
+// Package anomaly flags statistically significant spikes in the daily issue volumes kept by
+// the trends rollups, per issue and per issue and city.
+package anomaly
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/actionable"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/trends"
+)
+
+const collection = "anomaly_alerts"
+
+// Detection methods.
+const (
+	MethodEWMA     = "ewma"     // exponentially weighted mean and variance of the previous days
+	MethodSeasonal = "seasonal" // mean and spread of the same weekday in previous weeks
+)
+
+// maxExamples is how many example calls an alert carries.
+const maxExamples = 3
+
+// Options configure detection.
+type Options struct {
+	Method string
+	// Threshold is the z-score a day's volume must reach
+	Threshold float64
+	// MinCalls keeps a handful of calls on a quiet issue from alerting
+	MinCalls int
+	// Lookback is how many days before the checked day form the baseline
+	Lookback int
+	// Alpha is the EWMA smoothing factor
+	Alpha float64
+}
+
+// OptionsFromEnv reads ANOMALY_METHOD (ewma|seasonal, default ewma), ANOMALY_Z_THRESHOLD
+// (default 3), ANOMALY_MIN_CALLS (default 5), ANOMALY_LOOKBACK_DAYS (default 28) and
+// ANOMALY_EWMA_ALPHA (default 0.3).
+func OptionsFromEnv() Options {
+	o := Options{Method: MethodEWMA, Threshold: 3, MinCalls: 5, Lookback: 28, Alpha: 0.3}
+	if os.Getenv("ANOMALY_METHOD") == MethodSeasonal {
+		o.Method = MethodSeasonal
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ANOMALY_Z_THRESHOLD"), 64); err == nil && v > 0 {
+		o.Threshold = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("ANOMALY_MIN_CALLS")); err == nil && v > 0 {
+		o.MinCalls = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("ANOMALY_LOOKBACK_DAYS")); err == nil && v >= 7 {
+		o.Lookback = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("ANOMALY_EWMA_ALPHA"), 64); err == nil && v > 0 && v < 1 {
+		o.Alpha = v
+	}
+	return o
+}
+
+// Example is a call counted in a spike. The primary issue is the model's summary, so no
+// transcript text leaves the job store.
+type Example struct {
+	JobID        string    `json:"job_id"`
+	AudioURL     string    `json:"audio_url"`
+	CallTime     time.Time `json:"call_time"`
+	City         string    `json:"city,omitempty"`
+	PrimaryIssue string    `json:"primary_issue"`
+}
+
+// Alert is a spike of one issue on one day, across all cities when City is empty. It is
+// updated in place while the day's volume keeps growing.
+type Alert struct {
+	ID        string   `json:"id"`
+	Day       string   `json:"day"`
+	Issue     string   `json:"issue"`
+	IssuePath []string `json:"issue_path"`
+	City      string   `json:"city,omitempty"`
+	Observed  int      `json:"observed"`
+	Expected  float64  `json:"expected"`
+	StdDev    float64  `json:"std_dev"`
+	Z         float64  `json:"z"`
+	Method    string   `json:"method"`
+
+	Examples        []Example             `json:"examples"`
+	Card            actionable.ActionCard `json:"action_card"`
+	FirstDetectedAt time.Time             `json:"first_detected_at"`
+	UpdatedAt       time.Time             `json:"updated_at"`
+}
+
+// series is the daily volume of one issue, optionally in one city.
+type series struct {
+	issue, city string
+	counts      []float64 // one per day, the checked day last
+}
+
+// Detect checks every issue and issue-city volume of the IST day containing day against its
+// baseline and stores an alert for each spike.
+func Detect(day time.Time, opts Options) ([]Alert, error) {
+	return detect(day, opts, func(string, string) bool { return true })
+}
+
+// CheckCall re-checks only the series a just-recorded call counts towards, so a surge is
+// flagged while it is happening.
+func CheckCall(j *jobs.Job, opts Options) ([]Alert, error) {
+	c := trends.CellOf(j)
+	return detect(j.At(), opts, func(issue, city string) bool {
+		return issue == c.Issue && (city == "" || city == c.City)
+	})
+}
+
+func detect(day time.Time, opts Options, keep func(issue, city string) bool) ([]Alert, error) {
+	log := logger.New().WithField("component", "anomaly")
+	last := trends.DayStart(day)
+	days, err := trends.Days(last.AddDate(0, 0, -opts.Lookback), last)
+	if err != nil {
+		return nil, err
+	}
+	n := len(days)
+
+	all := map[string]*series{}
+	for i, d := range days {
+		for _, c := range d.Cells {
+			for _, city := range []string{"", c.City} {
+				if !keep(c.Issue, city) {
+					continue
+				}
+				key := c.Issue + "\x1f" + city
+				s := all[key]
+				if s == nil {
+					s = &series{issue: c.Issue, city: city, counts: make([]float64, n)}
+					all[key] = s
+				}
+				s.counts[i] += float64(c.Calls)
+			}
+		}
+	}
+
+	var out []Alert
+	for _, s := range all {
+		observed := s.counts[n-1]
+		if int(observed) < opts.MinCalls {
+			continue
+		}
+		mean, sd, ok := baseline(s.counts[:n-1], opts)
+		if !ok {
+			continue
+		}
+		// counts are roughly Poisson, so never trust a spread below sqrt(mean)
+		sd = math.Max(sd, math.Sqrt(math.Max(mean, 1)))
+		z := (observed - mean) / sd
+		if z < opts.Threshold {
+			continue
+		}
+		a, err := save(days[n-1], s, Alert{Observed: int(observed), Expected: mean, StdDev: sd, Z: z, Method: opts.Method})
+		if err != nil {
+			return out, err
+		}
+		log.WithFields(map[string]interface{}{"issue": a.Issue, "city": a.City, "observed": a.Observed, "expected": a.Expected, "z": a.Z}).Warn("issue volume spike detected")
+		out = append(out, a)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Z > out[j].Z })
+	return out, nil
+}
+
+// baseline is the expected volume and its spread from history, the days before the
+// checked one. ok is false when there is too little history to judge.
+func baseline(history []float64, opts Options) (mean, sd float64, ok bool) {
+	if opts.Method == MethodSeasonal {
+		var same []float64
+		for i := len(history) - 7; i >= 0; i -= 7 {
+			same = append(same, history[i])
+		}
+		if len(same) < 2 {
+			return 0, 0, false
+		}
+		for _, v := range same {
+			mean += v
+		}
+		mean /= float64(len(same))
+		var ss float64
+		for _, v := range same {
+			ss += (v - mean) * (v - mean)
+		}
+		return mean, math.Sqrt(ss / float64(len(same)-1)), true
+	}
+
+	if len(history) < 7 {
+		return 0, 0, false
+	}
+	mean = history[0]
+	var variance float64
+	for _, v := range history[1:] {
+		diff := v - mean
+		mean += opts.Alpha * diff
+		variance = (1 - opts.Alpha) * (variance + opts.Alpha*diff*diff)
+	}
+	return mean, math.Sqrt(variance), true
+}
+
+// save fills in the alert for s on day d, keeping when it was first detected, and stores it.
+func save(d *trends.Day, s *series, a Alert) (Alert, error) {
+	st, err := store.Default()
+	if err != nil {
+		return a, err
+	}
+	a.ID = strings.Join([]string{d.Day, s.issue, s.city}, "|")
+	a.Day, a.Issue, a.City = d.Day, s.issue, s.city
+	if t, err := taxonomy.Current(); err == nil {
+		a.IssuePath = t.Path(s.issue)
+	}
+	if a.IssuePath == nil {
+		a.IssuePath = []string{}
+	}
+
+	a.Examples = []Example{}
+	ids := d.JobIDs(func(c *trends.Cell) bool { return c.Issue == s.issue && (s.city == "" || c.City == s.city) })
+	for _, id := range ids {
+		if len(a.Examples) == maxExamples {
+			break
+		}
+		j, err := jobs.Get(id)
+		if err != nil {
+			continue // a deleted job just is not an example
+		}
+		a.Examples = append(a.Examples, Example{
+			JobID:        j.ID,
+			AudioURL:     j.AudioURL,
+			CallTime:     j.At(),
+			City:         j.City,
+			PrimaryIssue: j.Result.KPI.CustomerProblem.PrimaryIssue,
+		})
+	}
+
+	name := s.issue
+	if len(a.IssuePath) > 0 {
+		name = strings.Join(a.IssuePath, " > ")
+	}
+	a.Card = actionable.ForSpike(actionable.Spike{Issue: name, City: s.city, Day: d.Day, Observed: a.Observed, Expected: a.Expected, Z: a.Z})
+
+	now := time.Now().UTC()
+	a.FirstDetectedAt, a.UpdatedAt = now, now
+	var prev Alert
+	if err := st.Get(collection, a.ID, &prev); err == nil {
+		a.FirstDetectedAt = prev.FirstDetectedAt
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return a, fmt.Errorf("load alert: %w", err)
+	}
+	if err := st.Put(collection, a.ID, a); err != nil {
+		return a, fmt.Errorf("save alert: %w", err)
+	}
+	return a, nil
+}
+
+// List returns the stored alerts for days in [from, to), strongest first.
+func List(from, to time.Time) ([]Alert, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	first := trends.DayStart(from).Format("2006-01-02")
+	last := trends.DayStart(to.Add(-time.Nanosecond)).Format("2006-01-02")
+	out := []Alert{}
+	err = st.List(collection, func(_ string, raw json.RawMessage) error {
+		var a Alert
+		if err := json.Unmarshal(raw, &a); err != nil {
+			return err
+		}
+		if a.Day >= first && a.Day <= last {
+			out = append(out, a)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Day != out[j].Day {
+			return out[i].Day > out[j].Day
+		}
+		return out[i].Z > out[j].Z
+	})
+	return out, err
+}
@@ -0,0 +1,129 @@
+package anomaly
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/trends"
+	"voice-insights-go/internal/types"
+)
+
+func TestBaseline(t *testing.T) {
+	ewma := Options{Method: MethodEWMA, Alpha: 0.3}
+	seasonal := Options{Method: MethodSeasonal}
+	flat := func(n int, v float64) []float64 {
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = v
+		}
+		return out
+	}
+	tests := []struct {
+		name     string
+		history  []float64
+		opts     Options
+		mean, sd float64
+		tol      float64
+		ok       bool
+	}{
+		{"ewma flat", flat(14, 4), ewma, 4, 0, 1e-9, true},
+		{"ewma too short", flat(6, 4), ewma, 0, 0, 0, false},
+		// the EWMA only approaches a new level, and the step leaves some variance behind
+		{"ewma follows a level shift", append(flat(10, 2), flat(10, 10)...), ewma, 9.774, 1.325, 0.001, true},
+		{"seasonal same weekday", []float64{9, 1, 1, 1, 1, 1, 1, 3, 1, 1, 1, 1, 1, 1}, seasonal, 6, math.Sqrt(18), 1e-9, true},
+		{"seasonal one week", flat(7, 1), seasonal, 0, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mean, sd, ok := baseline(tt.history, tt.opts)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && (math.Abs(mean-tt.mean) > tt.tol || math.Abs(sd-tt.sd) > tt.tol) {
+				t.Errorf("baseline = %v ± %v, want %v ± %v (within %v)", mean, sd, tt.mean, tt.sd, tt.tol)
+			}
+		})
+	}
+}
+
+func record(t *testing.T, at time.Time, issue, city string) {
+	t.Helper()
+	j := jobs.New("https://calls/x.wav", 3, "single")
+	j.Status, j.CallTime, j.City = jobs.StatusCompleted, at, city
+	j.Result.Classification = &types.Classification{Category: issue}
+	j.Result.KPI.CustomerProblem.PrimaryIssue = "leads are fake"
+	if err := jobs.Save(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := trends.Record(j); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectSpike(t *testing.T) {
+	testutil.DataDir(t)
+	spike := time.Date(2026, 5, 29, 12, 0, 0, 0, time.UTC)
+	for d := 21; d >= 1; d-- {
+		day := spike.AddDate(0, 0, -d)
+		record(t, day, "lead_quality/fake_enquiries", "pune")
+		record(t, day, "billing/refund", "delhi")
+	}
+	for i := 0; i < 12; i++ {
+		record(t, spike, "lead_quality/fake_enquiries", "pune")
+	}
+	for i := 0; i < 3; i++ {
+		record(t, spike, "billing/refund", "delhi") // above baseline but under MinCalls
+	}
+
+	opts := Options{Method: MethodEWMA, Threshold: 3, MinCalls: 5, Lookback: 28, Alpha: 0.3}
+	alerts, err := Detect(spike, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("got %d alerts %+v, want the issue overall and in pune", len(alerts), alerts)
+	}
+	cities := map[string]bool{}
+	for _, a := range alerts {
+		cities[a.City] = true
+		if a.Issue != "lead_quality/fake_enquiries" || a.Observed != 12 || a.Z < opts.Threshold || a.Day != "2026-05-29" {
+			t.Errorf("alert = %+v", a)
+		}
+		if len(a.Examples) != maxExamples || a.Examples[0].PrimaryIssue != "leads are fake" {
+			t.Errorf("examples = %+v", a.Examples)
+		}
+		if len(a.IssuePath) == 0 {
+			t.Errorf("issue path missing for %s", a.Issue)
+		}
+	}
+	if !cities[""] || !cities["pune"] {
+		t.Errorf("alert cities = %v, want overall and pune", cities)
+	}
+
+	// a later check of the same day updates the alert but keeps when it was first seen
+	first := alerts[0].FirstDetectedAt
+	record(t, spike, "lead_quality/fake_enquiries", "pune")
+	again, err := CheckCall(&jobs.Job{CallTime: spike, City: "pune", Result: types.KPIResult{Classification: &types.Classification{Category: "lead_quality/fake_enquiries"}}}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 2 || again[0].Observed != 13 {
+		t.Fatalf("recheck = %+v", again)
+	}
+	for _, a := range again {
+		if a.ID == alerts[0].ID && !a.FirstDetectedAt.Equal(first) {
+			t.Errorf("first detected moved from %v to %v", first, a.FirstDetectedAt)
+		}
+	}
+
+	listed, err := List(spike.AddDate(0, 0, -1), spike.AddDate(0, 0, 1))
+	if err != nil || len(listed) != 2 {
+		t.Errorf("List = %d alerts, err %v; want 2", len(listed), err)
+	}
+	if none, _ := List(spike.AddDate(0, 0, -10), spike.AddDate(0, 0, -5)); len(none) != 0 {
+		t.Errorf("List outside the spike = %+v", none)
+	}
+}
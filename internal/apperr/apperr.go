@@ -0,0 +1,186 @@
+package apperr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"unicode/utf8"
+
+	"voice-insights-go/internal/types"
+)
+
+// Kind classifies a failure so callers can decide whether to retry.
+type Kind string
+
+const (
+	InvalidInput     Kind = "invalid_input"
+	NotFound         Kind = "not_found"
+	Forbidden        Kind = "forbidden"
+	Conflict         Kind = "conflict"
+	UpstreamTimeout  Kind = "upstream_timeout"
+	UpstreamRejected Kind = "upstream_rejected"
+	UpstreamFailure  Kind = "upstream_failure"
+	QuotaExceeded    Kind = "quota_exceeded"
+	ParseFailure     Kind = "parse_failure"
+	Canceled         Kind = "canceled"
+	Internal         Kind = "internal"
+)
+
+// StatusClientClosedRequest is the nginx convention for a client that went away mid-request.
+const StatusClientClosedRequest = 499
+
+// Error is the typed error returned by every pipeline stage.
+type Error struct {
+	Kind  Kind
+	Stage types.Stage
+	Msg   string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	prefix := string(e.Kind)
+	if e.Stage != "" {
+		prefix = string(e.Stage) + ": " + prefix
+	}
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %s: %v", prefix, e.Msg, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", prefix, e.Msg)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// New builds a typed error without a cause.
+func New(kind Kind, stage types.Stage, msg string) *Error {
+	return &Error{Kind: kind, Stage: stage, Msg: msg}
+}
+
+// Wrap builds a typed error around cause.
+func Wrap(kind Kind, stage types.Stage, msg string, cause error) *Error {
+	return &Error{Kind: kind, Stage: stage, Msg: msg, Err: cause}
+}
+
+// FromHTTPStatus classifies a non-2xx response from a vendor API.
+func FromHTTPStatus(stage types.Stage, status int, body string) *Error {
+	msg := fmt.Sprintf("upstream returned %d", status)
+	if body != "" {
+		msg = fmt.Sprintf("%s: %s", msg, truncate(body, 300))
+	}
+	switch {
+	case status == http.StatusTooManyRequests:
+		return New(QuotaExceeded, stage, msg)
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return New(UpstreamTimeout, stage, msg)
+	case status >= 400 && status < 500:
+		return New(UpstreamRejected, stage, msg)
+	default:
+		return New(UpstreamFailure, stage, msg)
+	}
+}
+
+// FromTransport classifies an error returned by http.Client.Do or a cancelled context.
+func FromTransport(stage types.Stage, err error) *Error {
+	var ne net.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Wrap(Canceled, stage, "request cancelled", err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return Wrap(UpstreamTimeout, stage, "deadline exceeded", err)
+	case errors.As(err, &ne) && ne.Timeout():
+		return Wrap(UpstreamTimeout, stage, "upstream timed out", err)
+	default:
+		return Wrap(UpstreamFailure, stage, "upstream request failed", err)
+	}
+}
+
+// Ensure returns err as a typed error, stamping stage when the origin did not know it.
+// Untyped errors become Internal unless they carry a context cancellation.
+func Ensure(err error, stage types.Stage) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		if e.Stage != "" {
+			return e
+		}
+		cp := *e
+		cp.Stage = stage
+		return &cp
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return FromTransport(stage, err)
+	}
+	return Wrap(Internal, stage, "unexpected error", err)
+}
+
+// KindOf returns the kind of err, or Internal for untyped errors.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return Internal
+}
+
+// Retryable reports whether the same request may succeed if sent again later.
+func (k Kind) Retryable() bool {
+	switch k {
+	case UpstreamTimeout, UpstreamFailure, QuotaExceeded, ParseFailure:
+		return true
+	default:
+		return false
+	}
+}
+
+// HTTPStatus maps a kind to the status code /process responds with.
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case InvalidInput:
+		return http.StatusBadRequest
+	case NotFound:
+		return http.StatusNotFound
+	case Forbidden:
+		return http.StatusForbidden
+	case Conflict:
+		return http.StatusConflict
+	case QuotaExceeded:
+		return http.StatusTooManyRequests
+	case UpstreamTimeout:
+		return http.StatusGatewayTimeout
+	case UpstreamRejected, UpstreamFailure, ParseFailure:
+		return http.StatusBadGateway
+	case Canceled:
+		return StatusClientClosedRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Info renders err as the stable JSON error body.
+func Info(err error) *types.ErrorInfo {
+	if err == nil {
+		return nil
+	}
+	e := Ensure(err, "")
+	return &types.ErrorInfo{
+		Kind:      string(e.Kind),
+		Stage:     e.Stage,
+		Message:   e.Error(),
+		Retryable: e.Kind.Retryable(),
+	}
+}
+
+// truncate cuts s to at most n bytes on a rune boundary, so an upstream body never leaves
+// half a character in the message.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "..."
+}
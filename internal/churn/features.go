@@ -0,0 +1,94 @@
+package churn
+
+import (
+	"math"
+
+	"voice-insights-go/internal/cohort"
+	"voice-insights-go/internal/types"
+)
+
+// Input is what a churn score is computed from: the extraction, the repeat-contact summary
+// and the call's cohort metadata.
+type Input struct {
+	KPI           types.KPIExtraction
+	Repeat        *types.RepeatContact
+	VintageMonths *int
+	City          string
+}
+
+// cityTierPrefix names the one-hot city tier features, one per tier label of the cohort
+// config at training time.
+const cityTierPrefix = "city_tier="
+
+// feature is one numeric model input.
+type feature struct {
+	name  string
+	value func(in Input) float64
+}
+
+// baseFeatures are the inputs every model uses. The LLM's own risk_of_churn is left out on
+// purpose: the model is what it is blended with, not built on.
+var baseFeatures = []feature{
+	{"frustration_score", func(in Input) float64 { return in.KPI.KPI.FrustrationScore }},
+	{"confusion_level", func(in Input) float64 { return in.KPI.KPI.ConfusionLevel }},
+	{"empathy_score", func(in Input) float64 { return in.KPI.KPI.EmpathyScore }},
+	{"resolution_likelihood", func(in Input) float64 { return in.KPI.KPI.ResolutionLikelihood }},
+	{"customer_talk_ratio", func(in Input) float64 { return in.KPI.KPI.CustomerTalkRatio }},
+	{"interruption_count", func(in Input) float64 { return math.Log1p(float64(max(in.KPI.KPI.InterruptionCount, 0))) }},
+	{"dead_air_instances", func(in Input) float64 { return math.Log1p(float64(max(in.KPI.KPI.DeadAirInstances, 0))) }},
+	{"rapport_score", func(in Input) float64 { return in.KPI.AgentAnalysis.RapportScore }},
+	{"professionalism_score", func(in Input) float64 { return in.KPI.AgentAnalysis.ProfessionalismScore }},
+	{"solution_accuracy_score", func(in Input) float64 { return in.KPI.AgentAnalysis.SolutionAccuracyScore }},
+	{"correct_guidance", func(in Input) float64 { return boolValue(in.KPI.AgentAnalysis.CorrectnessOfGuidance) }},
+	{"missed_opportunities", func(in Input) float64 { return float64(len(in.KPI.AgentAnalysis.MissedOpportunities)) }},
+	{"severity", func(in Input) float64 { return float64(in.KPI.CustomerProblem.Severity) }},
+	{"requires_escalation", func(in Input) float64 { return boolValue(in.KPI.Actions.RequiresEscalation) }},
+	{"prior_calls", func(in Input) float64 {
+		if in.Repeat == nil {
+			return 0
+		}
+		return float64(in.Repeat.PriorCalls)
+	}},
+	{"same_issue_repeat", func(in Input) float64 { return boolValue(in.Repeat != nil && in.Repeat.SameIssue) }},
+	{"vintage_months_log", func(in Input) float64 {
+		if in.VintageMonths == nil {
+			return 0
+		}
+		return math.Log1p(float64(max(*in.VintageMonths, 0)))
+	}},
+	{"vintage_unknown", func(in Input) float64 { return boolValue(in.VintageMonths == nil) }},
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// featureNames lists the inputs of a model trained under cfg.
+func featureNames(cfg *cohort.Config) []string {
+	names := make([]string, 0, len(baseFeatures)+4)
+	for _, f := range baseFeatures {
+		names = append(names, f.name)
+	}
+	for _, label := range cfg.Labels(cohort.DimCityTier) {
+		names = append(names, cityTierPrefix+label)
+	}
+	return names
+}
+
+// vector computes the named features of in. Unknown names (a city tier the current cohort
+// config no longer has) are zero.
+func vector(names []string, in Input, cfg *cohort.Config) []float64 {
+	byName := make(map[string]float64, len(baseFeatures)+1)
+	for _, f := range baseFeatures {
+		byName[f.name] = f.value(in)
+	}
+	byName[cityTierPrefix+cfg.CityTierOf(in.City)] = 1
+	out := make([]float64, len(names))
+	for i, n := range names {
+		out[i] = byName[n]
+	}
+	return out
+}
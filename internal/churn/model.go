@@ -0,0 +1,413 @@
+// Package churn is a trainable churn model: logistic regression over call features, fitted
+// on reported outcomes and calibrated with Platt scaling, whose score is blended with the
+// LLM's risk_of_churn. Every score comes with the features that moved it most.
+package churn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/cohort"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/types"
+)
+
+const (
+	collection = "churn_models"
+	currentID  = "current"
+)
+
+// Training limits: below these there is too little signal to defend a model.
+const (
+	minSamples  = 20
+	minPerClass = 5
+	topFactors  = 5
+)
+
+// TrainOptions tune the fit. Lambda is the L2 penalty on the standardized weights.
+type TrainOptions struct {
+	Lambda     float64 `json:"lambda"`
+	Folds      int     `json:"folds"`
+	Iterations int     `json:"iterations"`
+}
+
+// DefaultTrainOptions are used for zero fields.
+var DefaultTrainOptions = TrainOptions{Lambda: 0.1, Folds: 5, Iterations: 3000}
+
+// Metrics are out-of-fold estimates of how the calibrated model does on calls it has not
+// seen: AUC for ranking, Brier and log loss for the probabilities. BaselineBrier is what
+// always predicting the base rate scores, and LLMAUC is the ranking quality of the LLM's own
+// risk_of_churn on the same calls.
+type Metrics struct {
+	Samples       int     `json:"samples"`
+	Positives     int     `json:"positives"`
+	AUC           float64 `json:"auc"`
+	Brier         float64 `json:"brier"`
+	LogLoss       float64 `json:"log_loss"`
+	BaselineBrier float64 `json:"baseline_brier"`
+	LLMAUC        float64 `json:"llm_auc"`
+}
+
+// Coefficient is one feature's weight on the standardized scale, comparable across features.
+type Coefficient struct {
+	Feature string  `json:"feature"`
+	Weight  float64 `json:"weight"`
+}
+
+// Model is a trained churn model. Means and Stds standardize the features; PlattA and
+// PlattB map the raw log-odds to a calibrated probability.
+type Model struct {
+	Version       int          `json:"version"`
+	TrainedAt     time.Time    `json:"trained_at"`
+	CohortVersion int          `json:"cohort_version"`
+	Features      []string     `json:"features"`
+	Means         []float64    `json:"means"`
+	Stds          []float64    `json:"stds"`
+	Weights       []float64    `json:"weights"`
+	Bias          float64      `json:"bias"`
+	PlattA        float64      `json:"platt_a"`
+	PlattB        float64      `json:"platt_b"`
+	Options       TrainOptions `json:"options"`
+	Metrics       Metrics      `json:"metrics"`
+}
+
+// Coefficients lists the weights, largest effect first.
+func (m *Model) Coefficients() []Coefficient {
+	out := make([]Coefficient, len(m.Features))
+	for i, f := range m.Features {
+		out[i] = Coefficient{Feature: f, Weight: m.Weights[i]}
+	}
+	sort.Slice(out, func(i, j int) bool { return math.Abs(out[i].Weight) > math.Abs(out[j].Weight) })
+	return out
+}
+
+var (
+	mu      sync.RWMutex
+	current *Model
+	loaded  bool
+)
+
+// Current returns the trained model, or nil when none has been trained yet.
+func Current() (*Model, error) {
+	mu.RLock()
+	m, ok := current, loaded
+	mu.RUnlock()
+	if ok {
+		return m, nil
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if loaded {
+		return current, nil
+	}
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var stored Model
+	switch err := st.Get(collection, currentID, &stored); {
+	case errors.Is(err, store.ErrNotFound):
+	case err != nil:
+		return nil, fmt.Errorf("load churn model: %w", err)
+	default:
+		current = &stored
+	}
+	loaded = true
+	return current, nil
+}
+
+// ModelWeight is CHURN_MODEL_WEIGHT: the share of the model in the blended score, from 0
+// (LLM only) to 1 (model only, the default).
+func ModelWeight() float64 {
+	if w, err := strconv.ParseFloat(os.Getenv("CHURN_MODEL_WEIGHT"), 64); err == nil && w >= 0 && w <= 1 {
+		return w
+	}
+	return 1
+}
+
+// Score computes the calibrated probability of in and blends it with the LLM's
+// risk_of_churn. It returns nil when no model is trained.
+func Score(in Input) (*types.ChurnPrediction, error) {
+	m, err := Current()
+	if err != nil || m == nil {
+		return nil, err
+	}
+	cfg, err := cohort.Current()
+	if err != nil {
+		return nil, err
+	}
+	x := vector(m.Features, in, cfg)
+	logit := m.Bias
+	contrib := make([]types.ChurnFactor, len(x))
+	for i := range x {
+		c := m.Weights[i] * (x[i] - m.Means[i]) / m.Stds[i]
+		logit += c
+		contrib[i] = types.ChurnFactor{Feature: m.Features[i], Value: round(x[i]), Contribution: round(c)}
+	}
+	sort.Slice(contrib, func(i, j int) bool { return math.Abs(contrib[i].Contribution) > math.Abs(contrib[j].Contribution) })
+	if len(contrib) > topFactors {
+		contrib = contrib[:topFactors]
+	}
+	p := sigmoid(m.PlattA*logit + m.PlattB)
+	llm := in.KPI.BusinessImpact.RiskOfChurn
+	w := ModelWeight()
+	return &types.ChurnPrediction{
+		Probability:  round(p),
+		LLM:          llm,
+		Blended:      round(w*p + (1-w)*llm),
+		ModelWeight:  w,
+		ModelVersion: m.Version,
+		TopFactors:   contrib,
+	}, nil
+}
+
+// InputOf builds the model input of a processed job. The LLM value is taken from the stored
+// prediction when there is one, the value it was blended with.
+func InputOf(j *jobs.Job) Input {
+	in := Input{KPI: j.Result.KPI, Repeat: j.Result.Repeat, VintageMonths: j.VintageMonths, City: j.City}
+	if c := j.Result.Churn; c != nil {
+		in.KPI.BusinessImpact.RiskOfChurn = c.LLM
+	}
+	return in
+}
+
+type example struct {
+	x   []float64
+	y   float64
+	llm float64
+}
+
+// Train fits a model on every processed call with a reported churn outcome and makes it
+// current. Metrics come from Folds-fold cross-validation; Platt scaling is fitted on the
+// out-of-fold scores, then the weights are refitted on all calls.
+func Train(opts TrainOptions) (*Model, error) {
+	if opts.Lambda <= 0 {
+		opts.Lambda = DefaultTrainOptions.Lambda
+	}
+	if opts.Folds < 2 {
+		opts.Folds = DefaultTrainOptions.Folds
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultTrainOptions.Iterations
+	}
+	cfg, err := cohort.Current()
+	if err != nil {
+		return nil, err
+	}
+	names := featureNames(cfg)
+
+	outs, err := outcome.List("")
+	if err != nil {
+		return nil, err
+	}
+	// deterministic order so folds, and so metrics, are reproducible
+	sort.Slice(outs, func(i, j int) bool { return outs[i].JobID < outs[j].JobID })
+	var data []example
+	positives := 0
+	for _, o := range outs {
+		if o.Churned == nil {
+			continue
+		}
+		j, err := jobs.Get(o.JobID)
+		if errors.Is(err, jobs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+			continue
+		}
+		in := InputOf(j)
+		ex := example{x: vector(names, in, cfg), llm: in.KPI.BusinessImpact.RiskOfChurn}
+		if *o.Churned {
+			ex.y = 1
+			positives++
+		}
+		data = append(data, ex)
+	}
+	if len(data) < minSamples || positives < minPerClass || len(data)-positives < minPerClass {
+		return nil, apperr.New(apperr.InvalidInput, "", fmt.Sprintf(
+			"need at least %d calls with a churn outcome and %d of each class; have %d (%d churned)", minSamples, minPerClass, len(data), positives))
+	}
+	if opts.Folds > len(data) {
+		opts.Folds = len(data)
+	}
+
+	// out-of-fold raw log-odds
+	oof := make([]float64, len(data))
+	for f := 0; f < opts.Folds; f++ {
+		var train []example
+		for i, ex := range data {
+			if i%opts.Folds != f {
+				train = append(train, ex)
+			}
+		}
+		means, stds := standardizer(train)
+		w, b := fit(train, means, stds, opts)
+		for i, ex := range data {
+			if i%opts.Folds == f {
+				oof[i] = logOdds(ex.x, means, stds, w, b)
+			}
+		}
+	}
+	labels := make([]float64, len(data))
+	for i, ex := range data {
+		labels[i] = ex.y
+	}
+	a, b := platt(oof, labels, opts.Iterations)
+
+	m := &Model{TrainedAt: time.Now().UTC(), CohortVersion: cfg.Version, Features: names, PlattA: a, PlattB: b, Options: opts}
+	m.Metrics = evaluate(data, oof, a, b)
+	m.Means, m.Stds = standardizer(data)
+	m.Weights, m.Bias = fit(data, m.Means, m.Stds, opts)
+
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	m.Version = 1
+	if current != nil {
+		m.Version = current.Version + 1
+	} else {
+		var prev Model
+		if err := st.Get(collection, currentID, &prev); err == nil {
+			m.Version = prev.Version + 1
+		}
+	}
+	if err := st.Put(collection, "v"+strconv.Itoa(m.Version), m); err != nil {
+		return nil, fmt.Errorf("save churn model: %w", err)
+	}
+	if err := st.Put(collection, currentID, m); err != nil {
+		return nil, fmt.Errorf("save churn model: %w", err)
+	}
+	current, loaded = m, true
+	return m, nil
+}
+
+func standardizer(data []example) (means, stds []float64) {
+	n := len(data[0].x)
+	means, stds = make([]float64, n), make([]float64, n)
+	for _, ex := range data {
+		for i, v := range ex.x {
+			means[i] += v
+		}
+	}
+	for i := range means {
+		means[i] /= float64(len(data))
+	}
+	for _, ex := range data {
+		for i, v := range ex.x {
+			stds[i] += (v - means[i]) * (v - means[i])
+		}
+	}
+	for i := range stds {
+		stds[i] = math.Sqrt(stds[i] / float64(len(data)))
+		if stds[i] < 1e-9 {
+			stds[i] = 1 // constant feature: weight stays at zero under the penalty
+		}
+	}
+	return means, stds
+}
+
+// fit is full-batch gradient descent on the mean log loss plus Lambda/2 times the squared
+// weights; the bias is not penalized. Standardized inputs keep a fixed step stable.
+func fit(data []example, means, stds []float64, opts TrainOptions) ([]float64, float64) {
+	const step = 0.5
+	n := len(means)
+	w, b := make([]float64, n), 0.0
+	z := make([][]float64, len(data))
+	for k, ex := range data {
+		z[k] = make([]float64, n)
+		for i, v := range ex.x {
+			z[k][i] = (v - means[i]) / stds[i]
+		}
+	}
+	grad := make([]float64, n)
+	for it := 0; it < opts.Iterations; it++ {
+		for i := range grad {
+			grad[i] = opts.Lambda * w[i]
+		}
+		gb := 0.0
+		for k, ex := range data {
+			s := b
+			for i, v := range z[k] {
+				s += w[i] * v
+			}
+			e := (sigmoid(s) - ex.y) / float64(len(data))
+			for i, v := range z[k] {
+				grad[i] += e * v
+			}
+			gb += e
+		}
+		for i := range w {
+			w[i] -= step * grad[i]
+		}
+		b -= step * gb
+	}
+	return w, b
+}
+
+func logOdds(x, means, stds, w []float64, b float64) float64 {
+	s := b
+	for i, v := range x {
+		s += w[i] * (v - means[i]) / stds[i]
+	}
+	return s
+}
+
+// platt fits p = sigmoid(a*s + b) to the labels by gradient descent on the log loss.
+func platt(scores, labels []float64, iterations int) (a, b float64) {
+	const step = 0.1
+	a = 1
+	for it := 0; it < iterations; it++ {
+		ga, gb := 0.0, 0.0
+		for i, s := range scores {
+			e := sigmoid(a*s+b) - labels[i]
+			ga += e * s
+			gb += e
+		}
+		a -= step * ga / float64(len(scores))
+		b -= step * gb / float64(len(scores))
+	}
+	return a, b
+}
+
+func evaluate(data []example, oof []float64, a, b float64) Metrics {
+	m := Metrics{Samples: len(data)}
+	probs, llm := make([]float64, len(data)), make([]float64, len(data))
+	hit := make([]bool, len(data))
+	for i, ex := range data {
+		p := sigmoid(a*oof[i] + b)
+		probs[i], llm[i], hit[i] = p, ex.llm, ex.y == 1
+		if hit[i] {
+			m.Positives++
+		}
+		m.Brier += (p - ex.y) * (p - ex.y)
+		pc := math.Min(math.Max(p, 1e-12), 1-1e-12)
+		m.LogLoss -= ex.y*math.Log(pc) + (1-ex.y)*math.Log(1-pc)
+	}
+	n := float64(len(data))
+	base := float64(m.Positives) / n
+	m.Brier = round(m.Brier / n)
+	m.LogLoss = round(m.LogLoss / n)
+	m.BaselineBrier = round(base * (1 - base))
+	m.AUC = round(outcome.AUC(probs, hit))
+	m.LLMAUC = round(outcome.AUC(llm, hit))
+	return m
+}
+
+func sigmoid(s float64) float64 { return 1 / (1 + math.Exp(-s)) }
+
+func round(v float64) float64 { return math.Round(v*10000) / 10000 }
@@ -0,0 +1,147 @@
+package churn
+
+import (
+	"math"
+	"testing"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/testutil"
+)
+
+func TestPlatt(t *testing.T) {
+	scores := []float64{-3, -2, -1, -0.5, 0, 0.5, 1, 2, 3}
+	// soft labels drawn from a known curve put the log-loss minimum exactly on it
+	labels := func(a, b float64) []float64 {
+		out := make([]float64, len(scores))
+		for i, s := range scores {
+			out[i] = sigmoid(a*s + b)
+		}
+		return out
+	}
+	tests := []struct {
+		name string
+		a, b float64
+	}{
+		{"already calibrated", 1, 0},
+		{"overconfident scores are flattened", 0.5, 0},
+		{"underconfident and shifted", 2, 0.5},
+		{"reversed", -1, -0.3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := platt(scores, labels(tt.a, tt.b), 20000)
+			if math.Abs(a-tt.a) > 0.01 || math.Abs(b-tt.b) > 0.01 {
+				t.Errorf("platt = (%.3f, %.3f), want (%v, %v)", a, b, tt.a, tt.b)
+			}
+		})
+	}
+}
+
+func TestStandardizer(t *testing.T) {
+	data := []example{{x: []float64{1, 5}}, {x: []float64{3, 5}}}
+	means, stds := standardizer(data)
+	if means[0] != 2 || stds[0] != 1 || means[1] != 5 || stds[1] != 1 {
+		t.Errorf("means=%v stds=%v, want [2 5] and [1 1] (constant feature keeps 1)", means, stds)
+	}
+}
+
+// seed stores n processed calls whose churn outcome follows frustration, with a few
+// exceptions so the classes overlap.
+func seed(t *testing.T, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		j := jobs.New("https://calls/x.wav", 3, "single")
+		j.Status = jobs.StatusCompleted
+		frustration := float64(i%10) / 10
+		j.Result.KPI.KPI.FrustrationScore = frustration
+		j.Result.KPI.KPI.EmpathyScore = 0.5
+		j.Result.KPI.BusinessImpact.RiskOfChurn = 0.5
+		churned := frustration >= 0.5
+		if i%17 == 0 {
+			churned = !churned
+		}
+		if err := jobs.Save(j); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := outcome.Record(j, outcome.Update{Churned: &churned}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTrainAndScore(t *testing.T) {
+	testutil.DataDir(t)
+	forget := func() {
+		mu.Lock()
+		current, loaded = nil, false
+		mu.Unlock()
+	}
+	forget() // the model cached from another run's store
+	t.Cleanup(forget)
+	seed(t, minSamples-1)
+	if _, err := Train(TrainOptions{}); apperr.KindOf(err) != apperr.InvalidInput {
+		t.Fatalf("training on %d calls: err = %v, want invalid input", minSamples-1, err)
+	}
+
+	seed(t, 41)
+	m, err := Train(TrainOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != 1 || m.Options != DefaultTrainOptions || m.Metrics.Samples != minSamples-1+41 {
+		t.Errorf("model version %d options %+v samples %d", m.Version, m.Options, m.Metrics.Samples)
+	}
+	if m.Metrics.AUC < 0.8 || m.Metrics.LLMAUC != 0.5 || m.Metrics.Brier >= m.Metrics.BaselineBrier {
+		t.Errorf("metrics = %+v, want the model to beat the flat LLM score and the base rate", m.Metrics)
+	}
+	if m.PlattA <= 0 {
+		t.Errorf("platt a = %v, want the out-of-fold scores to keep their direction", m.PlattA)
+	}
+	if top := m.Coefficients()[0]; top.Feature != "frustration_score" || top.Weight <= 0 {
+		t.Errorf("top coefficient = %+v", top)
+	}
+
+	t.Setenv("CHURN_MODEL_WEIGHT", "0.5")
+	tests := []struct {
+		name        string
+		frustration float64
+		churn       bool
+	}{
+		{"calm call", 0.1, false},
+		{"angry call", 0.9, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &jobs.Job{}
+			j.Result.KPI.KPI.FrustrationScore = tt.frustration
+			j.Result.KPI.KPI.EmpathyScore = 0.5
+			j.Result.KPI.BusinessImpact.RiskOfChurn = 0.5
+			p, err := Score(InputOf(j))
+			if err != nil || p == nil {
+				t.Fatalf("Score = %v, %v", p, err)
+			}
+			if (p.Probability > 0.5) != tt.churn {
+				t.Errorf("probability = %v, want churn %v", p.Probability, tt.churn)
+			}
+			if p.ModelWeight != 0.5 || math.Abs(p.Blended-(p.Probability+0.5)/2) > 1e-4 || p.ModelVersion != 1 {
+				t.Errorf("prediction = %+v", p)
+			}
+			if len(p.TopFactors) != topFactors || p.TopFactors[0].Feature != "frustration_score" {
+				t.Errorf("top factors = %+v", p.TopFactors)
+			}
+		})
+	}
+
+	again, err := Train(TrainOptions{Folds: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Version != 2 || again.Options.Folds != 3 {
+		t.Errorf("retrained version %d options %+v", again.Version, again.Options)
+	}
+	if cur, err := Current(); err != nil || cur.Version != 2 {
+		t.Errorf("Current = %+v, %v", cur, err)
+	}
+}
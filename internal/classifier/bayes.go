@@ -0,0 +1,198 @@
+package classifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
+)
+
+const (
+	modelCollection = "classifier_models"
+	bayesModelID    = "bayes"
+)
+
+// Example is one labeled transcript used to train the naive Bayes model.
+type Example struct {
+	Text     string `json:"text"`
+	Category string `json:"category"`
+	Confused bool   `json:"confused"`
+}
+
+// counts is a multinomial bag-of-words model for one class.
+type counts struct {
+	Docs   int            `json:"docs"`
+	Tokens int            `json:"tokens"`
+	Words  map[string]int `json:"words"`
+}
+
+func (c *counts) add(toks []string) {
+	c.Docs++
+	c.Tokens += len(toks)
+	for _, t := range toks {
+		c.Words[t]++
+	}
+}
+
+// Bayes is a multinomial naive Bayes model with Laplace smoothing: one class per taxonomy
+// category plus a separate two-class model for confusion.
+type Bayes struct {
+	TrainedAt  time.Time          `json:"trained_at"`
+	Examples   int                `json:"examples"`
+	Vocabulary int                `json:"vocabulary"`
+	Categories map[string]*counts `json:"categories"`
+	Confused   *counts            `json:"confused"`
+	Clear      *counts            `json:"clear"`
+}
+
+// Train fits a model on labeled examples. Categories must be taxonomy node ids.
+func Train(examples []Example) (*Bayes, error) {
+	t, err := taxonomy.Current()
+	if err != nil {
+		return nil, err
+	}
+	m := &Bayes{
+		TrainedAt:  time.Now().UTC(),
+		Categories: map[string]*counts{},
+		Confused:   &counts{Words: map[string]int{}},
+		Clear:      &counts{Words: map[string]int{}},
+	}
+	vocab := map[string]bool{}
+	for i, ex := range examples {
+		toks := tokens(ex.Text)
+		if len(toks) == 0 {
+			continue
+		}
+		cat := strings.TrimSpace(ex.Category)
+		if cat == "" {
+			cat = taxonomy.OtherID
+		}
+		if _, ok := t.Node(cat); !ok {
+			return nil, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("example %d: category %q is not a taxonomy node", i, cat))
+		}
+		if m.Categories[cat] == nil {
+			m.Categories[cat] = &counts{Words: map[string]int{}}
+		}
+		m.Categories[cat].add(toks)
+		if ex.Confused {
+			m.Confused.add(toks)
+		} else {
+			m.Clear.add(toks)
+		}
+		for _, tok := range toks {
+			vocab[tok] = true
+		}
+		m.Examples++
+	}
+	if m.Examples == 0 {
+		return nil, apperr.New(apperr.InvalidInput, "", "no usable training examples")
+	}
+	m.Vocabulary = len(vocab)
+	return m, nil
+}
+
+// Save persists the model as the active naive Bayes model.
+func (m *Bayes) Save() error {
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	if err := st.Put(modelCollection, bayesModelID, m); err != nil {
+		return fmt.Errorf("save bayes model: %w", err)
+	}
+	return nil
+}
+
+// LoadBayes returns the persisted model, or an error if none has been trained.
+func LoadBayes() (*Bayes, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var m Bayes
+	if err := st.Get(modelCollection, bayesModelID, &m); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, apperr.New(apperr.NotFound, "", "no naive bayes model has been trained")
+		}
+		return nil, fmt.Errorf("load bayes model: %w", err)
+	}
+	return &m, nil
+}
+
+func (m *Bayes) Name() string { return NameBayes }
+
+// Classify returns the most probable category; confidence is its posterior probability.
+func (m *Bayes) Classify(_ context.Context, text string) (types.Classification, error) {
+	toks := tokens(text)
+	out := types.Classification{Category: taxonomy.OtherID, Method: NameBayes}
+
+	cats := make([]string, 0, len(m.Categories))
+	for id := range m.Categories {
+		cats = append(cats, id)
+	}
+	sort.Strings(cats)
+	classes := make([]*counts, len(cats))
+	for i, id := range cats {
+		classes[i] = m.Categories[id]
+	}
+	if best, p := m.posterior(classes, toks); best >= 0 {
+		out.Category, out.Confidence = cats[best], p[best]
+	}
+
+	if _, p := m.posterior([]*counts{m.Clear, m.Confused}, toks); p != nil {
+		out.ConfusionConfidence = p[1]
+		out.Confused = p[1] >= 0.5
+	}
+	return withPath(out), nil
+}
+
+// posterior returns the index of the most probable class and the normalized probabilities
+// of all classes. Classes with no training documents get probability 0.
+func (m *Bayes) posterior(classes []*counts, toks []string) (int, []float64) {
+	docs := 0
+	for _, c := range classes {
+		if c != nil {
+			docs += c.Docs
+		}
+	}
+	if docs == 0 {
+		return -1, nil
+	}
+	v := float64(m.Vocabulary + 1) // +1 for unseen words
+	logs := make([]float64, len(classes))
+	best := -1
+	for i, c := range classes {
+		if c == nil || c.Docs == 0 {
+			logs[i] = math.Inf(-1)
+			continue
+		}
+		lp := math.Log(float64(c.Docs) / float64(docs))
+		denom := math.Log(float64(c.Tokens) + v)
+		for _, t := range toks {
+			lp += math.Log(float64(c.Words[t]+1)) - denom
+		}
+		logs[i] = lp
+		if best < 0 || lp > logs[best] {
+			best = i
+		}
+	}
+	// softmax over log-likelihoods, shifted by the max for stability
+	probs := make([]float64, len(classes))
+	sum := 0.0
+	for i, lp := range logs {
+		probs[i] = math.Exp(lp - logs[best])
+		sum += probs[i]
+	}
+	for i := range probs {
+		probs[i] /= sum
+	}
+	return best, probs
+}
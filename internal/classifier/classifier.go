@@ -0,0 +1,67 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
+)
+
+// Classifier assigns a taxonomy category and a confusion flag to a transcript.
+type Classifier interface {
+	Name() string
+	Classify(ctx context.Context, text string) (types.Classification, error)
+}
+
+// Names accepted by CLASSIFIER.
+const (
+	NameRules = "rules"
+	NameBayes = "bayes"
+	NameLLM   = "llm"
+)
+
+// FromEnv returns the classifier selected by CLASSIFIER (rules|bayes|llm, default rules).
+// bayes needs a trained model and falls back to rules until one exists.
+func FromEnv() (Classifier, error) {
+	log := logger.New().WithField("component", "classifier")
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("CLASSIFIER")))
+	switch name {
+	case "", NameRules:
+		return LoadRules()
+	case NameBayes:
+		m, err := LoadBayes()
+		if err == nil {
+			return m, nil
+		}
+		log.WithError(err).Warn("no naive bayes model; falling back to keyword rules")
+		return LoadRules()
+	case NameLLM:
+		return LLM{}, nil
+	default:
+		return nil, fmt.Errorf("unknown CLASSIFIER %q", name)
+	}
+}
+
+// withPath fills the taxonomy path of the result's category.
+func withPath(c types.Classification) types.Classification {
+	if t, err := taxonomy.Current(); err == nil {
+		c.Path = t.Path(c.Category)
+	}
+	if c.Path == nil {
+		c.Path = []string{}
+	}
+	return c
+}
+
+// tokens lowercases text and splits it into words, dropping apostrophes so "don't" is "dont".
+func tokens(s string) []string {
+	s = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(s))
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+}
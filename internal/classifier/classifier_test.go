@@ -0,0 +1,161 @@
+package classifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"voice-insights-go/internal/testutil"
+)
+
+func TestRulesClassify(t *testing.T) {
+	testutil.DataDir(t)
+	r, err := LoadRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		text     string
+		category string
+		confused bool
+	}{
+		{"fake leads", "Sir mujhe fake lead aa rahe hain, sab spam hai, bekar lead", "lead_quality/fake_enquiries", false},
+		{"refund", "I want a refund, paisa wapas chahiye, refund karo", "billing/refund", false},
+		{"how to with confusion", "samajh nahi aa raha, kaise karu, I am confused, dont understand", "platform/how_to", true},
+		{"no keywords", "hello good morning", "other", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.Classify(context.Background(), tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Category != tt.category || got.Confused != tt.confused {
+				t.Errorf("got %s confused=%v, want %s confused=%v", got.Category, got.Confused, tt.category, tt.confused)
+			}
+			if got.Method != NameRules || got.Path == nil {
+				t.Errorf("method %q path %v", got.Method, got.Path)
+			}
+			if got.Confidence < 0 || got.Confidence > 1 {
+				t.Errorf("confidence %v out of range", got.Confidence)
+			}
+		})
+	}
+}
+
+func TestBayesTrainAndClassify(t *testing.T) {
+	testutil.DataDir(t)
+	examples := []Example{
+		{Text: "fake lead spam enquiry", Category: "lead_quality/fake_enquiries"},
+		{Text: "leads are fake and junk", Category: "lead_quality/fake_enquiries"},
+		{Text: "refund my money back", Category: "billing/refund"},
+		{Text: "please refund the payment", Category: "billing/refund"},
+		{Text: "how do I add product, I am confused", Category: "platform/how_to", Confused: true},
+		{Text: "", Category: "billing/refund"},
+	}
+	m, err := Train(examples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Examples != 5 {
+		t.Errorf("trained on %d examples, want 5 (blank text skipped)", m.Examples)
+	}
+	tests := []struct {
+		text     string
+		category string
+	}{
+		{"so many fake leads", "lead_quality/fake_enquiries"},
+		{"I need a refund", "billing/refund"},
+	}
+	for _, tt := range tests {
+		got, err := m.Classify(context.Background(), tt.text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Category != tt.category || got.Confidence <= 0.5 {
+			t.Errorf("Classify(%q) = %s (%.2f), want %s", tt.text, got.Category, got.Confidence, tt.category)
+		}
+	}
+
+	if _, err := Train([]Example{{Text: "x", Category: "no/such/node"}}); err == nil {
+		t.Error("training on an unknown category succeeded")
+	}
+	if _, err := Train([]Example{{Text: "  "}}); err == nil {
+		t.Error("training without usable examples succeeded")
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		n    int
+		want string
+	}{
+		{"short", "abc", 10, "abc"},
+		{"ascii", "abcdef", 3, "abc"},
+		{"inside a rune", "ab" + "नमस्ते", 4, "ab"},
+		{"rune boundary", "ab" + "नमस्ते", 5, "abन"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncate(tt.in, tt.n)
+			if got != tt.want || !utf8.ValidString(got) {
+				t.Errorf("truncate(%q, %d) = %q, want %q", tt.in, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLLMClassifyRedactsAndValidates(t *testing.T) {
+	testutil.DataDir(t)
+	tests := []struct {
+		name     string
+		answer   string
+		category string
+		conf     float64
+	}{
+		{"known node", `{"category":"billing/refund","confidence":0.9,"confused":false,"confusion_confidence":0.1}`, "billing/refund", 0.9},
+		{"unknown node is other", `{"category":"made/up","confidence":0.9}`, "other", 0},
+		{"confidence clamped", `{"category":"billing/refund","confidence":7}`, "billing/refund", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prompt string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Messages []struct {
+						Content string `json:"content"`
+					} `json:"messages"`
+				}
+				body, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(body, &req)
+				prompt = req.Messages[0].Content
+				_ = json.NewEncoder(w).Encode(map[string]any{
+					"choices": []map[string]any{{"message": map[string]string{"content": tt.answer}}},
+				})
+			}))
+			defer srv.Close()
+			t.Setenv("LLM_GATEWAY_URL", srv.URL)
+			t.Setenv("LLM_API_KEY", "test")
+			t.Setenv("PII_REDACTION_MODE", "mask")
+
+			got, err := LLM{}.Classify(context.Background(), "refund chahiye, mera number 9876543210 hai")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(prompt, "9876543210") || !strings.Contains(prompt, "[PHONE]") {
+				t.Errorf("prompt was not redacted: %q", prompt)
+			}
+			if got.Category != tt.category || got.Confidence != tt.conf {
+				t.Errorf("got %s (%v), want %s (%v)", got.Category, got.Confidence, tt.category, tt.conf)
+			}
+		})
+	}
+}
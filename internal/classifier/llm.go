@@ -0,0 +1,92 @@
+package classifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
+)
+
+// maxLLMChars caps the transcript sent to the LLM; the category is usually clear early on.
+const maxLLMChars = 12000
+
+// LLM asks the gateway to pick a taxonomy node. Answers outside the taxonomy become "other".
+type LLM struct{}
+
+func (LLM) Name() string { return NameLLM }
+
+func (LLM) Classify(ctx context.Context, text string) (types.Classification, error) {
+	out := types.Classification{Category: taxonomy.OtherID, Method: NameLLM}
+	if !llm.Configured() {
+		return out, apperr.New(apperr.InvalidInput, "", "llm classifier selected but LLM_GATEWAY_URL/LLM_API_KEY are not set")
+	}
+	t, err := taxonomy.Current()
+	if err != nil {
+		return out, err
+	}
+	// dataset rows arrive raw, so the text is redacted here like every other LLM input
+	text, _, err = redact.Text(text)
+	if err != nil {
+		return out, apperr.Wrap(apperr.Internal, "", "redact transcript", err)
+	}
+	text = truncate(text, maxLLMChars)
+	var ans struct {
+		Category            string  `json:"category"`
+		Confidence          float64 `json:"confidence"`
+		Confused            bool    `json:"confused"`
+		ConfusionConfidence float64 `json:"confusion_confidence"`
+	}
+	if err := llm.CompleteJSON(ctx, llmPrompt(t, text), &ans); err != nil {
+		return out, fmt.Errorf("llm classify: %w", err)
+	}
+	if _, ok := t.Node(ans.Category); ok {
+		out.Category, out.Confidence = ans.Category, clamp01(ans.Confidence)
+	}
+	out.ConfusionConfidence = clamp01(ans.ConfusionConfidence)
+	out.Confused = ans.Confused
+	return withPath(out), nil
+}
+
+func llmPrompt(t *taxonomy.Taxonomy, text string) string {
+	var b strings.Builder
+	for _, n := range t.Nodes {
+		fmt.Fprintf(&b, "- %s: %s", n.ID, strings.Join(t.Path(n.ID), " > "))
+		if n.Description != "" {
+			fmt.Fprintf(&b, " — %s", n.Description)
+		}
+		b.WriteByte('\n')
+	}
+	return fmt.Sprintf(`You classify support calls of a B2B marketplace (Hindi/English/Hinglish).
+
+TAXONOMY (id: path):
+%s
+TRANSCRIPT:
+%s
+
+Pick the single most specific node describing the customer's main issue ("%s" if none fits),
+and whether the customer was confused about how to use the product.
+Return ONLY valid JSON:
+{"category": "<id from the list>", "confidence": 0.0-1.0, "confused": true|false, "confusion_confidence": 0.0-1.0}
+`, b.String(), text, taxonomy.OtherID)
+}
+
+// truncate cuts text to at most n bytes without splitting a multi-byte rune.
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
+
+func clamp01(v float64) float64 {
+	return max(0, min(1, v))
+}
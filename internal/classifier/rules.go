@@ -0,0 +1,94 @@
+package classifier
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rules scores each category by how many of its keyword phrases occur in the transcript.
+type Rules struct {
+	Categories map[string][]string `json:"categories"`
+	Confusion  []string            `json:"confusion"`
+}
+
+// LoadRules reads CLASSIFIER_RULES_PATH, or the embedded default rules. Category keys must
+// be taxonomy node ids.
+func LoadRules() (*Rules, error) {
+	data := defaultRules
+	if path := os.Getenv("CLASSIFIER_RULES_PATH"); path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read classifier rules: %w", err)
+		}
+	}
+	var r Rules
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("decode classifier rules: %w", err)
+	}
+	if t, err := taxonomy.Current(); err == nil {
+		for id := range r.Categories {
+			if _, ok := t.Node(id); !ok {
+				return nil, fmt.Errorf("classifier rules: category %q is not a taxonomy node", id)
+			}
+		}
+	}
+	return &r, nil
+}
+
+func (r *Rules) Name() string { return NameRules }
+
+// Classify picks the category with the most keyword hits; confidence is its share of all
+// hits, damped when there are only a few. Confusion confidence grows with the number of cues.
+func (r *Rules) Classify(_ context.Context, text string) (types.Classification, error) {
+	norm := " " + strings.Join(tokens(text), " ") + " "
+	out := types.Classification{Category: taxonomy.OtherID, Method: NameRules}
+
+	ids := make([]string, 0, len(r.Categories))
+	for id := range r.Categories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // deterministic tie-break
+	best, total := 0, 0
+	for _, id := range ids {
+		n := 0
+		for _, kw := range r.Categories[id] {
+			n += countPhrase(norm, kw)
+		}
+		total += n
+		if n > best {
+			out.Category, best = id, n
+		}
+	}
+	if total > 0 {
+		out.Confidence = float64(best) / float64(total) * (1 - math.Exp(-float64(best)/2))
+	}
+
+	cues := 0
+	for _, kw := range r.Confusion {
+		cues += countPhrase(norm, kw)
+	}
+	out.ConfusionConfidence = 1 - math.Exp(-float64(cues)/2)
+	out.Confused = out.ConfusionConfidence >= 0.5
+	return withPath(out), nil
+}
+
+// countPhrase counts whole-word occurrences of phrase in a space-padded normalized text.
+func countPhrase(norm, phrase string) int {
+	p := strings.Join(tokens(phrase), " ")
+	if p == "" {
+		return 0
+	}
+	return strings.Count(norm, " "+p+" ")
+}
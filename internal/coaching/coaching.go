@@ -0,0 +1,379 @@
+package coaching
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/types"
+)
+
+// Example is one call where a gap occurred, with the transcript quote that grounds it.
+type Example struct {
+	JobID string `json:"job_id"`
+	Date  string `json:"date"`
+	Item  string `json:"item"`
+	Quote string `json:"quote,omitempty"`
+	Turn  int    `json:"turn"`
+}
+
+// Gap is a cluster of similar missed items recurring across an agent's calls.
+type Gap struct {
+	Behavior          string    `json:"behavior"`
+	Calls             int       `json:"calls"`
+	Share             float64   `json:"share_of_calls"`
+	Mentions          int       `json:"mentions"`
+	Variants          []string  `json:"variants"`
+	Examples          []Example `json:"examples"`
+	SuggestedPhrasing []string  `json:"suggested_phrasing"`
+}
+
+// Brief is the coaching document for one agent over a period.
+type Brief struct {
+	AgentID      string    `json:"agent_id"`
+	Team         string    `json:"team"`
+	From         time.Time `json:"from"`
+	To           time.Time `json:"to"`
+	Calls        int       `json:"calls"`
+	TopBehaviors []Gap     `json:"top_behaviors"`
+	OtherGaps    []Gap     `json:"other_gaps"`
+	GeneratedAt  time.Time `json:"generated_at"`
+	// PhrasingSource is "llm" when suggested phrasing was written by the model, else "playbook"
+	PhrasingSource string `json:"phrasing_source"`
+}
+
+// similarity is the token overlap above which two missed items are the same gap. Overlap
+// (shared / smaller set) rather than Jaccard, because the same gap is often phrased once
+// tersely ("did not verify identity") and once at length by a compliance rule.
+const similarity = 0.6
+
+type cluster struct {
+	tokens   map[string]int // token -> member count, the cluster's centroid
+	members  map[string]int // original text -> mentions
+	calls    map[string]bool
+	examples []Example
+}
+
+func (c *cluster) overlap(t map[string]bool) float64 {
+	// centroid tokens are those used by at least half the members
+	total := 0
+	for _, n := range c.members {
+		total += n
+	}
+	inter, size := 0, 0
+	for tok, n := range c.tokens {
+		if 2*n < total {
+			continue
+		}
+		size++
+		if t[tok] {
+			inter++
+		}
+	}
+	smaller := min(size, len(t))
+	// a single shared word is not enough to merge two multi-word items
+	if smaller == 0 || inter < 2 && smaller > 1 {
+		return 0
+	}
+	return float64(inter) / float64(smaller)
+}
+
+// Build clusters the agent's missed opportunities, crucial missed questions and failed
+// compliance rules across completed calls in [from, to) and ranks them by how many calls
+// they recur in. The top three become the brief's behaviors.
+func Build(all []*jobs.Job, agentID string, from, to time.Time) Brief {
+	b := Brief{AgentID: agentID, From: from, To: to, GeneratedAt: time.Now().UTC(), PhrasingSource: "playbook"}
+
+	var clusters []*cluster
+	add := func(j *jobs.Job, item string, quote types.Quote) {
+		toks := tokenSet(item)
+		if len(toks) == 0 {
+			return
+		}
+		var best *cluster
+		bestScore := 0.0
+		for _, c := range clusters {
+			if s := c.overlap(toks); s >= similarity && s > bestScore {
+				best, bestScore = c, s
+			}
+		}
+		if best == nil {
+			best = &cluster{tokens: map[string]int{}, members: map[string]int{}, calls: map[string]bool{}}
+			clusters = append(clusters, best)
+		}
+		for t := range toks {
+			best.tokens[t]++
+		}
+		best.members[strings.TrimSpace(item)]++
+		if !best.calls[j.ID] {
+			best.calls[j.ID] = true
+			best.examples = append(best.examples, Example{
+				JobID: j.ID, Date: j.At().Format("2006-01-02"), Item: item, Quote: quote.Text, Turn: quote.Turn,
+			})
+		}
+	}
+
+	for _, j := range all {
+		if j.AgentID != agentID || j.At().Before(from) || !j.At().Before(to) {
+			continue
+		}
+		if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+			continue
+		}
+		b.Calls++
+		if j.Team != "" {
+			b.Team = j.Team
+		}
+		x := j.Result.KPI
+		quotes := groundingQuotes(x.Grounding)
+		for _, m := range x.AgentAnalysis.MissedOpportunities {
+			add(j, m, quotes["agent_analysis.missed_opportunities|"+m])
+		}
+		for _, m := range x.ShouldHaveDone.CrucialMissedQuestions {
+			add(j, m, quotes["should_have_done.crucial_missed_questions|"+m])
+		}
+		if rep := j.Result.Compliance; rep != nil {
+			for _, r := range rep.Results {
+				if r.Status == types.ComplianceFail {
+					add(j, r.Description, types.Quote{Turn: r.Turn, Text: r.Evidence})
+				}
+			}
+		}
+	}
+
+	gaps := make([]Gap, 0, len(clusters))
+	for _, c := range clusters {
+		g := Gap{Calls: len(c.calls), Variants: []string{}}
+		labelCount := -1
+		for text, n := range c.members {
+			g.Mentions += n
+			g.Variants = append(g.Variants, text)
+			if n > labelCount || n == labelCount && text < g.Behavior {
+				g.Behavior, labelCount = text, n
+			}
+		}
+		sort.Strings(g.Variants)
+		if b.Calls > 0 {
+			g.Share = float64(g.Calls) / float64(b.Calls)
+		}
+		sort.Slice(c.examples, func(i, j int) bool { return c.examples[i].Date > c.examples[j].Date })
+		g.Examples = c.examples
+		if len(g.Examples) > 3 {
+			g.Examples = g.Examples[:3]
+		}
+		g.SuggestedPhrasing = playbookPhrasing(g.Behavior)
+		gaps = append(gaps, g)
+	}
+	sort.Slice(gaps, func(i, j int) bool {
+		if gaps[i].Calls != gaps[j].Calls {
+			return gaps[i].Calls > gaps[j].Calls
+		}
+		if gaps[i].Mentions != gaps[j].Mentions {
+			return gaps[i].Mentions > gaps[j].Mentions
+		}
+		return gaps[i].Behavior < gaps[j].Behavior
+	})
+
+	n := min(3, len(gaps))
+	b.TopBehaviors, b.OtherGaps = gaps[:n], gaps[n:]
+	return b
+}
+
+// groundingQuotes indexes the first verified quote of each grounded list item by field|item.
+func groundingQuotes(g []types.GroundedFinding) map[string]types.Quote {
+	out := map[string]types.Quote{}
+	for _, f := range g {
+		for _, q := range f.Quotes {
+			if q.Verified {
+				out[f.Field+"|"+f.Item] = q
+				break
+			}
+		}
+	}
+	return out
+}
+
+// phrasingPrompt asks for replacement lines an agent could have used.
+func phrasingPrompt(gaps []Gap) string {
+	var b strings.Builder
+	for i, g := range gaps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, g.Behavior)
+		for _, e := range g.Examples {
+			if e.Quote != "" {
+				fmt.Fprintf(&b, "   context quote: %s\n", e.Quote)
+			}
+		}
+	}
+	return fmt.Sprintf(`You are a call-centre trainer for a B2B marketplace's seller support team (Hinglish calls).
+
+For each recurring gap below, write 2 short lines the agent could have said instead:
+one in English and one in natural Hinglish (Roman script). Be concrete and polite.
+
+GAPS:
+%s
+Return ONLY valid JSON: {"phrasing": [["line", "line"], ...]} with one entry per gap, in order.
+`, b.String())
+}
+
+// SuggestPhrasing replaces the playbook phrasing of the top behaviors with model-written
+// lines when an LLM is configured. Failures keep the playbook phrasing.
+func SuggestPhrasing(ctx context.Context, b *Brief) {
+	if len(b.TopBehaviors) == 0 || !llm.Configured() {
+		return
+	}
+	log := logger.New().WithField("component", "coaching").WithField("agent_id", b.AgentID)
+	var out struct {
+		Phrasing [][]string `json:"phrasing"`
+	}
+	if err := llm.CompleteJSON(ctx, phrasingPrompt(b.TopBehaviors), &out); err != nil {
+		log.WithError(err).Warn("phrasing generation failed; using playbook phrasing")
+		return
+	}
+	if len(out.Phrasing) != len(b.TopBehaviors) {
+		log.WithField("got", len(out.Phrasing)).Warn("phrasing count mismatch; using playbook phrasing")
+		return
+	}
+	for i := range b.TopBehaviors {
+		if len(out.Phrasing[i]) > 0 {
+			b.TopBehaviors[i].SuggestedPhrasing = out.Phrasing[i]
+		}
+	}
+	b.PhrasingSource = "llm"
+}
+
+// playbook maps gap keywords to phrasing trainers already use.
+var playbook = []struct {
+	keywords []string
+	lines    []string
+}{
+	{[]string{"verify", "verification", "identity", "registered", "confirm"}, []string{
+		"Before I make any change, may I confirm your registered mobile number?",
+		"Sir, changes karne se pehle main aapka registered mobile number confirm kar loon?",
+	}},
+	{[]string{"greet", "greeting", "introduce", "introduction"}, []string{
+		"Good morning, this is <name> from seller support. How may I help you today?",
+		"Namaste sir, main <name> seller support se baat kar rahi hoon. Bataiye main aapki kya madad kar sakti hoon?",
+	}},
+	{[]string{"close", "closing", "summary", "summarize", "summarise", "next", "follow"}, []string{
+		"To summarise: I have updated your categories and will call you back tomorrow. Is there anything else I can help with?",
+		"Toh sir, maine aapki categories update kar di hain, kal main aapko call back karungi. Aur kuch madad chahiye?",
+	}},
+	{[]string{"lead", "leads", "promise", "guarantee", "expectation"}, []string{
+		"Lead volume depends on buyer demand in your categories, so I can't promise a number, but here is how to improve it.",
+		"Sir, leads ki ginti buyer demand pe depend karti hai, main number promise nahi kar sakti, par ise badhane ka tarika bata deti hoon.",
+	}},
+	{[]string{"empathy", "empathize", "acknowledge", "frustration", "apolog"}, []string{
+		"I understand how frustrating irrelevant enquiries are; let's fix this together.",
+		"Sir, main samajh sakti hoon ki galat enquiries se pareshani hoti hai, chaliye isse saath mein theek karte hain.",
+	}},
+	{[]string{"ask", "question", "probe", "requirement", "need", "category", "categories"}, []string{
+		"Which products or services bring you the most business, so I can prioritise those categories?",
+		"Sir, aapka sabse zyada business kis product ya service se aata hai, taaki main wahi categories pehle set karoon?",
+	}},
+}
+
+func playbookPhrasing(behavior string) []string {
+	toks := tokenSet(behavior)
+	for _, p := range playbook {
+		for _, k := range p.keywords {
+			for t := range toks {
+				if strings.HasPrefix(t, stem(k)) {
+					return append([]string{}, p.lines...)
+				}
+			}
+		}
+	}
+	return []string{"Acknowledge the customer's point, then address it explicitly: \"" + behavior + "\"."}
+}
+
+// stopwords are dropped before clustering so phrasing differences ("did not", "agent should
+// have") do not dominate the similarity.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "and": true, "or": true, "for": true,
+	"in": true, "on": true, "with": true, "about": true, "by": true, "from": true, "is": true, "was": true,
+	"be": true, "been": true, "did": true, "not": true, "didnt": true, "no": true, "agent": true,
+	"customer": true, "should": true, "have": true, "has": true, "had": true, "could": true, "would": true,
+	"failed": true, "missed": true, "their": true, "his": true, "her": true, "its": true, "any": true,
+	"ka": true, "ki": true, "ke": true, "ko": true, "se": true, "hai": true, "nahi": true, "aur": true,
+}
+
+func tokenSet(s string) map[string]bool {
+	out := map[string]bool{}
+	s = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(s))
+	for _, t := range strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		if stopwords[t] || len(t) < 2 {
+			continue
+		}
+		out[stem(t)] = true
+	}
+	return out
+}
+
+// stem trims common English suffixes so "verify", "verifying" and "verified" cluster together.
+func stem(t string) string {
+	for _, suf := range []string{"ication", "ation", "ing", "ied", "ies", "ed", "es", "s", "y"} {
+		if len(t)-len(suf) >= 4 && strings.HasSuffix(t, suf) {
+			return t[:len(t)-len(suf)]
+		}
+	}
+	return t
+}
+
+// Markdown renders the brief for trainers.
+func Markdown(b Brief) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "# Coaching brief — %s\n\n", b.AgentID)
+	if b.Team != "" {
+		fmt.Fprintf(&s, "Team: %s  \n", b.Team)
+	}
+	fmt.Fprintf(&s, "Period: %s to %s  \nCalls reviewed: %d\n\n", b.From.Format("2006-01-02"), b.To.Add(-time.Second).Format("2006-01-02"), b.Calls)
+	if len(b.TopBehaviors) == 0 {
+		s.WriteString("No recurring gaps found in this period.\n")
+		return s.String()
+	}
+	s.WriteString("## Top behaviors to work on\n")
+	for i, g := range b.TopBehaviors {
+		fmt.Fprintf(&s, "\n### %d. %s\n\n", i+1, g.Behavior)
+		fmt.Fprintf(&s, "Seen in %d of %d calls (%.0f%%).\n", g.Calls, b.Calls, g.Share*100)
+		if len(g.Variants) > 1 {
+			fmt.Fprintf(&s, "\nAlso noted as: %s\n", strings.Join(otherVariants(g), "; "))
+		}
+		if len(g.Examples) > 0 {
+			s.WriteString("\n**Examples**\n\n")
+			for _, e := range g.Examples {
+				if e.Quote != "" {
+					fmt.Fprintf(&s, "- %s (job %s, turn %d): \"%s\"\n", e.Date, e.JobID, e.Turn, e.Quote)
+				} else {
+					fmt.Fprintf(&s, "- %s (job %s): %s\n", e.Date, e.JobID, e.Item)
+				}
+			}
+		}
+		s.WriteString("\n**Try saying**\n\n")
+		for _, p := range g.SuggestedPhrasing {
+			fmt.Fprintf(&s, "- %s\n", p)
+		}
+	}
+	if len(b.OtherGaps) > 0 {
+		s.WriteString("\n## Other gaps\n\n")
+		for _, g := range b.OtherGaps {
+			fmt.Fprintf(&s, "- %s (%d calls)\n", g.Behavior, g.Calls)
+		}
+	}
+	return s.String()
+}
+
+func otherVariants(g Gap) []string {
+	var out []string
+	for _, v := range g.Variants {
+		if v != g.Behavior {
+			out = append(out, v)
+		}
+	}
+	return out
+}
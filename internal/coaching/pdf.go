@@ -0,0 +1,155 @@
+package coaching
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Page geometry in PDF points (A4).
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	margin       = 50
+	bodySize     = 10
+	lineHeight   = 14
+	wrapColumns  = 95
+	headingScale = 1.4
+)
+
+type pdfLine struct {
+	text string
+	size float64
+	bold bool
+}
+
+// PDF renders the brief as a plain text PDF. It is deliberately minimal (standard Helvetica
+// fonts, no images) so it needs no external dependency; text outside Latin-1 is transliterated.
+func PDF(b Brief) []byte {
+	var lines []pdfLine
+	for _, raw := range strings.Split(Markdown(b), "\n") {
+		l := strings.TrimRight(raw, " ")
+		switch {
+		case strings.HasPrefix(l, "### "):
+			lines = append(lines, pdfLine{text: l[4:], size: bodySize * 1.15, bold: true})
+		case strings.HasPrefix(l, "## "):
+			lines = append(lines, pdfLine{text: l[3:], size: bodySize * 1.25, bold: true})
+		case strings.HasPrefix(l, "# "):
+			lines = append(lines, pdfLine{text: l[2:], size: bodySize * headingScale, bold: true})
+		default:
+			l = strings.ReplaceAll(l, "**", "")
+			for _, w := range wrap(l, wrapColumns) {
+				lines = append(lines, pdfLine{text: w, size: bodySize})
+			}
+		}
+	}
+
+	// paginate
+	var pages [][]pdfLine
+	var cur []pdfLine
+	y := pageHeight - margin
+	for _, l := range lines {
+		if y-lineHeight < margin {
+			pages = append(pages, cur)
+			cur, y = nil, pageHeight-margin
+		}
+		cur = append(cur, l)
+		y -= lineHeight
+		if l.size > bodySize {
+			y -= lineHeight / 2
+		}
+	}
+	pages = append(pages, cur)
+
+	// objects: 1 catalog, 2 pages, 3 regular font, 4 bold font, then page/content pairs
+	var objs []string
+	objs = append(objs, "<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	objs = append(objs, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	objs = append(objs, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	objs = append(objs, "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, p := range pages {
+		var c bytes.Buffer
+		y := float64(pageHeight - margin)
+		for _, l := range p {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&c, "BT /%s %.1f Tf %d %.1f Td (%s) Tj ET\n", font, l.size, margin, y, pdfEscape(l.text))
+			y -= lineHeight
+			if l.size > bodySize {
+				y -= lineHeight / 2
+			}
+		}
+		fmt.Fprintf(&c, "BT /F1 8 Tf %d %d Td (Page %d of %d) Tj ET\n", pageWidth-margin-50, margin/2, i+1, len(pages))
+		objs = append(objs, fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		objs = append(objs, fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", c.Len(), c.String()))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objs))
+	for i, o := range objs {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, o)
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objs)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objs)+1, xref)
+	return out.Bytes()
+}
+
+func wrap(s string, width int) []string {
+	if len([]rune(s)) <= width {
+		return []string{s}
+	}
+	indent := ""
+	if strings.HasPrefix(s, "- ") {
+		indent = "  "
+	}
+	var out []string
+	line := ""
+	for _, w := range strings.Fields(s) {
+		if line != "" && len([]rune(line))+1+len([]rune(w)) > width {
+			out = append(out, line)
+			line = indent + w
+			continue
+		}
+		if line == "" {
+			line = w
+		} else {
+			line += " " + w
+		}
+	}
+	return append(out, line)
+}
+
+var transliterate = strings.NewReplacer("—", "-", "–", "-", "‘", "'", "’", "'", "“", "\"", "”", "\"", "…", "...", "•", "-")
+
+// pdfEscape escapes a PDF string literal and maps text to Latin-1 (WinAnsi) bytes.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range transliterate.Replace(s) {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80:
+			b.WriteRune(r)
+		case r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
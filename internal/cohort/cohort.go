@@ -0,0 +1,309 @@
+// Package cohort holds the single definition of the cohorts calls are grouped into (seller
+// vintage buckets, city tiers and call types). Dataset summaries, aggregation and reports all
+// label calls through it, so changing what a "new seller" is only takes a config edit.
+package cohort
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/store"
+)
+
+//go:embed cohorts.json
+var defaultConfig []byte
+
+const (
+	collection = "cohorts"
+	currentID  = "current"
+)
+
+// Cohort dimensions.
+const (
+	DimVintage  = "vintage"
+	DimCityTier = "city_tier"
+	DimCallType = "call_type"
+)
+
+// Dimensions lists every dimension calls can be compared on.
+var Dimensions = []string{DimVintage, DimCityTier, DimCallType}
+
+// Bucket is one vintage range. MaxMonths is inclusive; the last bucket has none and takes
+// every older seller.
+type Bucket struct {
+	Label     string `json:"label"`
+	MaxMonths *int   `json:"max_months,omitempty"`
+}
+
+// VintageConfig buckets seller vintage in months. Unknown labels calls without a vintage.
+type VintageConfig struct {
+	Unknown string   `json:"unknown"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// Grouping maps raw values (case-insensitive) to a group label. Values in no group get
+// Default; empty values get Unknown.
+type Grouping struct {
+	Unknown string              `json:"unknown"`
+	Default string              `json:"default"`
+	Groups  map[string][]string `json:"groups"`
+
+	index map[string]string
+}
+
+// Config is the versioned cohort configuration. Every edit bumps Version.
+type Config struct {
+	Version   int           `json:"version"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Vintage   VintageConfig `json:"vintage"`
+	CityTier  Grouping      `json:"city_tier"`
+	CallType  Grouping      `json:"call_type"`
+}
+
+// Attributes are the call attributes cohorts are derived from. A nil VintageMonths means
+// the vintage is not known.
+type Attributes struct {
+	VintageMonths *int
+	City          string
+	CallType      string
+}
+
+// VintageBucket returns the label of the bucket months falls in.
+func (c *Config) VintageBucket(months int) string {
+	if months < 0 {
+		return c.Vintage.Unknown
+	}
+	for _, b := range c.Vintage.Buckets {
+		if b.MaxMonths == nil || months <= *b.MaxMonths {
+			return b.Label
+		}
+	}
+	return c.Vintage.Unknown // unreachable with a validated config
+}
+
+// VintageOf labels an optional vintage; nil (not known) is Unknown.
+func (c *Config) VintageOf(months *int) string {
+	if months == nil {
+		return c.Vintage.Unknown
+	}
+	return c.VintageBucket(*months)
+}
+
+// CityTierOf returns the tier of city.
+func (c *Config) CityTierOf(city string) string {
+	return c.CityTier.label(city)
+}
+
+// CallTypeOf returns the normalized call type of t.
+func (c *Config) CallTypeOf(t string) string {
+	return c.CallType.label(t)
+}
+
+// Label returns the cohort of a call with attributes a along dimension dim.
+func (c *Config) Label(dim string, a Attributes) (string, error) {
+	switch dim {
+	case DimVintage:
+		return c.VintageOf(a.VintageMonths), nil
+	case DimCityTier:
+		return c.CityTierOf(a.City), nil
+	case DimCallType:
+		return c.CallTypeOf(a.CallType), nil
+	}
+	return "", apperr.New(apperr.InvalidInput, "", fmt.Sprintf("unknown cohort dimension %q (want one of %s)", dim, strings.Join(Dimensions, ", ")))
+}
+
+// Labels returns every label of dimension dim in config order.
+func (c *Config) Labels(dim string) []string {
+	switch dim {
+	case DimVintage:
+		out := []string{}
+		for _, b := range c.Vintage.Buckets {
+			out = append(out, b.Label)
+		}
+		return append(out, c.Vintage.Unknown)
+	case DimCityTier:
+		return c.CityTier.labels()
+	case DimCallType:
+		return c.CallType.labels()
+	}
+	return nil
+}
+
+func (g *Grouping) label(v string) string {
+	v = normalize(v)
+	if v == "" {
+		return g.Unknown
+	}
+	if l, ok := g.index[v]; ok {
+		return l
+	}
+	return g.Default
+}
+
+func (g *Grouping) labels() []string {
+	out := make([]string, 0, len(g.Groups)+2)
+	for l := range g.Groups {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return append(out, g.Default, g.Unknown)
+}
+
+func normalize(v string) string {
+	return strings.ToLower(strings.Join(strings.Fields(v), " "))
+}
+
+// Validate checks labels are set and unique and vintage boundaries ascend.
+func (c *Config) Validate() error {
+	v := c.Vintage
+	if len(v.Buckets) == 0 {
+		return errors.New("vintage: at least one bucket is required")
+	}
+	if strings.TrimSpace(v.Unknown) == "" {
+		return errors.New("vintage: the unknown label is required")
+	}
+	labels := map[string]bool{v.Unknown: true}
+	prev := -1
+	for i, b := range v.Buckets {
+		if strings.TrimSpace(b.Label) == "" {
+			return fmt.Errorf("vintage bucket %d: label is required", i)
+		}
+		if labels[b.Label] {
+			return fmt.Errorf("vintage: duplicate label %q", b.Label)
+		}
+		labels[b.Label] = true
+		last := i == len(v.Buckets)-1
+		switch {
+		case b.MaxMonths == nil && !last:
+			return fmt.Errorf("vintage bucket %q: only the last bucket may omit max_months", b.Label)
+		case b.MaxMonths != nil && last:
+			return fmt.Errorf("vintage bucket %q: the last bucket must omit max_months so every vintage has a bucket", b.Label)
+		case b.MaxMonths != nil && *b.MaxMonths <= prev:
+			return fmt.Errorf("vintage bucket %q: max_months must be greater than the previous bucket's", b.Label)
+		}
+		if b.MaxMonths != nil {
+			prev = *b.MaxMonths
+		}
+	}
+	if err := c.CityTier.build(); err != nil {
+		return fmt.Errorf("city_tier: %w", err)
+	}
+	if err := c.CallType.build(); err != nil {
+		return fmt.Errorf("call_type: %w", err)
+	}
+	return nil
+}
+
+// build validates g and indexes its values.
+func (g *Grouping) build() error {
+	if strings.TrimSpace(g.Unknown) == "" || strings.TrimSpace(g.Default) == "" {
+		return errors.New("the unknown and default labels are required")
+	}
+	if g.Unknown == g.Default {
+		return errors.New("the unknown and default labels must differ")
+	}
+	g.index = map[string]string{}
+	for label, values := range g.Groups {
+		if strings.TrimSpace(label) == "" {
+			return errors.New("group labels must not be empty")
+		}
+		if label == g.Unknown || label == g.Default {
+			return fmt.Errorf("group %q clashes with the unknown or default label", label)
+		}
+		for _, v := range values {
+			key := normalize(v)
+			if key == "" {
+				continue
+			}
+			if other, dup := g.index[key]; dup && other != label {
+				return fmt.Errorf("%q is in both %q and %q", v, other, label)
+			}
+			g.index[key] = label
+		}
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current *Config
+)
+
+// Current returns the active configuration: the last one saved with Replace, else
+// COHORTS_PATH, else the embedded default.
+func Current() (*Config, error) {
+	mu.RLock()
+	c := current
+	mu.RUnlock()
+	if c != nil {
+		return c, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil {
+		return current, nil
+	}
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var loaded Config
+	source := "store"
+	err = st.Get(collection, currentID, &loaded)
+	if errors.Is(err, store.ErrNotFound) {
+		data := defaultConfig
+		source = "embedded"
+		if path := os.Getenv("COHORTS_PATH"); path != "" {
+			if data, err = os.ReadFile(path); err != nil {
+				return nil, fmt.Errorf("read cohorts: %w", err)
+			}
+			source = path
+		}
+		loaded = Config{}
+		err = json.Unmarshal(data, &loaded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load cohorts: %w", err)
+	}
+	if err := loaded.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid cohorts from %s: %w", source, err)
+	}
+	logger.New().WithField("component", "cohort").WithField("source", source).WithField("version", loaded.Version).Info("cohort config loaded")
+	current = &loaded
+	return current, nil
+}
+
+// Replace validates next, bumps the version past the current one and persists it. Dataset
+// summaries pick it up on their next full refresh.
+func Replace(next Config) (*Config, error) {
+	if _, err := Current(); err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, apperr.Wrap(apperr.InvalidInput, "", "invalid cohort config", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	next.Version = current.Version + 1
+	next.UpdatedAt = time.Now().UTC()
+
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Put(collection, currentID, &next); err != nil {
+		return nil, fmt.Errorf("save cohorts: %w", err)
+	}
+	current = &next
+	return current, nil
+}
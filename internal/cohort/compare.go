@@ -0,0 +1,204 @@
+package cohort
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/scorecard"
+	"voice-insights-go/internal/types"
+)
+
+// Metrics are per-call KPI means of a cohort; rates are fractions of its calls.
+type Metrics struct {
+	Rapport              float64 `json:"rapport"`
+	Professionalism      float64 `json:"professionalism"`
+	SolutionAccuracy     float64 `json:"solution_accuracy"`
+	ResolutionLikelihood float64 `json:"resolution_likelihood"`
+	Frustration          float64 `json:"frustration"`
+	ConfusionLevel       float64 `json:"confusion_level"`
+	RiskOfChurn          float64 `json:"risk_of_churn"`
+	ConfusedRate         float64 `json:"confused_rate"`
+	CompliancePassRate   float64 `json:"compliance_pass_rate"`
+}
+
+func (m Metrics) minus(o Metrics) Metrics {
+	return Metrics{
+		Rapport:              m.Rapport - o.Rapport,
+		Professionalism:      m.Professionalism - o.Professionalism,
+		SolutionAccuracy:     m.SolutionAccuracy - o.SolutionAccuracy,
+		ResolutionLikelihood: m.ResolutionLikelihood - o.ResolutionLikelihood,
+		Frustration:          m.Frustration - o.Frustration,
+		ConfusionLevel:       m.ConfusionLevel - o.ConfusionLevel,
+		RiskOfChurn:          m.RiskOfChurn - o.RiskOfChurn,
+		ConfusedRate:         m.ConfusedRate - o.ConfusedRate,
+		CompliancePassRate:   m.CompliancePassRate - o.CompliancePassRate,
+	}
+}
+
+// Stats is one cohort's call count and KPI means.
+type Stats struct {
+	Label   string  `json:"label"`
+	Calls   int     `json:"calls"`
+	Metrics Metrics `json:"metrics"`
+}
+
+// Comparison contrasts cohort B with baseline A along one dimension. Deltas are B minus A.
+// Cohorts lists every cohort with calls in the window, so other pairs can be picked.
+type Comparison struct {
+	Dimension     string           `json:"dimension"`
+	ConfigVersion int              `json:"config_version"`
+	Window        scorecard.Window `json:"window"`
+	A             Stats            `json:"a"`
+	B             Stats            `json:"b"`
+	Deltas        Metrics          `json:"deltas"`
+	Cohorts       []Stats          `json:"cohorts"`
+}
+
+// AttributesOf returns the cohort attributes recorded on a job.
+func AttributesOf(j *jobs.Job) Attributes {
+	return Attributes{VintageMonths: j.VintageMonths, City: j.City, CallType: j.CallType}
+}
+
+// Compare computes KPI means per cohort of dim over the completed calls in w and the deltas
+// of cohort b against cohort a.
+func Compare(all []*jobs.Job, c *Config, dim, a, b string, w scorecard.Window) (Comparison, error) {
+	labels := c.Labels(dim)
+	if labels == nil {
+		return Comparison{}, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("unknown cohort dimension %q (want one of %s)", dim, strings.Join(Dimensions, ", ")))
+	}
+	for _, l := range []string{a, b} {
+		if !contains(labels, l) {
+			return Comparison{}, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("unknown %s cohort %q (want one of %s)", dim, l, strings.Join(labels, ", ")))
+		}
+	}
+
+	type acc struct {
+		stats     Stats
+		classed   int
+		confused  int
+		passed    int
+		evaluated int
+	}
+	byLabel := map[string]*acc{}
+	for _, j := range all {
+		if !w.Contains(j.At()) || (j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial) {
+			continue
+		}
+		label, err := c.Label(dim, AttributesOf(j))
+		if err != nil {
+			return Comparison{}, err
+		}
+		ac := byLabel[label]
+		if ac == nil {
+			ac = &acc{stats: Stats{Label: label}}
+			byLabel[label] = ac
+		}
+		x := j.Result.KPI
+		m := &ac.stats.Metrics
+		ac.stats.Calls++
+		m.Rapport += x.AgentAnalysis.RapportScore
+		m.Professionalism += x.AgentAnalysis.ProfessionalismScore
+		m.SolutionAccuracy += x.AgentAnalysis.SolutionAccuracyScore
+		m.ResolutionLikelihood += x.KPI.ResolutionLikelihood
+		m.Frustration += x.KPI.FrustrationScore
+		m.ConfusionLevel += x.KPI.ConfusionLevel
+		m.RiskOfChurn += j.Result.ChurnRisk()
+		if cls := j.Result.Classification; cls != nil {
+			ac.classed++
+			if cls.Confused {
+				ac.confused++
+			}
+		}
+		if rep := j.Result.Compliance; rep != nil {
+			for _, r := range rep.Results {
+				if r.Status == types.ComplianceNotApplicable {
+					continue
+				}
+				ac.evaluated++
+				if r.Status == types.CompliancePass {
+					ac.passed++
+				}
+			}
+		}
+	}
+
+	cmp := Comparison{Dimension: dim, ConfigVersion: c.Version, Window: w, Cohorts: []Stats{}}
+	stats := map[string]Stats{}
+	for label, ac := range byLabel {
+		s := ac.stats
+		n := float64(s.Calls)
+		m := &s.Metrics
+		m.Rapport /= n
+		m.Professionalism /= n
+		m.SolutionAccuracy /= n
+		m.ResolutionLikelihood /= n
+		m.Frustration /= n
+		m.ConfusionLevel /= n
+		m.RiskOfChurn /= n
+		if ac.classed > 0 {
+			m.ConfusedRate = float64(ac.confused) / float64(ac.classed)
+		}
+		// as on scorecards, a cohort with no audited calls is neutral rather than failing
+		m.CompliancePassRate = 1
+		if ac.evaluated > 0 {
+			m.CompliancePassRate = float64(ac.passed) / float64(ac.evaluated)
+		}
+		stats[label] = s
+		cmp.Cohorts = append(cmp.Cohorts, s)
+	}
+	order := map[string]int{}
+	for i, l := range labels {
+		order[l] = i
+	}
+	sort.Slice(cmp.Cohorts, func(i, j int) bool { return order[cmp.Cohorts[i].Label] < order[cmp.Cohorts[j].Label] })
+
+	cmp.A, cmp.B = stats[a], stats[b]
+	cmp.A.Label, cmp.B.Label = a, b
+	if cmp.A.Calls > 0 && cmp.B.Calls > 0 {
+		cmp.Deltas = cmp.B.Metrics.minus(cmp.A.Metrics)
+	}
+	return cmp, nil
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown renders the comparison as a report table.
+func Markdown(cmp Comparison) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Cohort comparison: %s — %s vs %s\n\n", cmp.Dimension, cmp.B.Label, cmp.A.Label)
+	fmt.Fprintf(&b, "Window %s to %s, cohort config v%d.\n\n", cmp.Window.From.Format("2006-01-02"), cmp.Window.To.Format("2006-01-02"), cmp.ConfigVersion)
+	if cmp.A.Calls == 0 || cmp.B.Calls == 0 {
+		fmt.Fprintf(&b, "Not enough calls to compare (%s: %d, %s: %d).\n", cmp.A.Label, cmp.A.Calls, cmp.B.Label, cmp.B.Calls)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "| Metric | %s (%d calls) | %s (%d calls) | Delta |\n|---|---|---|---|\n", cmp.A.Label, cmp.A.Calls, cmp.B.Label, cmp.B.Calls)
+	a, bm, d := cmp.A.Metrics, cmp.B.Metrics, cmp.Deltas
+	rows := []struct {
+		name    string
+		a, b, d float64
+	}{
+		{"Rapport", a.Rapport, bm.Rapport, d.Rapport},
+		{"Professionalism", a.Professionalism, bm.Professionalism, d.Professionalism},
+		{"Solution accuracy", a.SolutionAccuracy, bm.SolutionAccuracy, d.SolutionAccuracy},
+		{"Resolution likelihood", a.ResolutionLikelihood, bm.ResolutionLikelihood, d.ResolutionLikelihood},
+		{"Frustration", a.Frustration, bm.Frustration, d.Frustration},
+		{"Confusion level", a.ConfusionLevel, bm.ConfusionLevel, d.ConfusionLevel},
+		{"Risk of churn", a.RiskOfChurn, bm.RiskOfChurn, d.RiskOfChurn},
+		{"Confused rate", a.ConfusedRate, bm.ConfusedRate, d.ConfusedRate},
+		{"Compliance pass rate", a.CompliancePassRate, bm.CompliancePassRate, d.CompliancePassRate},
+	}
+	for _, r := range rows {
+		fmt.Fprintf(&b, "| %s | %.2f | %.2f | %+.2f |\n", r.name, r.a, r.b, r.d)
+	}
+	return b.String()
+}
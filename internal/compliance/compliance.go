@@ -0,0 +1,354 @@
+package compliance
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/transcript"
+	"voice-insights-go/internal/types"
+)
+
+//go:embed rules.json
+var defaultRules []byte
+
+// Rule types.
+const (
+	TypeRequired   = "required"   // a phrase must be said (optionally within a window of turns)
+	TypeProhibited = "prohibited" // no phrase may be said
+	TypePrecedes   = "precedes"   // a phrase must be said before the first trigger phrase
+)
+
+// Rule is one script requirement. Phrases are matched on normalized text (lowercase, punctuation
+// stripped) as whole-word sequences; a phrase prefixed with "re:" is a regular expression over
+// the same normalized text. Hinglish variants are simply listed as additional phrases.
+type Rule struct {
+	ID               string   `json:"id"`
+	Description      string   `json:"description"`
+	Severity         string   `json:"severity"`
+	Type             string   `json:"type"`
+	Speaker          string   `json:"speaker"` // agent (default) | customer | any
+	Phrases          []string `json:"phrases"`
+	Triggers         []string `json:"triggers,omitempty"`
+	WithinFirstTurns int      `json:"within_first_turns,omitempty"`
+	WithinLastTurns  int      `json:"within_last_turns,omitempty"`
+
+	phrases  []matcher
+	triggers []matcher
+}
+
+// Ruleset is the versioned rule configuration.
+type Ruleset struct {
+	Version string `json:"version"`
+	// AgentCues identify the agent when the transcript only has "Speaker N" labels
+	AgentCues []string `json:"agent_cues"`
+	Rules     []Rule   `json:"rules"`
+
+	cues []matcher
+}
+
+type matcher struct {
+	phrase string
+	re     *regexp.Regexp
+}
+
+func (m matcher) match(norm string) bool {
+	if m.re != nil {
+		return m.re.MatchString(norm)
+	}
+	return strings.Contains(" "+norm+" ", " "+m.phrase+" ")
+}
+
+func compile(phrases []string) ([]matcher, error) {
+	out := make([]matcher, 0, len(phrases))
+	for _, p := range phrases {
+		if expr, ok := strings.CutPrefix(p, "re:"); ok {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("phrase %q: %w", p, err)
+			}
+			out = append(out, matcher{phrase: p, re: re})
+			continue
+		}
+		out = append(out, matcher{phrase: normalize(p)})
+	}
+	return out, nil
+}
+
+// Parse validates and compiles a ruleset.
+func Parse(data []byte) (*Ruleset, error) {
+	var rs Ruleset
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("decode ruleset: %w", err)
+	}
+	var err error
+	if rs.cues, err = compile(rs.AgentCues); err != nil {
+		return nil, fmt.Errorf("agent_cues: %w", err)
+	}
+	seen := map[string]bool{}
+	for i := range rs.Rules {
+		r := &rs.Rules[i]
+		if r.ID == "" || seen[r.ID] {
+			return nil, fmt.Errorf("rule %d: missing or duplicate id %q", i, r.ID)
+		}
+		seen[r.ID] = true
+		switch r.Type {
+		case TypeRequired, TypeProhibited:
+		case TypePrecedes:
+			if len(r.Triggers) == 0 {
+				return nil, fmt.Errorf("rule %s: precedes rule needs triggers", r.ID)
+			}
+		default:
+			return nil, fmt.Errorf("rule %s: unknown type %q", r.ID, r.Type)
+		}
+		if len(r.Phrases) == 0 {
+			return nil, fmt.Errorf("rule %s: no phrases", r.ID)
+		}
+		if r.Speaker == "" {
+			r.Speaker = "agent"
+		}
+		if r.phrases, err = compile(r.Phrases); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		if r.triggers, err = compile(r.Triggers); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return &rs, nil
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Ruleset
+	loadErr  error
+)
+
+// Load returns the ruleset from COMPLIANCE_RULES_PATH, or the embedded default rules.
+func Load() (*Ruleset, error) {
+	loadOnce.Do(func() {
+		log := logger.New().WithField("component", "compliance")
+		data := defaultRules
+		if path := os.Getenv("COMPLIANCE_RULES_PATH"); path != "" {
+			data, loadErr = os.ReadFile(path)
+			if loadErr != nil {
+				loadErr = fmt.Errorf("read compliance rules: %w", loadErr)
+				return
+			}
+		}
+		loaded, loadErr = Parse(data)
+		if loadErr == nil {
+			log.WithField("version", loaded.Version).WithField("rules", len(loaded.Rules)).Info("compliance rules loaded")
+		}
+	})
+	return loaded, loadErr
+}
+
+type turn struct {
+	transcript.Turn
+	norm string
+}
+
+// Check evaluates every rule against the transcript.
+func Check(transcriptText string, rs *Ruleset) types.ComplianceReport {
+	var turns []turn
+	for _, t := range transcript.Parse(transcriptText) {
+		turns = append(turns, turn{Turn: t, norm: normalize(t.Text)})
+	}
+	agent := agentSpeaker(turns, rs)
+
+	rep := types.ComplianceReport{
+		RulesetVersion: rs.Version,
+		AgentSpeaker:   agent,
+		Results:        make([]types.ComplianceResult, 0, len(rs.Rules)),
+		LLMFlags:       []string{},
+	}
+	for _, r := range rs.Rules {
+		res := evaluate(r, speakerTurns(turns, r.Speaker, agent))
+		switch res.Status {
+		case types.CompliancePass:
+			rep.Passed++
+		case types.ComplianceFail:
+			rep.Failed++
+		}
+		rep.Results = append(rep.Results, res)
+	}
+	if n := rep.Passed + rep.Failed; n > 0 {
+		rep.Score = float64(rep.Passed) / float64(n)
+	}
+	return rep
+}
+
+func evaluate(r Rule, turns []turn) types.ComplianceResult {
+	res := types.ComplianceResult{RuleID: r.ID, Description: r.Description, Severity: r.Severity, Turn: -1}
+	hit := func(t turn) {
+		res.Turn, res.Speaker, res.Evidence = t.Index, t.Speaker, evidence(t.Text)
+	}
+
+	switch r.Type {
+	case TypeRequired:
+		window := turns
+		where := ""
+		if r.WithinFirstTurns > 0 && len(window) > r.WithinFirstTurns {
+			window, where = window[:r.WithinFirstTurns], fmt.Sprintf(" in the first %d %s turns", r.WithinFirstTurns, r.Speaker)
+		}
+		if r.WithinLastTurns > 0 && len(window) > r.WithinLastTurns {
+			window, where = window[len(window)-r.WithinLastTurns:], fmt.Sprintf(" in the last %d %s turns", r.WithinLastTurns, r.Speaker)
+		}
+		if t, p, ok := first(window, r.phrases); ok {
+			hit(t)
+			res.Status, res.Reason = types.CompliancePass, fmt.Sprintf("matched %q", p)
+			return res
+		}
+		res.Status, res.Reason = types.ComplianceFail, "no required phrase"+where
+
+	case TypeProhibited:
+		if t, p, ok := first(turns, r.phrases); ok {
+			hit(t)
+			res.Status, res.Reason = types.ComplianceFail, fmt.Sprintf("prohibited phrase %q", p)
+			return res
+		}
+		res.Status, res.Reason = types.CompliancePass, "no prohibited phrase"
+
+	case TypePrecedes:
+		trig, tp, ok := first(turns, r.triggers)
+		if !ok {
+			res.Status, res.Reason = types.ComplianceNotApplicable, "no trigger phrase in call"
+			return res
+		}
+		var before []turn
+		for _, t := range turns {
+			if t.Index >= trig.Index {
+				break
+			}
+			before = append(before, t)
+		}
+		if t, p, ok := first(before, r.phrases); ok {
+			hit(t)
+			res.Status, res.Reason = types.CompliancePass, fmt.Sprintf("matched %q before trigger %q at turn %d", p, tp, trig.Index)
+			return res
+		}
+		hit(trig)
+		res.Status, res.Reason = types.ComplianceFail, fmt.Sprintf("trigger %q with no required phrase before it", tp)
+	}
+	return res
+}
+
+func first(turns []turn, ms []matcher) (turn, string, bool) {
+	for _, t := range turns {
+		for _, m := range ms {
+			if m.match(t.norm) {
+				return t, m.phrase, true
+			}
+		}
+	}
+	return turn{}, "", false
+}
+
+func speakerTurns(turns []turn, role, agent string) []turn {
+	if role == "any" {
+		return turns
+	}
+	var out []turn
+	for _, t := range turns {
+		if (t.Speaker == agent) == (role == "agent") {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// agentSpeaker picks the agent's label: an explicit Agent/Executive label wins, otherwise the
+// speaker with the most agent-cue matches (ties go to the first speaker).
+func agentSpeaker(turns []turn, rs *Ruleset) string {
+	score := map[string]int{}
+	var order []string
+	for _, t := range turns {
+		switch t.Speaker {
+		case "Agent", "Executive":
+			return t.Speaker
+		}
+		if _, ok := score[t.Speaker]; !ok {
+			order = append(order, t.Speaker)
+		}
+		for _, c := range rs.cues {
+			if c.match(t.norm) {
+				score[t.Speaker]++
+			}
+		}
+	}
+	best, bestScore := "", -1
+	for _, s := range order {
+		if score[s] > bestScore {
+			best, bestScore = s, score[s]
+		}
+	}
+	return best
+}
+
+// MergeLLMFlags records the model's compliance flags on the report and appends failed rules
+// to x.AgentAnalysis.ComplianceFlags, so consumers of the extraction see both. Rule flags
+// already in x (from an earlier attempt of the same call) are replaced, not repeated.
+func MergeLLMFlags(rep *types.ComplianceReport, x *types.KPIExtraction) {
+	ids := map[string]bool{}
+	for _, r := range rep.Results {
+		ids[r.RuleID] = true
+	}
+	llm := stripRuleFlags(x.AgentAnalysis.ComplianceFlags, ids)
+	rep.LLMFlags = append([]string{}, llm...)
+	for _, r := range rep.Results {
+		if r.Status != types.ComplianceFail {
+			continue
+		}
+		flag := fmt.Sprintf("rule %s: %s (turn %d)", r.RuleID, r.Description, r.Turn)
+		if r.Turn < 0 {
+			flag = fmt.Sprintf("rule %s: %s", r.RuleID, r.Description)
+		}
+		llm = append(llm, flag)
+	}
+	x.AgentAnalysis.ComplianceFlags = llm
+}
+
+// StripRuleFlags removes the flags MergeLLMFlags added for the rules of rs, leaving the
+// model's own. An extraction reused by a retry goes through it before being checked again.
+func StripRuleFlags(x *types.KPIExtraction, rs *Ruleset) {
+	ids := map[string]bool{}
+	for _, r := range rs.Rules {
+		ids[r.ID] = true
+	}
+	x.AgentAnalysis.ComplianceFlags = stripRuleFlags(x.AgentAnalysis.ComplianceFlags, ids)
+}
+
+// stripRuleFlags keeps the flags that are not "rule <id>: ..." for one of ids.
+func stripRuleFlags(flags []string, ids map[string]bool) []string {
+	out := make([]string, 0, len(flags))
+	for _, f := range flags {
+		if rest, ok := strings.CutPrefix(f, "rule "); ok {
+			if id, _, ok := strings.Cut(rest, ": "); ok && ids[id] {
+				continue
+			}
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+func evidence(s string) string {
+	r := []rune(s)
+	if len(r) > 160 {
+		return string(r[:160]) + "..."
+	}
+	return s
+}
+
+func normalize(s string) string {
+	s = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	}), " ")
+}
@@ -0,0 +1,142 @@
+package compliance
+
+import (
+	"reflect"
+	"testing"
+
+	"voice-insights-go/internal/types"
+)
+
+const testRules = `{
+  "version": "test",
+  "agent_cues": ["how may i help you", "thank you for calling"],
+  "rules": [
+    {"id": "greeting", "type": "required", "within_first_turns": 2, "phrases": ["thank you for calling", "namaste"]},
+    {"id": "closing", "type": "required", "within_last_turns": 1, "phrases": ["anything else"]},
+    {"id": "no_guarantee", "type": "prohibited", "phrases": ["guaranteed returns", "re:\\b100 ?percent\\b"]},
+    {"id": "recording_before_payment", "type": "precedes", "phrases": ["call is recorded"], "triggers": ["card number"]}
+  ]
+}`
+
+func statuses(rep types.ComplianceReport) map[string]types.ComplianceStatus {
+	out := map[string]types.ComplianceStatus{}
+	for _, r := range rep.Results {
+		out[r.RuleID] = r.Status
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	rs, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pass, fail, na := types.CompliancePass, types.ComplianceFail, types.ComplianceNotApplicable
+	tests := []struct {
+		name       string
+		transcript string
+		agent      string
+		want       map[string]types.ComplianceStatus
+	}{
+		{
+			name: "clean call",
+			transcript: "Agent: Thank you for calling, how may I help you?\n" +
+				"Customer: I want to pay my bill.\n" +
+				"Agent: This call is recorded.\n" +
+				"Agent: Please share your card number.\n" +
+				"Agent: Is there anything else?",
+			agent: "Agent",
+			want:  map[string]types.ComplianceStatus{"greeting": pass, "closing": pass, "no_guarantee": pass, "recording_before_payment": pass},
+		},
+		{
+			name: "late greeting, promise, card before disclosure",
+			transcript: "Agent: Hello.\n" +
+				"Agent: One second.\n" +
+				"Agent: Thank you for calling. Returns are 100 percent guaranteed.\n" +
+				"Agent: Read me your card number.\n" +
+				"Agent: This call is recorded.",
+			agent: "Agent",
+			want:  map[string]types.ComplianceStatus{"greeting": fail, "closing": fail, "no_guarantee": fail, "recording_before_payment": fail},
+		},
+		{
+			name: "no trigger, agent found by cues, customer words do not count",
+			transcript: "Speaker 1: Namaste, how may I help you?\n" +
+				"Speaker 2: Is there anything else you offer? Guaranteed returns?\n" +
+				"Speaker 1: No.",
+			agent: "Speaker 1",
+			want:  map[string]types.ComplianceStatus{"greeting": pass, "closing": fail, "no_guarantee": pass, "recording_before_payment": na},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rep := Check(tt.transcript, rs)
+			if rep.AgentSpeaker != tt.agent {
+				t.Errorf("agent = %q, want %q", rep.AgentSpeaker, tt.agent)
+			}
+			if got := statuses(rep); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statuses = %v, want %v", got, tt.want)
+			}
+			if n := rep.Passed + rep.Failed; n > 0 && rep.Score != float64(rep.Passed)/float64(n) {
+				t.Errorf("score = %v with %d passed of %d", rep.Score, rep.Passed, n)
+			}
+		})
+	}
+}
+
+func TestCheckEvidence(t *testing.T) {
+	rs, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := Check("Agent: Hi.\nAgent: Your card number please.\nAgent: This call is recorded.", rs)
+	for _, r := range rep.Results {
+		if r.RuleID != "recording_before_payment" {
+			continue
+		}
+		if r.Turn != 1 || r.Evidence != "Your card number please." {
+			t.Errorf("result = %+v, want the trigger turn as evidence", r)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, bad := range []string{
+		`{"rules": [{"id": "a", "type": "required", "phrases": []}]}`,
+		`{"rules": [{"id": "a", "type": "precedes", "phrases": ["x"]}]}`,
+		`{"rules": [{"id": "a", "type": "sometimes", "phrases": ["x"]}]}`,
+		`{"rules": [{"id": "a", "type": "required", "phrases": ["x"]}, {"id": "a", "type": "required", "phrases": ["y"]}]}`,
+		`{"rules": [{"id": "a", "type": "required", "phrases": ["re:("]}]}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%s) succeeded", bad)
+		}
+	}
+	if _, err := Parse(defaultRules); err != nil {
+		t.Errorf("default rules: %v", err)
+	}
+}
+
+func TestMergeLLMFlagsIsRepeatable(t *testing.T) {
+	rs, err := Parse([]byte(testRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := Check("Agent: Hello.\nAgent: Bye.", rs)
+	x := &types.KPIExtraction{}
+	x.AgentAnalysis.ComplianceFlags = []string{"agent was rude"}
+	MergeLLMFlags(&rep, x)
+	first := append([]string{}, x.AgentAnalysis.ComplianceFlags...)
+	if len(first) != 1+rep.Failed || !reflect.DeepEqual(rep.LLMFlags, []string{"agent was rude"}) {
+		t.Errorf("flags = %v, llm flags = %v", first, rep.LLMFlags)
+	}
+
+	StripRuleFlags(x, rs)
+	if !reflect.DeepEqual(x.AgentAnalysis.ComplianceFlags, []string{"agent was rude"}) {
+		t.Errorf("stripped flags = %v", x.AgentAnalysis.ComplianceFlags)
+	}
+	MergeLLMFlags(&rep, x)
+	MergeLLMFlags(&rep, x)
+	if !reflect.DeepEqual(x.AgentAnalysis.ComplianceFlags, first) {
+		t.Errorf("flags after merging again = %v, want %v", x.AgentAnalysis.ComplianceFlags, first)
+	}
+}
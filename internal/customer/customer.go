@@ -0,0 +1,220 @@
+// Package customer links calls from the same customer or seller. Callers are identified by a
+// keyed hash of their phone number or account id, never the raw value, and every processed
+// call is recorded against that id so repeat contacts can be found and fed back into the
+// extraction.
+package customer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/store"
+)
+
+const (
+	collection      = "customers"
+	aliasCollection = "customer_aliases"
+)
+
+// account is the hash namespace of account and seller ids; phones use redact.Phone so the
+// same number spoken or typed differently hashes alike.
+const account redact.Kind = "ACCOUNT"
+
+// Call is one contact in a customer's history.
+type Call struct {
+	JobID string    `json:"job_id"`
+	At    time.Time `json:"at"`
+}
+
+// Customer is the linked call history of one caller.
+type Customer struct {
+	ID        string    `json:"id"`
+	Calls     []Call    `json:"calls"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// alias maps one hashed identifier to the customer it belongs to.
+type alias struct {
+	CustomerID string `json:"customer_id"`
+}
+
+// mu serialises identification and linking, which read then write the alias index.
+var mu sync.Mutex
+
+// hashKey is CUSTOMER_HASH_KEY. There is no default and no fallback to the PII keys: a
+// guessable key would make phone hashes reversible by enumeration, and sharing the vault
+// key would tie customer ids to it.
+func hashKey() ([]byte, error) {
+	if v := os.Getenv("CUSTOMER_HASH_KEY"); v != "" {
+		return []byte(v), nil
+	}
+	return nil, apperr.New(apperr.Internal, "", "customer linking requires CUSTOMER_HASH_KEY")
+}
+
+// identifiers hashes the phone and account given, account first since it is the stronger
+// identifier.
+func identifiers(phone, acct string) ([]string, error) {
+	phone, acct = strings.TrimSpace(phone), strings.TrimSpace(acct)
+	if phone == "" && acct == "" {
+		return nil, apperr.New(apperr.InvalidInput, "", "a phone number or account id is required")
+	}
+	key, err := hashKey()
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if acct != "" {
+		ids = append(ids, "a_"+redact.Hash(key, account, acct))
+	}
+	if phone != "" {
+		if n := redact.Normalize(redact.Phone, phone); len(n) < 6 || strings.Trim(n, "0123456789") != "" {
+			return nil, apperr.New(apperr.InvalidInput, "", "phone number must be digits")
+		}
+		ids = append(ids, "p_"+redact.Hash(key, redact.Phone, phone))
+	}
+	return ids, nil
+}
+
+// resolve returns the customer an identifier already belongs to, or "".
+func resolve(st *store.Store, ids []string) (string, error) {
+	for _, id := range ids {
+		var a alias
+		err := st.Get(aliasCollection, id, &a)
+		if err == nil {
+			return a.CustomerID, nil
+		}
+		if !errors.Is(err, store.ErrNotFound) {
+			return "", fmt.Errorf("load customer alias: %w", err)
+		}
+	}
+	return "", nil
+}
+
+// Lookup returns the customer id for a phone number or account id without creating one.
+func Lookup(phone, acct string) (string, error) {
+	ids, err := identifiers(phone, acct)
+	if err != nil {
+		return "", err
+	}
+	st, err := store.Default()
+	if err != nil {
+		return "", err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	id, err := resolve(st, ids)
+	if err == nil && id == "" {
+		err = apperr.New(apperr.NotFound, "", "no calls from this customer")
+	}
+	return id, err
+}
+
+// Attach identifies the caller of j from a phone number and/or account id, records the call
+// in their history and sets j.CustomerID. A call giving both identifiers joins them, so
+// later calls giving either one link to the same customer; an identifier already linked to
+// another customer keeps its link.
+func Attach(j *jobs.Job, phone, acct string) error {
+	ids, err := identifiers(phone, acct)
+	if err != nil {
+		return err
+	}
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	cid, err := resolve(st, ids)
+	if err != nil {
+		return err
+	}
+	if cid == "" {
+		cid = ids[0]
+	}
+	for _, id := range ids {
+		if err := st.Get(aliasCollection, id, &alias{}); errors.Is(err, store.ErrNotFound) {
+			if err := st.Put(aliasCollection, id, alias{CustomerID: cid}); err != nil {
+				return fmt.Errorf("save customer alias: %w", err)
+			}
+		}
+	}
+	j.CustomerID = cid
+	return link(st, cid, Call{JobID: j.ID, At: j.At()})
+}
+
+// link adds call to the customer's history; a job already there is updated in place.
+func link(st *store.Store, cid string, call Call) error {
+	c := Customer{ID: cid}
+	if err := st.Get(collection, cid, &c); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("load customer: %w", err)
+	}
+	found := false
+	for i := range c.Calls {
+		if c.Calls[i].JobID == call.JobID {
+			c.Calls[i], found = call, true
+		}
+	}
+	if !found {
+		c.Calls = append(c.Calls, call)
+	}
+	sort.Slice(c.Calls, func(a, b int) bool { return c.Calls[a].At.Before(c.Calls[b].At) })
+	c.FirstSeen, c.LastSeen = c.Calls[0].At, c.Calls[len(c.Calls)-1].At
+	c.UpdatedAt = time.Now().UTC()
+	if err := st.Put(collection, cid, c); err != nil {
+		return fmt.Errorf("save customer: %w", err)
+	}
+	return nil
+}
+
+// Get loads a customer by id.
+func Get(id string) (Customer, error) {
+	st, err := store.Default()
+	if err != nil {
+		return Customer{}, err
+	}
+	var c Customer
+	if err := st.Get(collection, id, &c); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return c, apperr.New(apperr.NotFound, "", "customer not found")
+		}
+		return c, fmt.Errorf("load customer: %w", err)
+	}
+	return c, nil
+}
+
+// List returns customers with at least minCalls calls, most calls first.
+func List(minCalls int) ([]Customer, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	out := []Customer{}
+	err = st.List(collection, func(id string, raw json.RawMessage) error {
+		var c Customer
+		if err := json.Unmarshal(raw, &c); err != nil {
+			return fmt.Errorf("decode customer %s: %w", id, err)
+		}
+		if len(c.Calls) >= minCalls {
+			out = append(out, c)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i].Calls) != len(out[j].Calls) {
+			return len(out[i].Calls) > len(out[j].Calls)
+		}
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	return out, err
+}
@@ -0,0 +1,356 @@
+package customer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/store"
+)
+
+const summaryCollection = "customer_summaries"
+
+// trendEpsilon is the change below which a trajectory counts as flat.
+const trendEpsilon = 0.1
+
+// Step is one processed call on a customer's journey.
+type Step struct {
+	JobID                string    `json:"job_id"`
+	At                   time.Time `json:"at"`
+	Issue                string    `json:"issue"`
+	IssuePath            []string  `json:"issue_path,omitempty"`
+	PrimaryIssue         string    `json:"primary_issue"`
+	Priority             string    `json:"priority,omitempty"`
+	RequiresEscalation   bool      `json:"requires_escalation"`
+	AgentSentiment       string    `json:"agent_sentiment,omitempty"`
+	Frustration          float64   `json:"frustration"`
+	Confusion            float64   `json:"confusion"`
+	ResolutionLikelihood float64   `json:"resolution_likelihood"`
+	RiskOfChurn          float64   `json:"risk_of_churn"`
+	// Resolution is the reported outcome (resolved, unresolved, pending) or "unknown"
+	Resolution string `json:"resolution"`
+	Repeat     bool   `json:"repeat"`
+	AgentID    string `json:"agent_id,omitempty"`
+	Team       string `json:"team,omitempty"`
+	City       string `json:"city,omitempty"`
+	CallType   string `json:"call_type,omitempty"`
+}
+
+// Trend is how a score moved from the first to the latest call.
+type Trend struct {
+	First     float64 `json:"first"`
+	Last      float64 `json:"last"`
+	Mean      float64 `json:"mean"`
+	Change    float64 `json:"change"`
+	Direction string  `json:"direction"` // rising, falling or flat
+}
+
+// IssueThread groups the calls about one taxonomy node; Status is the resolution of the
+// latest of them.
+type IssueThread struct {
+	Issue     string    `json:"issue"`
+	Path      []string  `json:"path,omitempty"`
+	Calls     int       `json:"calls"`
+	FirstAt   time.Time `json:"first_at"`
+	LastAt    time.Time `json:"last_at"`
+	Status    string    `json:"status"`
+	Escalated bool      `json:"escalated"`
+}
+
+// Profile is the call metadata seen for the customer, most recent value first.
+type Profile struct {
+	Cities        []string `json:"cities,omitempty"`
+	CallTypes     []string `json:"call_types,omitempty"`
+	Agents        []string `json:"agents,omitempty"`
+	Teams         []string `json:"teams,omitempty"`
+	VintageMonths *int     `json:"vintage_months,omitempty"`
+}
+
+// AccountSummary is the narrative for account managers. Source is "llm" or, when no model
+// is configured or it failed, "rules". Fingerprint identifies the calls and outcomes it was
+// written from; a cached summary is reused only while it still matches.
+type AccountSummary struct {
+	Text        string    `json:"text"`
+	Risks       []string  `json:"risks"`
+	NextSteps   []string  `json:"next_steps"`
+	Source      string    `json:"source"`
+	Calls       int       `json:"calls"`
+	Fingerprint string    `json:"fingerprint"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Cached      bool      `json:"cached"`
+}
+
+// Journey is the full picture of one customer: processed calls in order, how frustration
+// and churn risk moved, the issues raised and whether each was resolved, and a summary.
+type Journey struct {
+	CustomerID   string           `json:"customer_id"`
+	Calls        int              `json:"calls"`
+	FirstSeen    time.Time        `json:"first_seen"`
+	LastSeen     time.Time        `json:"last_seen"`
+	Profile      Profile          `json:"profile"`
+	Steps        []Step           `json:"steps"`
+	Trajectory   map[string]Trend `json:"trajectory"`
+	Issues       []IssueThread    `json:"issues"`
+	OpenIssues   int              `json:"open_issues"`
+	Summary      AccountSummary   `json:"summary"`
+	RepeatWindow int              `json:"repeat_window_days"`
+}
+
+// BuildJourney assembles the journey of customer id. The summary comes from the cache while
+// no call or outcome has changed since it was written, unless refresh is set.
+func BuildJourney(ctx context.Context, id string, opts Options, refresh bool) (Journey, error) {
+	c, err := Get(id)
+	if err != nil {
+		return Journey{}, err
+	}
+	jr := Journey{CustomerID: c.ID, Steps: []Step{}, Issues: []IssueThread{}, Trajectory: map[string]Trend{}, RepeatWindow: opts.WindowDays}
+	var last time.Time
+	for _, call := range c.Calls {
+		j, err := jobs.Get(call.JobID)
+		if errors.Is(err, jobs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return jr, err
+		}
+		if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+			continue
+		}
+		x := j.Result.KPI
+		s := Step{
+			JobID:                j.ID,
+			At:                   call.At,
+			Issue:                issueOf(j),
+			Priority:             x.Actions.Priority,
+			RequiresEscalation:   x.Actions.RequiresEscalation,
+			AgentSentiment:       x.AgentAnalysis.AgentSentiment,
+			Frustration:          x.KPI.FrustrationScore,
+			Confusion:            x.KPI.ConfusionLevel,
+			ResolutionLikelihood: x.KPI.ResolutionLikelihood,
+			RiskOfChurn:          j.Result.ChurnRisk(),
+			Resolution:           "unknown",
+			Repeat:               !last.IsZero() && call.At.Sub(last) <= time.Duration(opts.WindowDays)*24*time.Hour,
+			AgentID:              j.AgentID,
+			Team:                 j.Team,
+			City:                 j.City,
+			CallType:             j.CallType,
+		}
+		last = call.At
+		if is := j.Result.Issues; is != nil {
+			s.IssuePath = is.PrimaryIssue.Path
+		}
+		if s.PrimaryIssue, _, err = redact.Text(x.CustomerProblem.PrimaryIssue); err != nil {
+			return jr, fmt.Errorf("redact primary issue: %w", err)
+		}
+		if o, err := outcome.Get(j.ID); err == nil {
+			s.Resolution = o.Status
+		} else if apperr.KindOf(err) != apperr.NotFound {
+			return jr, err
+		}
+		if j.VintageMonths != nil {
+			v := *j.VintageMonths
+			jr.Profile.VintageMonths = &v
+		}
+		jr.Steps = append(jr.Steps, s)
+	}
+	jr.Calls = len(jr.Steps)
+	if jr.Calls == 0 {
+		jr.Summary = AccountSummary{Text: "No processed calls yet.", Risks: []string{}, NextSteps: []string{}, Source: "rules"}
+		return jr, nil
+	}
+	jr.FirstSeen, jr.LastSeen = jr.Steps[0].At, jr.Steps[jr.Calls-1].At
+	jr.Profile = profileOf(jr.Steps, jr.Profile.VintageMonths)
+	jr.Trajectory["frustration"] = trendOf(jr.Steps, func(s Step) float64 { return s.Frustration })
+	jr.Trajectory["confusion"] = trendOf(jr.Steps, func(s Step) float64 { return s.Confusion })
+	jr.Trajectory["risk_of_churn"] = trendOf(jr.Steps, func(s Step) float64 { return s.RiskOfChurn })
+	jr.Trajectory["resolution_likelihood"] = trendOf(jr.Steps, func(s Step) float64 { return s.ResolutionLikelihood })
+	jr.Issues = threadsOf(jr.Steps)
+	for _, t := range jr.Issues {
+		if t.Status != outcome.StatusResolved {
+			jr.OpenIssues++
+		}
+	}
+	jr.Summary, err = summarize(ctx, jr, refresh)
+	return jr, err
+}
+
+func trendOf(steps []Step, score func(Step) float64) Trend {
+	t := Trend{First: score(steps[0]), Last: score(steps[len(steps)-1]), Direction: "flat"}
+	for _, s := range steps {
+		t.Mean += score(s)
+	}
+	t.Mean /= float64(len(steps))
+	t.Change = t.Last - t.First
+	switch {
+	case t.Change > trendEpsilon:
+		t.Direction = "rising"
+	case t.Change < -trendEpsilon:
+		t.Direction = "falling"
+	}
+	return t
+}
+
+func threadsOf(steps []Step) []IssueThread {
+	byIssue := map[string]*IssueThread{}
+	var order []string
+	for _, s := range steps {
+		t, ok := byIssue[s.Issue]
+		if !ok {
+			t = &IssueThread{Issue: s.Issue, Path: s.IssuePath, FirstAt: s.At}
+			byIssue[s.Issue] = t
+			order = append(order, s.Issue)
+		}
+		t.Calls++
+		t.LastAt = s.At
+		t.Status = s.Resolution
+		t.Escalated = t.Escalated || s.RequiresEscalation
+	}
+	out := make([]IssueThread, 0, len(order))
+	for _, id := range order {
+		out = append(out, *byIssue[id])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].LastAt.After(out[j].LastAt) })
+	return out
+}
+
+func profileOf(steps []Step, vintage *int) Profile {
+	p := Profile{VintageMonths: vintage}
+	add := func(list *[]string, seen map[string]bool, v string) {
+		if v != "" && !seen[strings.ToLower(v)] {
+			seen[strings.ToLower(v)] = true
+			*list = append(*list, v)
+		}
+	}
+	cities, types, agents, teams := map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for i := len(steps) - 1; i >= 0; i-- {
+		s := steps[i]
+		add(&p.Cities, cities, s.City)
+		add(&p.CallTypes, types, s.CallType)
+		add(&p.Agents, agents, s.AgentID)
+		add(&p.Teams, teams, s.Team)
+	}
+	return p
+}
+
+// fingerprint changes whenever a call is added or reprocessed or an outcome is reported.
+func fingerprint(jr Journey) string {
+	h := sha256.New()
+	for _, s := range jr.Steps {
+		fmt.Fprintf(h, "%s|%s|%s|%.3f|%.3f\n", s.JobID, s.Issue, s.Resolution, s.Frustration, s.RiskOfChurn)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// summarize returns the cached summary while its fingerprint matches, else writes a new
+// one. Only model-written summaries are cached; the rules fallback is cheap to rebuild and
+// should not hide a model that has since recovered.
+func summarize(ctx context.Context, jr Journey, refresh bool) (AccountSummary, error) {
+	st, err := store.Default()
+	if err != nil {
+		return AccountSummary{}, err
+	}
+	fp := fingerprint(jr)
+	var cached AccountSummary
+	if !refresh {
+		err := st.Get(summaryCollection, jr.CustomerID, &cached)
+		if err == nil && cached.Fingerprint == fp {
+			cached.Cached = true
+			return cached, nil
+		}
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			return AccountSummary{}, fmt.Errorf("load account summary: %w", err)
+		}
+	}
+
+	sum := rulesSummary(jr)
+	if llm.Configured() {
+		log := logger.New().WithField("component", "customer").WithField("customer_id", jr.CustomerID)
+		var out struct {
+			Summary   string   `json:"summary"`
+			Risks     []string `json:"risks"`
+			NextSteps []string `json:"next_steps"`
+		}
+		if err := llm.CompleteJSON(ctx, summaryPrompt(jr), &out); err != nil {
+			log.WithError(err).Warn("account summary generation failed; using rules summary")
+		} else if strings.TrimSpace(out.Summary) == "" {
+			log.Warn("account summary came back empty; using rules summary")
+		} else {
+			sum.Text, sum.Source = strings.TrimSpace(out.Summary), "llm"
+			if out.Risks != nil {
+				sum.Risks = out.Risks
+			}
+			if out.NextSteps != nil {
+				sum.NextSteps = out.NextSteps
+			}
+		}
+	}
+	sum.Calls, sum.Fingerprint, sum.GeneratedAt = jr.Calls, fp, time.Now().UTC()
+	if sum.Source == "llm" {
+		if err := st.Put(summaryCollection, jr.CustomerID, sum); err != nil {
+			return sum, fmt.Errorf("save account summary: %w", err)
+		}
+	}
+	return sum, nil
+}
+
+func rulesSummary(jr Journey) AccountSummary {
+	sum := AccountSummary{Source: "rules", Risks: []string{}, NextSteps: []string{}}
+	latest := jr.Steps[jr.Calls-1]
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d call(s) between %s and %s about %d issue(s); %d not confirmed resolved.",
+		jr.Calls, jr.FirstSeen.Format("2006-01-02"), jr.LastSeen.Format("2006-01-02"), len(jr.Issues), jr.OpenIssues)
+	fmt.Fprintf(&b, " Latest call: %s (%s).", latest.Issue, latest.Resolution)
+	fr, churn := jr.Trajectory["frustration"], jr.Trajectory["risk_of_churn"]
+	fmt.Fprintf(&b, " Frustration %s (%.2f to %.2f), churn risk %s (%.2f to %.2f).", fr.Direction, fr.First, fr.Last, churn.Direction, churn.First, churn.Last)
+	sum.Text = b.String()
+
+	if churn.Last > 0.7 {
+		sum.Risks = append(sum.Risks, fmt.Sprintf("High churn risk on the latest call (%.2f)", churn.Last))
+	}
+	if fr.Direction == "rising" {
+		sum.Risks = append(sum.Risks, "Frustration is rising across calls")
+	}
+	for _, t := range jr.Issues {
+		if t.Calls > 1 && t.Status != outcome.StatusResolved {
+			sum.Risks = append(sum.Risks, fmt.Sprintf("%s raised %d times and still not resolved", t.Issue, t.Calls))
+			sum.NextSteps = append(sum.NextSteps, "Own "+t.Issue+" end to end and confirm resolution with the customer")
+		}
+	}
+	if jr.OpenIssues > 0 && len(sum.NextSteps) == 0 {
+		sum.NextSteps = append(sum.NextSteps, "Confirm whether the open issues were resolved and record the outcome")
+	}
+	return sum
+}
+
+func summaryPrompt(jr Journey) string {
+	calls, _ := json.MarshalIndent(jr.Steps, "", "  ")
+	traj, _ := json.MarshalIndent(jr.Trajectory, "", "  ")
+	issues, _ := json.MarshalIndent(jr.Issues, "", "  ")
+	return fmt.Sprintf(`You brief account managers of a B2B marketplace about one seller before they call them.
+
+CALLS (oldest first; scores are 0-1; resolution is the reported outcome or "unknown"):
+%s
+
+SCORE TRAJECTORY (first to latest call):
+%s
+
+ISSUES (status is the resolution of the latest call on that issue):
+%s
+
+Write a factual account summary in 3-5 sentences: what the seller keeps calling about, how
+their mood and churn risk are moving, and what is still unresolved. Do not invent facts.
+Return ONLY valid JSON: {"summary": "...", "risks": ["..."], "next_steps": ["..."]}
+`, calls, traj, issues)
+}
@@ -0,0 +1,113 @@
+package customer
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/types"
+)
+
+func TestTrendOf(t *testing.T) {
+	steps := func(scores ...float64) []Step {
+		out := make([]Step, len(scores))
+		for i, s := range scores {
+			out[i].Frustration = s
+		}
+		return out
+	}
+	tests := []struct {
+		name      string
+		scores    []float64
+		direction string
+		mean      float64
+	}{
+		{"rising", []float64{0.2, 0.5, 0.8}, "rising", 0.5},
+		{"falling", []float64{0.9, 0.4}, "falling", 0.65},
+		{"small moves are flat", []float64{0.5, 0.9, 0.55}, "flat", 0.65},
+		{"one call", []float64{0.7}, "flat", 0.7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := trendOf(steps(tt.scores...), func(s Step) float64 { return s.Frustration })
+			if tr.Direction != tt.direction || math.Abs(tr.Mean-tt.mean) > 1e-9 || tr.First != tt.scores[0] || tr.Last != tt.scores[len(tt.scores)-1] {
+				t.Errorf("trend = %+v, want %s with mean %v", tr, tt.direction, tt.mean)
+			}
+		})
+	}
+}
+
+func TestBuildJourney(t *testing.T) {
+	testutil.DataDir(t)
+	t.Setenv("CUSTOMER_HASH_KEY", "test-key")
+	t.Setenv("LLM_GATEWAY_URL", "") // rules summary
+	const phone = "9876543210"
+	first := call(t, phone, 0, "billing/refund", 0.2, 0.3)
+	call(t, phone, 10, "delivery/late", 0.5, 0.3)
+	last := call(t, phone, 60, "billing/refund", 0.8, 0.3)
+	// the churn model's blended score is what the journey tracks
+	last.Result.Churn = &types.ChurnPrediction{Blended: 0.9}
+	if err := jobs.Save(last); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outcome.Record(first, outcome.Update{Status: outcome.StatusResolved}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := outcome.Record(last, outcome.Update{Status: outcome.StatusUnresolved}); err != nil {
+		t.Fatal(err)
+	}
+
+	jr, err := BuildJourney(context.Background(), first.CustomerID, Options{WindowDays: 30}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jr.Calls != 3 || !jr.FirstSeen.Equal(day0) || !jr.LastSeen.Equal(last.CallTime) {
+		t.Fatalf("journey has %d calls from %v to %v", jr.Calls, jr.FirstSeen, jr.LastSeen)
+	}
+	var repeats []bool
+	for _, s := range jr.Steps {
+		repeats = append(repeats, s.Repeat)
+	}
+	if !slices.Equal(repeats, []bool{false, true, false}) {
+		t.Errorf("repeat flags = %v, want only the call 10 days after the first", repeats)
+	}
+
+	want := map[string]struct {
+		direction string
+		last      float64
+	}{
+		"frustration":   {"rising", 0.8},
+		"risk_of_churn": {"rising", 0.9},
+		"confusion":     {"flat", 0},
+	}
+	for name, w := range want {
+		if tr := jr.Trajectory[name]; tr.Direction != w.direction || tr.Last != w.last {
+			t.Errorf("%s trend = %+v, want %s ending at %v", name, tr, w.direction, w.last)
+		}
+	}
+
+	if len(jr.Issues) != 2 || jr.OpenIssues != 2 {
+		t.Fatalf("issues = %+v, open %d", jr.Issues, jr.OpenIssues)
+	}
+	refund := jr.Issues[0]
+	if refund.Issue != "billing/refund" || refund.Calls != 2 || refund.Status != outcome.StatusUnresolved {
+		t.Errorf("latest thread = %+v, want the refund raised twice and unresolved", refund)
+	}
+	if jr.Issues[1].Status != "unknown" {
+		t.Errorf("thread without an outcome = %+v", jr.Issues[1])
+	}
+
+	sum := jr.Summary
+	if sum.Source != "rules" || sum.Calls != 3 || sum.Fingerprint == "" {
+		t.Errorf("summary = %+v", sum)
+	}
+	for _, risk := range []string{"High churn risk on the latest call (0.90)", "Frustration is rising across calls", "billing/refund raised 2 times and still not resolved"} {
+		if !slices.Contains(sum.Risks, risk) {
+			t.Errorf("risks = %q, missing %q", sum.Risks, risk)
+		}
+	}
+}
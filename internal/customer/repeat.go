@@ -0,0 +1,230 @@
+package customer
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/outcome"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
+)
+
+// Options bound repeat-contact detection.
+type Options struct {
+	// WindowDays is how far back an earlier call makes this one a repeat contact
+	WindowDays int
+	// PromptLimit caps the prior contacts given to the extraction, most recent first
+	PromptLimit int
+}
+
+// OptionsFromEnv reads CUSTOMER_REPEAT_WINDOW_DAYS (default 30) and CUSTOMER_PROMPT_PRIOR_LIMIT
+// (default 5).
+func OptionsFromEnv() Options {
+	o := Options{WindowDays: 30, PromptLimit: 5}
+	if n, err := strconv.Atoi(os.Getenv("CUSTOMER_REPEAT_WINDOW_DAYS")); err == nil && n > 0 {
+		o.WindowDays = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("CUSTOMER_PROMPT_PRIOR_LIMIT")); err == nil && n >= 0 {
+		o.PromptLimit = n
+	}
+	return o
+}
+
+// Prior is an earlier processed call of the same customer, as given to the extraction.
+// PrimaryIssue is redacted; Outcome is empty when none was reported.
+type Prior struct {
+	JobID        string    `json:"job_id"`
+	At           time.Time `json:"at"`
+	DaysBefore   float64   `json:"days_before"`
+	Issue        string    `json:"issue"`
+	PrimaryIssue string    `json:"primary_issue"`
+	Escalated    bool      `json:"escalated"`
+	Outcome      string    `json:"outcome,omitempty"`
+}
+
+// PriorContacts returns the customer's processed calls placed before j within the repeat
+// window, most recent first.
+func PriorContacts(j *jobs.Job, opts Options) ([]Prior, error) {
+	if j.CustomerID == "" {
+		return nil, nil
+	}
+	c, err := Get(j.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	at := j.At()
+	since := at.AddDate(0, 0, -opts.WindowDays)
+	var out []Prior
+	for i := len(c.Calls) - 1; i >= 0; i-- {
+		call := c.Calls[i]
+		if call.JobID == j.ID || !call.At.Before(at) || call.At.Before(since) {
+			continue
+		}
+		prev, err := jobs.Get(call.JobID)
+		if errors.Is(err, jobs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return out, err
+		}
+		if prev.Status != jobs.StatusCompleted && prev.Status != jobs.StatusPartial {
+			continue
+		}
+		p := Prior{
+			JobID:      prev.ID,
+			At:         call.At,
+			DaysBefore: math.Round(at.Sub(call.At).Hours()/24*10) / 10,
+			Issue:      issueOf(prev),
+			Escalated:  prev.Result.KPI.Actions.RequiresEscalation,
+		}
+		if p.PrimaryIssue, _, err = redact.Text(prev.Result.KPI.CustomerProblem.PrimaryIssue); err != nil {
+			return out, fmt.Errorf("redact prior issue: %w", err)
+		}
+		if o, err := outcome.Get(prev.ID); err == nil {
+			p.Outcome = o.Status
+		} else if apperr.KindOf(err) != apperr.NotFound {
+			return out, err
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// PromptContext is what the extraction sees of the prior contacts, capped at PromptLimit.
+// It is nil when there are none, so the prompt is unchanged for first-time callers.
+func PromptContext(prior []Prior, opts Options) any {
+	if len(prior) == 0 || opts.PromptLimit == 0 {
+		return nil
+	}
+	if len(prior) > opts.PromptLimit {
+		prior = prior[:opts.PromptLimit]
+	}
+	return map[string]any{
+		"window_days": opts.WindowDays,
+		"calls":       prior,
+	}
+}
+
+// Detect summarises the prior contacts of a call whose primary issue is issue. It returns nil
+// for a first contact within the window.
+func Detect(j *jobs.Job, prior []Prior, issue string, opts Options) *types.RepeatContact {
+	if len(prior) == 0 {
+		return nil
+	}
+	rc := &types.RepeatContact{
+		CustomerID:    j.CustomerID,
+		WindowDays:    opts.WindowDays,
+		PriorCalls:    len(prior),
+		DaysSinceLast: prior[0].DaysBefore,
+		PriorIssues:   []string{},
+	}
+	seen := map[string]bool{}
+	for _, p := range prior {
+		if !seen[p.Issue] {
+			seen[p.Issue] = true
+			rc.PriorIssues = append(rc.PriorIssues, p.Issue)
+		}
+		if p.Issue == issue && issue != taxonomy.OtherID {
+			rc.SameIssue = true
+		}
+	}
+	return rc
+}
+
+func issueOf(j *jobs.Job) string {
+	if is := j.Result.Issues; is != nil && is.PrimaryIssue.NodeID != "" {
+		return is.PrimaryIssue.NodeID
+	}
+	return taxonomy.OtherID
+}
+
+// Entry is one call on a customer's timeline.
+type Entry struct {
+	JobID                string           `json:"job_id"`
+	At                   time.Time        `json:"at"`
+	Status               jobs.Status      `json:"status"`
+	Issue                string           `json:"issue,omitempty"`
+	IssuePath            []string         `json:"issue_path,omitempty"`
+	PrimaryIssue         string           `json:"primary_issue,omitempty"`
+	Priority             string           `json:"priority,omitempty"`
+	RequiresEscalation   bool             `json:"requires_escalation"`
+	ResolutionLikelihood float64          `json:"resolution_likelihood"`
+	RiskOfChurn          float64          `json:"risk_of_churn"`
+	AgentID              string           `json:"agent_id,omitempty"`
+	Team                 string           `json:"team,omitempty"`
+	Repeat               bool             `json:"repeat"`
+	Outcome              *outcome.Outcome `json:"outcome,omitempty"`
+}
+
+// Timeline is every call of a customer, oldest first. RepeatContacts counts calls placed
+// within WindowDays of the previous one, and IssueCounts the calls per taxonomy node.
+type Timeline struct {
+	CustomerID     string         `json:"customer_id"`
+	Calls          int            `json:"calls"`
+	RepeatContacts int            `json:"repeat_contacts"`
+	WindowDays     int            `json:"window_days"`
+	FirstSeen      time.Time      `json:"first_seen"`
+	LastSeen       time.Time      `json:"last_seen"`
+	IssueCounts    map[string]int `json:"issue_counts"`
+	Entries        []Entry        `json:"entries"`
+}
+
+// BuildTimeline loads every call of customer id with its extraction and outcome.
+func BuildTimeline(id string, opts Options) (Timeline, error) {
+	c, err := Get(id)
+	if err != nil {
+		return Timeline{}, err
+	}
+	tl := Timeline{
+		CustomerID:  c.ID,
+		WindowDays:  opts.WindowDays,
+		FirstSeen:   c.FirstSeen,
+		LastSeen:    c.LastSeen,
+		IssueCounts: map[string]int{},
+		Entries:     []Entry{},
+	}
+	var last time.Time
+	for _, call := range c.Calls {
+		j, err := jobs.Get(call.JobID)
+		if errors.Is(err, jobs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return tl, err
+		}
+		e := Entry{JobID: j.ID, At: call.At, Status: j.Status, AgentID: j.AgentID, Team: j.Team}
+		e.Repeat = !last.IsZero() && call.At.Sub(last) <= time.Duration(opts.WindowDays)*24*time.Hour
+		last = call.At
+		if e.Repeat {
+			tl.RepeatContacts++
+		}
+		if j.Status == jobs.StatusCompleted || j.Status == jobs.StatusPartial {
+			x := j.Result.KPI
+			e.Issue = issueOf(j)
+			if is := j.Result.Issues; is != nil {
+				e.IssuePath = is.PrimaryIssue.Path
+			}
+			e.PrimaryIssue = x.CustomerProblem.PrimaryIssue
+			e.Priority = x.Actions.Priority
+			e.RequiresEscalation = x.Actions.RequiresEscalation
+			e.ResolutionLikelihood = x.KPI.ResolutionLikelihood
+			e.RiskOfChurn = j.Result.ChurnRisk()
+			tl.IssueCounts[e.Issue]++
+			if o, err := outcome.Get(j.ID); err == nil {
+				e.Outcome = &o
+			} else if apperr.KindOf(err) != apperr.NotFound {
+				return tl, err
+			}
+		}
+		tl.Entries = append(tl.Entries, e)
+	}
+	tl.Calls = len(tl.Entries)
+	return tl, nil
+}
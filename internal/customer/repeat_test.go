@@ -0,0 +1,140 @@
+package customer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/types"
+)
+
+var day0 = time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+// call stores a processed call from phone placed days after day0 about issue.
+func call(t *testing.T, phone string, days int, issue string, frustration, churn float64) *jobs.Job {
+	t.Helper()
+	j := jobs.New("https://calls/x.wav", 3, "single")
+	j.Status = jobs.StatusCompleted
+	j.CallTime = day0.AddDate(0, 0, days)
+	j.Result.Issues = &types.IssueTaxonomy{PrimaryIssue: types.CanonicalIssue{NodeID: issue, Path: []string{issue}}}
+	j.Result.KPI.CustomerProblem.PrimaryIssue = "Customer 98765 43210 wants " + issue
+	j.Result.KPI.KPI.FrustrationScore = frustration
+	j.Result.KPI.BusinessImpact.RiskOfChurn = churn
+	if err := Attach(j, phone, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := jobs.Save(j); err != nil {
+		t.Fatal(err)
+	}
+	return j
+}
+
+func TestAttachLinksIdentifiers(t *testing.T) {
+	testutil.DataDir(t)
+	t.Setenv("CUSTOMER_HASH_KEY", "")
+	if err := Attach(jobs.New("https://calls/x.wav", 3, "single"), "9876543210", ""); apperr.KindOf(err) != apperr.Internal {
+		t.Fatalf("attach without a hash key: err = %v, want internal", err)
+	}
+	t.Setenv("CUSTOMER_HASH_KEY", "test-key")
+
+	both := jobs.New("https://calls/a.wav", 3, "single")
+	if err := Attach(both, "98765 43210", "SELLER-7"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		phone, acct string
+		want        string
+	}{
+		{"account alone", "", "SELLER-7", both.CustomerID},
+		{"phone formatted differently", "+91-98765-43210", "", both.CustomerID},
+		{"new caller", "9000000001", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := jobs.New("https://calls/b.wav", 3, "single")
+			if err := Attach(j, tt.phone, tt.acct); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != "" && j.CustomerID != tt.want || tt.want == "" && j.CustomerID == both.CustomerID {
+				t.Errorf("customer = %q, linked to %q: want linked %v", j.CustomerID, both.CustomerID, tt.want != "")
+			}
+		})
+	}
+	if c, err := Get(both.CustomerID); err != nil || len(c.Calls) != 3 {
+		t.Errorf("customer has %d calls, err %v; want 3", len(c.Calls), err)
+	}
+	for _, bad := range [][2]string{{"", ""}, {"call me", ""}} {
+		if err := Attach(jobs.New("https://calls/c.wav", 3, "single"), bad[0], bad[1]); apperr.KindOf(err) != apperr.InvalidInput {
+			t.Errorf("Attach(%q, %q): err = %v, want invalid input", bad[0], bad[1], err)
+		}
+	}
+}
+
+func TestPriorContactsAndDetect(t *testing.T) {
+	testutil.DataDir(t)
+	t.Setenv("CUSTOMER_HASH_KEY", "test-key")
+	const phone = "9876543210"
+	call(t, phone, 0, "billing/refund", 0.2, 0.2) // outside the window
+	call(t, phone, 40, "delivery/late", 0.4, 0.3)
+	running := call(t, phone, 45, "billing/refund", 0.5, 0.3)
+	running.Status = jobs.StatusRunning
+	if err := jobs.Save(running); err != nil {
+		t.Fatal(err)
+	}
+	call(t, phone, 50, "billing/refund", 0.6, 0.4)
+	cur := call(t, phone, 55, "billing/refund", 0.8, 0.6)
+	call(t, "9000000001", 54, "billing/refund", 0.1, 0.1) // another customer
+
+	opts := Options{WindowDays: 30, PromptLimit: 1}
+	prior, err := PriorContacts(cur, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prior) != 2 || prior[0].Issue != "billing/refund" || prior[0].DaysBefore != 5 || prior[1].Issue != "delivery/late" {
+		t.Fatalf("prior = %+v, want the calls of day 50 then 40", prior)
+	}
+	if prior[0].PrimaryIssue == "Customer 98765 43210 wants billing/refund" {
+		t.Errorf("prior issue was not redacted: %q", prior[0].PrimaryIssue)
+	}
+
+	tests := []struct {
+		name  string
+		prior []Prior
+		issue string
+		want  *types.RepeatContact
+	}{
+		{"first contact", nil, "billing/refund", nil},
+		{"same issue again", prior, "billing/refund", &types.RepeatContact{
+			CustomerID: cur.CustomerID, WindowDays: 30, PriorCalls: 2, DaysSinceLast: 5,
+			PriorIssues: []string{"billing/refund", "delivery/late"}, SameIssue: true,
+		}},
+		{"new issue", prior, "account/kyc", &types.RepeatContact{
+			CustomerID: cur.CustomerID, WindowDays: 30, PriorCalls: 2, DaysSinceLast: 5,
+			PriorIssues: []string{"billing/refund", "delivery/late"},
+		}},
+		{"other never matches", []Prior{{Issue: taxonomy.OtherID, DaysBefore: 1}}, taxonomy.OtherID, &types.RepeatContact{
+			CustomerID: cur.CustomerID, WindowDays: 30, PriorCalls: 1, DaysSinceLast: 1,
+			PriorIssues: []string{taxonomy.OtherID},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(cur, tt.prior, tt.issue, opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Detect = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	pc, ok := PromptContext(prior, opts).(map[string]any)
+	if !ok || len(pc["calls"].([]Prior)) != 1 {
+		t.Errorf("prompt context = %v, want the latest prior call only", pc)
+	}
+	if PromptContext(nil, opts) != nil || PromptContext(prior, Options{WindowDays: 30}) != nil {
+		t.Error("prompt context should be nil without prior calls or with a zero limit")
+	}
+}
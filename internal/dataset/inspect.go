@@ -0,0 +1,85 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/xuri/excelize/v2"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/redact"
+)
+
+// Inspection is a dry run over the first rows of a dataset: what was detected, what the
+// heuristics would map, and whether the given mapping fits the header.
+type Inspection struct {
+	Format    string        `json:"format"`
+	Sheets    []string      `json:"sheets,omitempty"`
+	Header    []string      `json:"header"`
+	Sample    [][]string    `json:"sample_rows"`
+	Suggested ColumnMapping `json:"suggested_mapping"`
+	// Mapping is the explicit mapping that would be used, and MappingError why it would fail
+	Mapping      ColumnMapping `json:"mapping"`
+	MappingError string        `json:"mapping_error,omitempty"`
+}
+
+// Inspect reads the header and up to sampleRows rows. Sample cells are PII-redacted like
+// every other transcript that leaves the service.
+func Inspect(path string, opts Options, sampleRows int) (Inspection, error) {
+	log := logger.New().WithField("component", "dataset.inspect").WithField("path", path)
+	out := Inspection{Format: opts.Format, Sample: [][]string{}}
+	if out.Format == "" {
+		out.Format = DetectFormat(path)
+	}
+	if out.Format == FormatExcel {
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return out, apperr.Wrap(apperr.InvalidInput, "", "open dataset", err)
+		}
+		out.Sheets = f.GetSheetList()
+		f.Close()
+	}
+
+	rd, err := Open(path, opts)
+	if err != nil {
+		return out, apperr.Wrap(apperr.InvalidInput, "", "open dataset", err)
+	}
+	defer rd.Close()
+	out.Header = rd.Header()
+	out.Suggested = Suggest(out.Header)
+
+	for len(out.Sample) < sampleRows {
+		row, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if errors.As(err, new(*RowError)) {
+			continue
+		}
+		if err != nil {
+			return out, apperr.Wrap(apperr.InvalidInput, "", fmt.Sprintf("read row %s", rd.Line()), err)
+		}
+		red := make([]string, len(row))
+		for i, v := range row {
+			if red[i], _, err = redact.Text(v); err != nil {
+				log.WithError(err).Error("redaction failed; omitting sample rows")
+				out.Sample = [][]string{}
+				sampleRows = 0
+				break
+			}
+		}
+		if sampleRows > 0 {
+			out.Sample = append(out.Sample, red)
+		}
+	}
+
+	if out.Mapping, err = mappingFor(opts); err != nil {
+		out.MappingError = err.Error()
+	} else if out.Mapping.IsZero() {
+		out.MappingError = "no column mapping given; review suggested_mapping and pass it as mapping"
+	} else if _, err := out.Mapping.resolve(out.Header); err != nil {
+		out.MappingError = err.Error()
+	}
+	return out, nil
+}
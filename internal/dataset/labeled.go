@@ -0,0 +1,74 @@
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/logger"
+)
+
+// LoadLabeled reads training examples for the classifier from a dataset with a transcript
+// column, a category/label column holding taxonomy node ids and an optional confused column
+// (yes/true/1). Rows without a transcript are skipped.
+func LoadLabeled(path string, opts Options) ([]classifier.Example, error) {
+	log := logger.New().WithField("component", "dataset.labeled").WithField("path", path)
+	rd, err := Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer rd.Close()
+
+	transcriptIdx, categoryIdx, confusedIdx := -1, -1, -1
+	for i, h := range rd.Header() {
+		n := strings.ToLower(strings.TrimSpace(h))
+		switch {
+		case transcriptIdx == -1 && (strings.Contains(n, "transcript") || strings.Contains(n, "text")):
+			transcriptIdx = i
+		case categoryIdx == -1 && (strings.Contains(n, "category") || strings.Contains(n, "label")):
+			categoryIdx = i
+		case confusedIdx == -1 && strings.Contains(n, "confus"):
+			confusedIdx = i
+		}
+	}
+	if transcriptIdx == -1 || categoryIdx == -1 {
+		return nil, fmt.Errorf("labeled dataset needs transcript and category columns")
+	}
+
+	var out []classifier.Example
+	for {
+		r, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		var bad *RowError
+		if errors.As(err, &bad) {
+			log.WithError(bad.Err).WithField("line", bad.Line).Warn("skipping unreadable row")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read: %w", err)
+		}
+		ex := classifier.Example{}
+		if transcriptIdx < len(r) {
+			ex.Text = strings.TrimSpace(r[transcriptIdx])
+		}
+		if categoryIdx < len(r) {
+			ex.Category = strings.TrimSpace(r[categoryIdx])
+		}
+		if confusedIdx >= 0 && confusedIdx < len(r) {
+			switch strings.ToLower(strings.TrimSpace(r[confusedIdx])) {
+			case "1", "true", "yes", "y":
+				ex.Confused = true
+			}
+		}
+		if ex.Text == "" {
+			continue
+		}
+		out = append(out, ex)
+	}
+	log.WithField("examples", len(out)).Info("labeled examples loaded")
+	return out, nil
+}
@@ -1,119 +1,147 @@
 package dataset
 
 import (
-	"fmt"
+	"errors"
+	"io"
 	"strconv"
 	"strings"
 
-	"github.com/xuri/excelize/v2"
+	"voice-insights-go/internal/apperr"
 	"voice-insights-go/internal/logger"
 	"voice-insights-go/internal/types"
 )
 
-// Load attempts to auto-detect audio URL column by header heuristics
-func Load(path string) ([]types.CallRecord, error) {
+// Load reads call records using the explicit column mapping in opts; the audio URL column
+// must be mapped. Every skipped row (unreadable row, bad audio URL, duplicate call id) and
+// every coerced field (unparseable vintage, repeat count or timestamp) is listed in the
+// report; only a failure to read the file itself aborts the import.
+func Load(path string, opts Options) ([]types.CallRecord, ImportReport, error) {
 	log := logger.New().WithField("component", "dataset.Load").WithField("path", path)
 	log.Info("opening dataset file")
-	f, err := excelize.OpenFile(path)
+	rep := newImportReport()
+	mapping, err := mappingFor(opts)
 	if err != nil {
-		log.WithError(err).Error("open file failed")
-		return nil, fmt.Errorf("open file: %w", err)
-	}
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		log.Error("no sheets found in file")
-		return nil, fmt.Errorf("no sheets")
+		return nil, rep, err
 	}
-	rows, err := f.GetRows(sheets[0])
+	rd, err := Open(path, opts)
 	if err != nil {
-		log.WithError(err).Error("read rows failed")
-		return nil, fmt.Errorf("read rows: %w", err)
-	}
-	if len(rows) <= 1 {
-		log.Error("no data rows")
-		return nil, fmt.Errorf("no data rows")
+		log.WithError(err).Error("open file failed")
+		return nil, rep, apperr.Wrap(apperr.InvalidInput, "", "open dataset", err)
 	}
-	header := rows[0]
+	defer rd.Close()
+	header := rd.Header()
 	log.WithField("columns", len(header)).Info("detected header columns")
 
-	// find audio column
-	audioIdx := -1
-	callIDIdx := -1
-	callTypeIdx := -1
-	cityIdx := -1
-	vintageIdx := -1
-	repeatIdx := -1
-	for i, h := range header {
-		l := strings.ToLower(strings.TrimSpace(h))
-		switch {
-		case strings.Contains(l, "audio") || strings.Contains(l, "record") || (strings.Contains(l, "call") && strings.Contains(l, "link")) || strings.Contains(l, "url"):
-			if audioIdx == -1 {
-				audioIdx = i
-			}
-		case strings.Contains(l, "call id") || strings.Contains(l, "callid") || strings.Contains(l, "id"):
-			if callIDIdx == -1 {
-				callIDIdx = i
-			}
-		case strings.Contains(l, "type"):
-			if callTypeIdx == -1 {
-				callTypeIdx = i
-			}
-		case strings.Contains(l, "city"):
-			cityIdx = i
-		case strings.Contains(l, "vintage") || strings.Contains(l, "month"):
-			vintageIdx = i
-		case strings.Contains(l, "repeat") || strings.Contains(l, "escalation"):
-			repeatIdx = i
-		}
+	cols, err := mapping.resolve(header, "audio_url")
+	if err != nil {
+		log.WithError(err).Error("column mapping does not match dataset")
+		return nil, rep, apperr.Wrap(apperr.InvalidInput, "", "column mapping does not match dataset (see POST /datasets/inspect)", err)
 	}
-	log.WithFields(map[string]interface{}{
-		"audioIdx":   audioIdx,
-		"callIDIdx":  callIDIdx,
-		"callTypeIdx": callTypeIdx,
-		"cityIdx":    cityIdx,
-		"vintageIdx": vintageIdx,
-		"repeatIdx":  repeatIdx,
-	}).Info("column mapping heuristics result")
+	log.WithField("mapping", mapping).Info("column mapping applied")
 
-	// fallback heuristics
-	if audioIdx == -1 {
-		if len(header) > 4 {
-			audioIdx = 4
-			log.WithField("audioIdx", audioIdx).Warn("audio index not detected by header heuristics; using fallback index 4")
-		} else {
-			audioIdx = -1
-		}
-	}
 	var out []types.CallRecord
-	for i, r := range rows {
-		if i == 0 {
-			continue
+	seen := map[string]string{} // call id -> line first seen
+	for {
+		r, err := rd.Next()
+		if err == io.EOF {
+			break
 		}
-		record := types.CallRecord{}
-		if callIDIdx >= 0 && callIDIdx < len(r) {
-			record.CallID = r[callIDIdx]
+		var bad *RowError
+		if errors.As(err, &bad) {
+			row := &rowReport{line: bad.Line}
+			row.skip("", "", "unreadable row", bad.Err.Error())
+			rep.add(row)
+			continue
 		}
-		if callTypeIdx >= 0 && callTypeIdx < len(r) {
-			record.CallType = r[callTypeIdx]
+		if err != nil {
+			log.WithError(err).Error("read rows failed")
+			return nil, rep, apperr.Wrap(apperr.InvalidInput, "", "read rows", err)
 		}
-		if audioIdx >= 0 && audioIdx < len(r) {
-			record.AudioURL = r[audioIdx]
+		row := &rowReport{line: rd.Line()}
+		record := types.CallRecord{
+			CallID:   cell(r, cols.CallID),
+			CallType: cell(r, cols.CallType),
+			AudioURL: cell(r, cols.AudioURL),
+			City:     cell(r, cols.City),
+			AgentID:  cell(r, cols.Agent),
+			Team:     cell(r, cols.Team),
 		}
-		if cityIdx >= 0 && cityIdx < len(r) {
-			record.City = r[cityIdx]
+		record.VintageMonth = optionalInt(row, mapping.Vintage, cell(r, cols.Vintage))
+		record.RepeatEsc = atoi(row, mapping.Repeat, cell(r, cols.Repeat))
+		if ts := cell(r, cols.Timestamp); ts != "" {
+			if record.CallTime, err = ParseTimestamp(ts); err != nil {
+				row.coerce(mapping.Timestamp, ts, "unrecognised timestamp; call time left empty")
+			}
 		}
-		if vintageIdx >= 0 && vintageIdx < len(r) {
-			record.VintageMonth, _ = strconv.Atoi(strings.TrimSpace(r[vintageIdx]))
+
+		lower := strings.ToLower(record.AudioURL)
+		switch {
+		case record.AudioURL == "":
+			row.skip(mapping.AudioURL, "", "missing audio url", "")
+		case !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://"):
+			row.skip(mapping.AudioURL, record.AudioURL, "audio url is not http(s)", "")
 		}
-		if repeatIdx >= 0 && repeatIdx < len(r) {
-			record.RepeatEsc, _ = strconv.Atoi(strings.TrimSpace(r[repeatIdx]))
+		if record.CallID != "" && row.skipped == "" {
+			if first, dup := seen[record.CallID]; dup {
+				row.skip(mapping.CallID, record.CallID, "duplicate call id", "first seen on line "+first)
+				rep.Duplicates++
+			} else {
+				seen[record.CallID] = row.line
+			}
 		}
-		// if audio URL doesn't look like URL, skip
-		if !(strings.HasPrefix(strings.ToLower(record.AudioURL), "http://") || strings.HasPrefix(strings.ToLower(record.AudioURL), "https://")) {
-			continue
+		rep.add(row)
+		if row.skipped == "" {
+			out = append(out, record)
 		}
-		out = append(out, record)
 	}
-	log.WithField("loaded_count", len(out)).Info("dataset rows parsed and returned")
-	return out, nil
+	if rep.TotalRows == 0 {
+		log.Error("no data rows")
+		return nil, rep, apperr.New(apperr.InvalidInput, "", "no data rows")
+	}
+	log.WithFields(map[string]interface{}{
+		"loaded_count": len(out),
+		"skipped":      rep.Skipped,
+		"coerced":      rep.Coerced,
+		"duplicates":   rep.Duplicates,
+	}).Info("dataset rows parsed and returned")
+	return out, rep, nil
+}
+
+// optionalInt parses a field that may be unknown: blank is nil, and anything that is not a
+// non-negative integer is reported and left nil.
+func optionalInt(row *rowReport, column, v string) *int {
+	if v == "" {
+		return nil
+	}
+	n, ok := parseInt(v)
+	if !ok || n < 0 {
+		row.coerce(column, v, "not a non-negative integer; left unknown")
+		return nil
+	}
+	return &n
+}
+
+// parseInt accepts integers and, since spreadsheets often store whole numbers as "3.0",
+// floats without a fraction.
+func parseInt(v string) (int, bool) {
+	if n, err := strconv.Atoi(v); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && f == float64(int(f)) {
+		return int(f), true
+	}
+	return 0, false
+}
+
+// atoi parses an optional integer field; anything else is coerced to 0 and reported.
+func atoi(row *rowReport, column, v string) int {
+	if v == "" {
+		return 0
+	}
+	n, ok := parseInt(v)
+	if !ok {
+		row.coerce(column, v, "not an integer; set to 0")
+		return 0
+	}
+	return n
 }
@@ -0,0 +1,218 @@
+package dataset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/scorecard"
+)
+
+// ColumnMapping names the dataset column (by header text, case-insensitive) that holds each
+// field. Unmapped fields are left empty; nothing is guessed at load time.
+type ColumnMapping struct {
+	CallID     string `json:"call_id,omitempty"`
+	AudioURL   string `json:"audio_url,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+	City       string `json:"city,omitempty"`
+	Vintage    string `json:"vintage,omitempty"`
+	Agent      string `json:"agent,omitempty"`
+	Team       string `json:"team,omitempty"`
+	CallType   string `json:"call_type,omitempty"`
+	Timestamp  string `json:"timestamp,omitempty"`
+	Repeat     string `json:"repeat,omitempty"`
+}
+
+// IsZero reports whether no column is mapped.
+func (m ColumnMapping) IsZero() bool { return m == ColumnMapping{} }
+
+// LoadMapping reads a mapping file (a JSON ColumnMapping).
+func LoadMapping(path string) (ColumnMapping, error) {
+	var m ColumnMapping
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return m, fmt.Errorf("read column mapping: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("decode column mapping: %w", err)
+	}
+	return m, nil
+}
+
+// mappingFor returns opts.Mapping, else the file at opts.MappingPath or DATASET_MAPPING_PATH.
+func mappingFor(opts Options) (ColumnMapping, error) {
+	if !opts.Mapping.IsZero() {
+		return opts.Mapping, nil
+	}
+	path := opts.MappingPath
+	if path == "" {
+		path = os.Getenv("DATASET_MAPPING_PATH")
+	}
+	if path == "" {
+		return ColumnMapping{}, nil
+	}
+	return LoadMapping(path)
+}
+
+// columns holds resolved header positions; -1 means unmapped.
+type columns struct {
+	CallID, AudioURL, Transcript, City, Vintage, Agent, Team, CallType, Timestamp, Repeat int
+}
+
+// resolve maps every named column to its header position. Naming a column that is not in
+// the header is an error, as is leaving any of required unmapped.
+func (m ColumnMapping) resolve(header []string, required ...string) (columns, error) {
+	idx := map[string]int{}
+	for i, h := range header {
+		k := strings.ToLower(strings.TrimSpace(h))
+		if _, dup := idx[k]; !dup {
+			idx[k] = i
+		}
+	}
+	var errs []string
+	find := func(field, col string) int {
+		if strings.TrimSpace(col) == "" {
+			for _, r := range required {
+				if r == field {
+					errs = append(errs, fmt.Sprintf("%s is required", field))
+				}
+			}
+			return -1
+		}
+		i, ok := idx[strings.ToLower(strings.TrimSpace(col))]
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s: no column named %q", field, col))
+			return -1
+		}
+		return i
+	}
+	c := columns{
+		CallID:     find("call_id", m.CallID),
+		AudioURL:   find("audio_url", m.AudioURL),
+		Transcript: find("transcript", m.Transcript),
+		City:       find("city", m.City),
+		Vintage:    find("vintage", m.Vintage),
+		Agent:      find("agent", m.Agent),
+		Team:       find("team", m.Team),
+		CallType:   find("call_type", m.CallType),
+		Timestamp:  find("timestamp", m.Timestamp),
+		Repeat:     find("repeat", m.Repeat),
+	}
+	if len(errs) > 0 {
+		return c, errors.New(strings.Join(errs, "; "))
+	}
+	return c, nil
+}
+
+// cell returns row[i] trimmed, or "" when the column is unmapped or the row is short.
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// Suggest proposes a mapping from header names. It is only ever shown to the user (see
+// Inspect); loaders never apply it implicitly.
+func Suggest(header []string) ColumnMapping {
+	var m ColumnMapping
+	used := map[int]bool{}
+	// most specific fields first so "Agent ID" is not taken as the call id
+	pick := func(dst *string, match func(words []string, norm string) bool) {
+		for i, h := range header {
+			if used[i] {
+				continue
+			}
+			norm := strings.ToLower(strings.TrimSpace(h))
+			words := strings.FieldsFunc(norm, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+			})
+			if match(words, norm) {
+				*dst = h
+				used[i] = true
+				return
+			}
+		}
+	}
+	has := func(words []string, want ...string) bool {
+		for _, w := range words {
+			for _, a := range want {
+				if w == a {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	pick(&m.Agent, func(w []string, _ string) bool { return has(w, "agent", "executive", "employee", "rep") })
+	pick(&m.Team, func(w []string, _ string) bool { return has(w, "team", "squad", "pod") })
+	pick(&m.AudioURL, func(w []string, _ string) bool {
+		return has(w, "audio", "recording", "url") || has(w, "call") && has(w, "link")
+	})
+	pick(&m.Transcript, func(w []string, _ string) bool { return has(w, "transcript", "transcription", "text") })
+	pick(&m.Timestamp, func(w []string, _ string) bool {
+		return has(w, "timestamp", "datetime", "date", "time") || has(w, "created", "started") && has(w, "at")
+	})
+	pick(&m.CallID, func(w []string, n string) bool {
+		return n == "id" || has(w, "callid") || has(w, "call") && has(w, "id")
+	})
+	pick(&m.CallType, func(w []string, _ string) bool { return has(w, "type") })
+	pick(&m.City, func(w []string, _ string) bool { return has(w, "city", "location") })
+	pick(&m.Vintage, func(w []string, _ string) bool { return has(w, "vintage", "tenure") })
+	pick(&m.Repeat, func(w []string, _ string) bool { return has(w, "repeat", "escalation", "escalations") })
+	return m
+}
+
+// timeLayouts are the timestamp formats seen in call-system exports.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02/01/2006",
+	"02-01-2006 15:04:05",
+	"02-01-2006",
+	"1/2/06 15:04",
+	"2 Jan 2006 15:04",
+	"2 Jan 2006",
+}
+
+// ParseTimestamp parses s in one of the layouts seen in call-system exports, as IST when no
+// zone is given.
+func ParseTimestamp(s string) (time.Time, error) {
+	for _, l := range timeLayouts {
+		if t, err := time.ParseInLocation(l, s, scorecard.IST); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognised timestamp %q", s)
+}
+
+// datasetRoot is where path-based API requests may read datasets from.
+func datasetRoot() string {
+	if d := os.Getenv("DATASET_DIR"); d != "" {
+		return d
+	}
+	return "datasets"
+}
+
+// ResolvePath turns a dataset name from an API request into a file path under DATASET_DIR,
+// rejecting anything that would escape it.
+func ResolvePath(name string) (string, error) {
+	clean := filepath.Clean("/" + name)
+	if name == "" || clean == "/" {
+		return "", apperr.New(apperr.InvalidInput, "", "dataset path is required")
+	}
+	if filepath.IsAbs(name) || strings.Contains(name, "..") {
+		return "", apperr.New(apperr.InvalidInput, "", "dataset path must be relative to DATASET_DIR")
+	}
+	return filepath.Join(datasetRoot(), clean), nil
+}
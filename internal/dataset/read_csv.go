@@ -0,0 +1,162 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// sniffBytes is how much of the file is inspected for encoding and delimiter detection.
+const sniffBytes = 64 << 10
+
+// delimiters are the candidates tried when sniffing, in tie-break order.
+var delimiters = []rune{',', ';', '\t', '|'}
+
+type csvReader struct {
+	f      *os.File
+	r      *csv.Reader
+	header []string
+	line   int
+}
+
+func openCSV(path, delimiter string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	raw := bufio.NewReaderSize(f, sniffBytes)
+	head, _ := raw.Peek(sniffBytes)
+
+	var in io.Reader = raw
+	if enc := detectEncoding(head); enc != nil {
+		in = transform.NewReader(raw, enc.NewDecoder())
+	}
+	text := bufio.NewReaderSize(in, sniffBytes)
+
+	comma := ','
+	if delimiter != "" {
+		comma, _ = utf8.DecodeRuneInString(delimiter)
+		if delimiter == `\t` {
+			comma = '\t'
+		}
+	} else {
+		sample, _ := text.Peek(sniffBytes)
+		comma = sniffDelimiter(sample)
+	}
+
+	r := csv.NewReader(text)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		f.Close()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no header row")
+		}
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+	if len(header) > 0 {
+		header[0] = trimBOM(header[0])
+	}
+	return &csvReader{f: f, r: r, header: header, line: 1}, nil
+}
+
+// detectEncoding returns the decoder for a UTF-16 BOM, or Windows-1252 when the sample is
+// not valid UTF-8 (typical of Excel "Save as CSV" on Windows). nil means UTF-8.
+func detectEncoding(sample []byte) encoding.Encoding {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return unicode.UTF16(unicode.LittleEndian, unicode.ExpectBOM)
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return unicode.UTF16(unicode.BigEndian, unicode.ExpectBOM)
+	}
+	// a truncated multi-byte rune at the end of the sample is not an encoding error
+	for i := 0; i < utf8.UTFMax && len(sample) > 0 && !utf8.Valid(sample); i++ {
+		sample = sample[:len(sample)-1]
+	}
+	if utf8.Valid(sample) {
+		return nil
+	}
+	return charmap.Windows1252
+}
+
+// sniffDelimiter picks the candidate that appears the same non-zero number of times (outside
+// quotes) on the most of the first lines, preferring the higher count.
+func sniffDelimiter(sample []byte) rune {
+	lines := bytes.Split(sample, []byte("\n"))
+	if len(lines) > 1 {
+		lines = lines[:len(lines)-1] // last line may be cut off
+	}
+	if len(lines) > 20 {
+		lines = lines[:20]
+	}
+	best, bestScore := ',', 0
+	for _, d := range delimiters {
+		freq := map[int]int{}
+		for _, l := range lines {
+			if n := countUnquoted(l, d); n > 0 {
+				freq[n]++
+			}
+		}
+		for n, lines := range freq {
+			if score := lines*1000 + n; score > bestScore {
+				best, bestScore = d, score
+			}
+		}
+	}
+	return best
+}
+
+func countUnquoted(line []byte, d rune) int {
+	n, quoted := 0, false
+	for _, r := range string(line) {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == d && !quoted:
+			n++
+		}
+	}
+	return n
+}
+
+func trimBOM(s string) string {
+	return strings.TrimPrefix(s, "\ufeff")
+}
+
+func (r *csvReader) Header() []string { return r.header }
+
+func (r *csvReader) Next() ([]string, error) {
+	row, err := r.r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		// the csv reader resumes at the next record after a parse error
+		var pe *csv.ParseError
+		if errors.As(err, &pe) {
+			r.line = pe.StartLine
+			return nil, &RowError{Line: r.Line(), Err: pe.Err}
+		}
+		return nil, fmt.Errorf("read row after line %d: %w", r.line, err)
+	}
+	// quoted fields may span lines, so report where the record starts
+	r.line, _ = r.r.FieldPos(0)
+	return row, nil
+}
+
+func (r *csvReader) Line() string { return fmt.Sprint(r.line) }
+
+func (r *csvReader) Close() error { return r.f.Close() }
@@ -0,0 +1,145 @@
+package dataset
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// excelReader streams rows with excelize's row iterator, one sheet after another.
+type excelReader struct {
+	f      *excelize.File
+	sheets []string
+	cur    int
+	rows   *excelize.Rows
+	line   int
+	header []string
+	// remap maps the current sheet's column positions to header positions
+	remap []int
+}
+
+func openExcel(path, sheet string) (Reader, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	all := f.GetSheetList()
+	if len(all) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("no sheets")
+	}
+	var sheets []string
+	switch sheet {
+	case "":
+		sheets = all[:1]
+	case AllSheets:
+		sheets = all
+	default:
+		for _, s := range all {
+			if strings.EqualFold(s, sheet) {
+				sheets = []string{s}
+			}
+		}
+		if sheets == nil {
+			f.Close()
+			return nil, fmt.Errorf("sheet %q not found (have %s)", sheet, strings.Join(all, ", "))
+		}
+	}
+	r := &excelReader{f: f, sheets: sheets, cur: -1}
+	if err := r.nextSheet(); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// nextSheet opens the following sheet and consumes its header row.
+func (r *excelReader) nextSheet() error {
+	if r.rows != nil {
+		r.rows.Close()
+		r.rows = nil
+	}
+	r.cur++
+	if r.cur >= len(r.sheets) {
+		return io.EOF
+	}
+	rows, err := r.f.Rows(r.sheets[r.cur])
+	if err != nil {
+		return fmt.Errorf("read sheet %q: %w", r.sheets[r.cur], err)
+	}
+	r.rows, r.line = rows, 0
+	if !rows.Next() {
+		return r.nextSheet() // empty sheet
+	}
+	r.line++
+	header, err := rows.Columns()
+	if err != nil {
+		return fmt.Errorf("read header of sheet %q: %w", r.sheets[r.cur], err)
+	}
+	if r.header == nil {
+		r.header = header
+		r.remap = nil
+		return nil
+	}
+	r.remap = make([]int, len(header))
+	for i, h := range header {
+		r.remap[i] = -1
+		for j, want := range r.header {
+			if strings.EqualFold(strings.TrimSpace(h), strings.TrimSpace(want)) {
+				r.remap[i] = j
+				break
+			}
+		}
+	}
+	return nil
+}
+
+func (r *excelReader) Header() []string { return r.header }
+
+func (r *excelReader) Next() ([]string, error) {
+	for {
+		if r.rows == nil {
+			return nil, io.EOF
+		}
+		if !r.rows.Next() {
+			if err := r.rows.Error(); err != nil {
+				return nil, fmt.Errorf("read rows: %w", err)
+			}
+			if err := r.nextSheet(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		r.line++
+		cols, err := r.rows.Columns()
+		if err != nil {
+			return nil, &RowError{Line: r.Line(), Err: err}
+		}
+		if r.remap == nil {
+			return cols, nil
+		}
+		row := make([]string, len(r.header))
+		for i, v := range cols {
+			if i < len(r.remap) && r.remap[i] >= 0 {
+				row[r.remap[i]] = v
+			}
+		}
+		return row, nil
+	}
+}
+
+func (r *excelReader) Line() string {
+	if r.cur >= 0 && r.cur < len(r.sheets) && len(r.sheets) > 1 {
+		return fmt.Sprintf("%s:%d", r.sheets[r.cur], r.line)
+	}
+	return fmt.Sprint(r.line)
+}
+
+func (r *excelReader) Close() error {
+	if r.rows != nil {
+		r.rows.Close()
+	}
+	return r.f.Close()
+}
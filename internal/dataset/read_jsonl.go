@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxJSONLLine bounds one JSONL record; transcripts make long lines common.
+const maxJSONLLine = 16 << 20
+
+// jsonlReader reads one JSON object per line. The header is the key order of the first
+// object; keys that only appear in later objects are ignored. Blank lines are skipped and a
+// line that is not a JSON object is a *RowError, so one bad record does not end the read.
+type jsonlReader struct {
+	f       *os.File
+	sc      *bufio.Scanner
+	header  []string
+	pending []jsonlRow // read while looking for the header, returned first
+	line    int        // physical line of the last row returned
+	next    int        // physical line of the last line scanned
+}
+
+type jsonlRow struct {
+	line int
+	raw  []byte
+	err  error
+}
+
+func openJSONL(path string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), maxJSONLLine)
+	r := &jsonlReader{f: f, sc: sc}
+	for r.header == nil {
+		row, err := r.scan()
+		if err != nil {
+			f.Close()
+			if err == io.EOF {
+				if len(r.pending) > 0 {
+					return nil, fmt.Errorf("no line is a JSON object (line %d: %v)", r.pending[0].line, r.pending[0].err)
+				}
+				return nil, fmt.Errorf("no data rows")
+			}
+			return nil, err
+		}
+		if row.err == nil {
+			if keys, err := objectKeys(row.raw); err != nil {
+				row.err = err
+			} else {
+				r.header = keys
+			}
+		}
+		r.pending = append(r.pending, row)
+	}
+	return r, nil
+}
+
+// scan returns the next non-blank line. Only a read failure or an over-long line is an error;
+// a line that is not valid JSON comes back with err set.
+func (r *jsonlReader) scan() (jsonlRow, error) {
+	for r.sc.Scan() {
+		r.next++
+		raw := bytes.TrimSpace(r.sc.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		row := jsonlRow{line: r.next, raw: append([]byte(nil), raw...)}
+		if !json.Valid(row.raw) {
+			row.err = fmt.Errorf("not valid JSON")
+		}
+		return row, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return jsonlRow{}, fmt.Errorf("line %d is longer than %d MiB", r.next+1, maxJSONLLine>>20)
+		}
+		return jsonlRow{}, fmt.Errorf("read after line %d: %w", r.next, err)
+	}
+	return jsonlRow{}, io.EOF
+}
+
+// objectKeys returns the top-level keys of a JSON object in document order.
+func objectKeys(raw []byte) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, t.(string))
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return nil, err
+		}
+	}
+	return keys, nil
+}
+
+// align turns an object into a row in header order. Strings are unquoted, null is empty and
+// any other value keeps its JSON text.
+func (r *jsonlReader) align(raw []byte) ([]string, error) {
+	if len(raw) == 0 || raw[0] != '{' {
+		return nil, fmt.Errorf("expected a JSON object")
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("expected a JSON object: %w", err)
+	}
+	row := make([]string, len(r.header))
+	for i, k := range r.header {
+		v := bytes.TrimSpace(obj[k])
+		switch {
+		case len(v) == 0 || string(v) == "null":
+		case v[0] == '"':
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				return nil, fmt.Errorf("field %q: %w", k, err)
+			}
+			row[i] = s
+		default:
+			row[i] = string(v)
+		}
+	}
+	return row, nil
+}
+
+func (r *jsonlReader) Header() []string { return r.header }
+
+func (r *jsonlReader) Next() ([]string, error) {
+	var row jsonlRow
+	if len(r.pending) > 0 {
+		row, r.pending = r.pending[0], r.pending[1:]
+	} else {
+		var err error
+		if row, err = r.scan(); err != nil {
+			return nil, err
+		}
+	}
+	r.line = row.line
+	if row.err != nil {
+		return nil, &RowError{Line: r.Line(), Err: row.err}
+	}
+	out, err := r.align(row.raw)
+	if err != nil {
+		return nil, &RowError{Line: r.Line(), Err: err}
+	}
+	return out, nil
+}
+
+func (r *jsonlReader) Line() string { return fmt.Sprint(r.line) }
+
+func (r *jsonlReader) Close() error { return r.f.Close() }
@@ -0,0 +1,164 @@
+package dataset
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// readAll drains a reader into rows and row errors, keyed by the line Next reported.
+func readAll(t *testing.T, rd Reader) (rows map[string][]string, bad map[string]string) {
+	t.Helper()
+	rows, bad = map[string][]string{}, map[string]string{}
+	for {
+		r, err := rd.Next()
+		if err == io.EOF {
+			return rows, bad
+		}
+		var re *RowError
+		if errors.As(err, &re) {
+			bad[re.Line] = re.Err.Error()
+			continue
+		}
+		if err != nil {
+			t.Fatalf("fatal error after line %s: %v", rd.Line(), err)
+		}
+		rows[rd.Line()] = r
+	}
+}
+
+func TestJSONLReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		header []string
+		rows   map[string][]string
+		bad    []string // lines reported as row errors
+	}{
+		{
+			name:   "values keep their JSON text",
+			input:  `{"a":"x","b":2,"c":null,"d":true}` + "\n" + `{"b":3.5,"a":"y","e":"ignored"}` + "\n",
+			header: []string{"a", "b", "c", "d"},
+			rows:   map[string][]string{"1": {"x", "2", "", "true"}, "2": {"y", "3.5", "", ""}},
+		},
+		{
+			name:   "blank lines keep physical numbering",
+			input:  "\n" + `{"a":"x"}` + "\n\n   \n" + `{"a":"y"}`,
+			header: []string{"a"},
+			rows:   map[string][]string{"2": {"x"}, "5": {"y"}},
+		},
+		{
+			name:   "bad lines are row errors and reading continues",
+			input:  `{"a":"x"}` + "\n{broken\n[1,2]\n" + `{"a":"y"}` + "\n",
+			header: []string{"a"},
+			rows:   map[string][]string{"1": {"x"}, "4": {"y"}},
+			bad:    []string{"2", "3"},
+		},
+		{
+			name:   "header comes from the first object",
+			input:  "not json\n" + `{"a":"x"}` + "\n",
+			header: []string{"a"},
+			rows:   map[string][]string{"2": {"x"}},
+			bad:    []string{"1"},
+		},
+		{
+			name:   "windows line endings",
+			input:  `{"a":"x"}` + "\r\n" + `{"a":"y"}` + "\r\n",
+			header: []string{"a"},
+			rows:   map[string][]string{"1": {"x"}, "2": {"y"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rd, err := openJSONL(writeFile(t, "d.jsonl", tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rd.Close()
+			if !reflect.DeepEqual(rd.Header(), tt.header) {
+				t.Errorf("header = %v, want %v", rd.Header(), tt.header)
+			}
+			rows, bad := readAll(t, rd)
+			if !reflect.DeepEqual(rows, tt.rows) {
+				t.Errorf("rows = %v, want %v", rows, tt.rows)
+			}
+			if len(bad) != len(tt.bad) {
+				t.Errorf("row errors = %v, want lines %v", bad, tt.bad)
+			}
+			for _, line := range tt.bad {
+				if _, ok := bad[line]; !ok {
+					t.Errorf("line %s not reported; row errors = %v", line, bad)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONLReaderOpenErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "no data rows"},
+		{"blank", "\n\n", "no data rows"},
+		{"no objects", "nope\n[1]\n", "no line is a JSON object"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := openJSONL(writeFile(t, "d.jsonl", tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONLReaderLongLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20) // beyond bufio.Scanner's default 64 KiB token
+	rd, err := openJSONL(writeFile(t, "d.jsonl", `{"t":"`+long+`"}`+"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rd.Close()
+	row, err := rd.Next()
+	if err != nil || len(row) != 1 || row[0] != long {
+		t.Fatalf("long line not read whole: err=%v", err)
+	}
+}
+
+func TestLoadSkipsUnreadableRows(t *testing.T) {
+	input := `{"call_id":"a","audio_url":"https://x/1.wav"}` + "\n" +
+		"{oops\n" +
+		`{"call_id":"b","audio_url":"ftp://x/2.wav"}` + "\n" +
+		`{"call_id":"a","audio_url":"https://x/3.wav"}` + "\n" +
+		`{"call_id":"c","audio_url":"https://x/4.wav"}` + "\n"
+	opts := Options{Format: FormatJSONL, Mapping: ColumnMapping{CallID: "call_id", AudioURL: "audio_url"}}
+	recs, rep, err := Load(writeFile(t, "d.jsonl", input), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 || rep.TotalRows != 5 || rep.Imported != 2 || rep.Skipped != 3 || rep.Duplicates != 1 {
+		t.Errorf("records=%d report=%+v", len(recs), rep)
+	}
+	want := map[string]int{"unreadable row": 1, "audio url is not http(s)": 1, "duplicate call id": 1}
+	if !reflect.DeepEqual(rep.SkippedByReason, want) {
+		t.Errorf("skipped by reason = %v, want %v", rep.SkippedByReason, want)
+	}
+	if rep.Issues[0].Line != "2" || rep.Issues[0].Action != ActionSkipped {
+		t.Errorf("first issue = %+v, want line 2 skipped", rep.Issues[0])
+	}
+}
@@ -0,0 +1,96 @@
+package dataset
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// parquetBatch is how many rows are decoded per read.
+const parquetBatch = 256
+
+// parquetReader streams row groups through a small buffer. Nested columns are flattened to
+// dotted paths; repeated values are joined with "; ".
+type parquetReader struct {
+	f      *os.File
+	r      *parquet.Reader
+	header []string
+	buf    []parquet.Row
+	pos    int
+	n      int
+	eof    bool
+	line   int
+}
+
+func openParquet(path string) (Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+	pf, err := parquet.OpenFile(f, st.Size())
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("open parquet: %w", err)
+	}
+	var header []string
+	for _, col := range pf.Schema().Columns() {
+		header = append(header, strings.Join(col, "."))
+	}
+	return &parquetReader{
+		f:      f,
+		r:      parquet.NewReader(pf),
+		header: header,
+		buf:    make([]parquet.Row, parquetBatch),
+	}, nil
+}
+
+func (r *parquetReader) Header() []string { return r.header }
+
+func (r *parquetReader) Next() ([]string, error) {
+	if r.pos >= r.n {
+		if r.eof {
+			return nil, io.EOF
+		}
+		n, err := r.r.ReadRows(r.buf)
+		r.pos, r.n = 0, n
+		if err == io.EOF {
+			r.eof = true
+		} else if err != nil {
+			return nil, fmt.Errorf("read rows after %d: %w", r.line, err)
+		}
+		if n == 0 {
+			return nil, io.EOF
+		}
+	}
+	values := r.buf[r.pos]
+	r.pos++
+	r.line++
+
+	row := make([]string, len(r.header))
+	for _, v := range values {
+		c := v.Column()
+		if c < 0 || c >= len(row) || v.IsNull() {
+			continue
+		}
+		if row[c] != "" {
+			row[c] += "; "
+		}
+		row[c] += v.String()
+	}
+	return row, nil
+}
+
+func (r *parquetReader) Line() string { return fmt.Sprint(r.line) }
+
+func (r *parquetReader) Close() error {
+	r.r.Close()
+	return r.f.Close()
+}
@@ -0,0 +1,92 @@
+package dataset
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Supported dataset formats.
+const (
+	FormatExcel   = "xlsx"
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+)
+
+// AllSheets selects every sheet of a workbook; rows of later sheets are matched to the
+// first sheet's header by column name.
+const AllSheets = "*"
+
+// Options controls how a dataset file is opened and which columns are read. The zero
+// value auto-detects the format but maps no columns.
+type Options struct {
+	// Format overrides detection by file extension (xlsx|csv|jsonl|parquet)
+	Format string `json:"format,omitempty"`
+	// Sheet selects an Excel sheet by name, or AllSheets; empty means the first sheet
+	Sheet string `json:"sheet,omitempty"`
+	// Delimiter overrides CSV delimiter sniffing
+	Delimiter string `json:"delimiter,omitempty"`
+	// Mapping names the columns to read; when empty the file at MappingPath (or
+	// DATASET_MAPPING_PATH) is used
+	Mapping     ColumnMapping `json:"mapping"`
+	MappingPath string        `json:"mapping_path,omitempty"`
+}
+
+// Reader streams a tabular dataset one row at a time, so large exports are never fully
+// loaded into memory. Rows are aligned to Header; short rows are allowed.
+type Reader interface {
+	Header() []string
+	// Next returns the next data row, or io.EOF after the last one. A *RowError is a bad
+	// row the reader has already stepped past; Next may be called again. Any other error
+	// ends the read.
+	Next() ([]string, error)
+	// Line is the 1-based position of the last row returned, for error reporting
+	Line() string
+	Close() error
+}
+
+// RowError is one row that could not be read, such as a JSONL line that is not a JSON
+// object. Line is where the row starts, in the form Reader.Line uses.
+type RowError struct {
+	Line string
+	Err  error
+}
+
+func (e *RowError) Error() string { return fmt.Sprintf("line %s: %v", e.Line, e.Err) }
+
+func (e *RowError) Unwrap() error { return e.Err }
+
+// Open returns a Reader for path, choosing the format from opts or the file extension.
+func Open(path string, opts Options) (Reader, error) {
+	format := strings.ToLower(strings.TrimSpace(opts.Format))
+	if format == "" {
+		format = DetectFormat(path)
+	}
+	switch format {
+	case FormatExcel:
+		return openExcel(path, opts.Sheet)
+	case FormatCSV:
+		return openCSV(path, opts.Delimiter)
+	case FormatJSONL:
+		return openJSONL(path)
+	case FormatParquet:
+		return openParquet(path)
+	default:
+		return nil, fmt.Errorf("unsupported dataset format %q", format)
+	}
+}
+
+// DetectFormat maps a file extension to a format, defaulting to Excel.
+func DetectFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv", ".txt":
+		return FormatCSV
+	case ".jsonl", ".ndjson", ".json":
+		return FormatJSONL
+	case ".parquet", ".pq":
+		return FormatParquet
+	default:
+		return FormatExcel
+	}
+}
@@ -0,0 +1,433 @@
+package dataset
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/cohort"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/types"
+)
+
+const registryCollection = "datasets"
+
+// Summarization states of a registered dataset. Records from before registration ran in the
+// background have no status and are ready.
+const (
+	StatusSummarizing = "summarizing"
+	StatusReady       = "ready"
+	StatusFailed      = "failed"
+)
+
+// registerTimeout bounds the background summarization of a newly registered dataset.
+const registerTimeout = 30 * time.Minute
+
+// Registered is a dataset known to the service with its persisted summary. Path is relative
+// to DATASET_DIR.
+type Registered struct {
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Path        string         `json:"path"`
+	Options     Options        `json:"options"`
+	CreatedAt   time.Time      `json:"created_at"`
+	RefreshedAt time.Time      `json:"refreshed_at"`
+	Status      string         `json:"status,omitempty"`
+	Error       string         `json:"error,omitempty"`
+	Summary     DatasetSummary `json:"summary"`
+	State       *SummaryState  `json:"state,omitempty"`
+}
+
+// Info is the registered dataset without its internal aggregates.
+func (d Registered) Info() Registered {
+	d.State = nil
+	return d
+}
+
+// refreshAttempts bounds how often Refresh scans again after another refresh of the same
+// dataset was saved while it was scanning.
+const refreshAttempts = 3
+
+// registryMu guards summarizing and refreshing, the datasets this process is summarizing or
+// refreshing in the background, and locks. Scans run without any lock held; a dataset's own
+// lock is held only to load, check and save its record, so concurrent updates do not lose
+// rows or calls and a processed call never waits for a scan.
+var (
+	registryMu  sync.Mutex
+	summarizing = map[string]bool{}
+	refreshing  = map[string]bool{}
+	locks       = map[string]*sync.Mutex{}
+)
+
+// lockDataset takes the lock of dataset id and returns its unlock.
+func lockDataset(id string) (unlock func()) {
+	registryMu.Lock()
+	mu, ok := locks[id]
+	if !ok {
+		mu = &sync.Mutex{}
+		locks[id] = mu
+	}
+	registryMu.Unlock()
+	mu.Lock()
+	return mu.Unlock
+}
+
+func isSummarizing(id string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return summarizing[id]
+}
+
+// Register checks that the dataset at path (relative to DATASET_DIR) opens and has the mapped
+// transcript column, persists it as summarizing and summarizes it in the background. Poll
+// GetRegistered, or the summary, for the outcome.
+func Register(name, path string, opts Options, c classifier.Classifier) (*Registered, error) {
+	full, err := ResolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSummarizable(full, opts); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) == "" {
+		name = path
+	}
+	cfg, err := cohort.Current()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	d := &Registered{ID: uuid.New().String(), Name: name, Path: path, Options: opts, CreatedAt: now, Status: StatusSummarizing}
+	d.State = newSummaryState(c.Name(), cfg.Version)
+	d.Summary = d.State.Summary()
+
+	unlock := lockDataset(d.ID)
+	defer unlock()
+	if err := saveRegistered(d); err != nil {
+		return nil, err
+	}
+	registryMu.Lock()
+	summarizing[d.ID] = true
+	registryMu.Unlock()
+	go summarize(d.ID, full, opts, c, cfg)
+	return d, nil
+}
+
+// checkSummarizable opens the dataset and resolves the mapping, so a bad path or mapping is
+// reported to the caller rather than by a failed background run.
+func checkSummarizable(path string, opts Options) error {
+	mapping, err := mappingFor(opts)
+	if err != nil {
+		return err
+	}
+	rd, err := Open(path, opts)
+	if err != nil {
+		return apperr.Wrap(apperr.InvalidInput, "", "open dataset", err)
+	}
+	defer rd.Close()
+	if _, err := mapping.resolve(rd.Header(), "transcript"); err != nil {
+		return apperr.Wrap(apperr.InvalidInput, "", "column mapping does not match dataset (see POST /datasets/inspect)", err)
+	}
+	return nil
+}
+
+// summarize builds the first summary of a registered dataset and records it as ready, or the
+// error as failed. Calls the pipeline added meanwhile are kept.
+func summarize(id, path string, opts Options, c classifier.Classifier, cfg *cohort.Config) {
+	log := logger.New().WithField("component", "dataset.registry").WithField("dataset_id", id)
+	ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+	defer cancel()
+	st := newSummaryState(c.Name(), cfg.Version)
+	scanErr := st.scan(ctx, path, opts, c, cfg)
+
+	unlock := lockDataset(id)
+	defer unlock()
+	registryMu.Lock()
+	delete(summarizing, id)
+	registryMu.Unlock()
+	d, err := GetRegistered(id)
+	if err != nil {
+		log.WithError(err).Error("load registered dataset failed")
+		return
+	}
+	if scanErr != nil {
+		d.Status, d.Error = StatusFailed, scanErr.Error()
+		log.WithError(scanErr).Error("dataset summarization failed")
+	} else {
+		keepCalls(st, d.State)
+		d.State, d.Summary, d.RefreshedAt = st, st.Summary(), time.Now().UTC()
+		d.Status, d.Error = StatusReady, ""
+		log.WithField("rows", st.Rows).Info("dataset registered")
+	}
+	if err := saveRegistered(d); err != nil {
+		log.WithError(err).Error("save registered dataset failed")
+	}
+}
+
+// ready is a Conflict while d is being summarized by this process or after its summary
+// failed. A dataset left summarizing by a process that stopped passes, and is re-summarized
+// in full by Refresh.
+func (d *Registered) ready() error {
+	switch d.Status {
+	case "", StatusReady:
+		return nil
+	case StatusFailed:
+		return apperr.New(apperr.Conflict, "", fmt.Sprintf("dataset %q summarization failed: %s; refresh it with full=true to retry", d.ID, d.Error))
+	}
+	if isSummarizing(d.ID) {
+		return apperr.New(apperr.Conflict, "", fmt.Sprintf("dataset %q is still being summarized", d.ID))
+	}
+	return nil
+}
+
+// GetRegistered loads a registered dataset.
+func GetRegistered(id string) (*Registered, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var d Registered
+	if err := st.Get(registryCollection, id, &d); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, apperr.New(apperr.NotFound, "", fmt.Sprintf("dataset %q not found", id))
+		}
+		return nil, fmt.Errorf("load dataset: %w", err)
+	}
+	return &d, nil
+}
+
+// ListRegistered returns every registered dataset without its aggregates.
+func ListRegistered() ([]Registered, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	out := []Registered{}
+	err = st.List(registryCollection, func(_ string, raw json.RawMessage) error {
+		var d Registered
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return err
+		}
+		out = append(out, d.Info())
+		return nil
+	})
+	return out, err
+}
+
+// Refresh folds rows appended since the last refresh into the summary. The whole file is
+// re-summarized when full is set, when earlier rows changed, or when the classifier or the
+// cohort config did. Processed calls already folded in are kept either way.
+//
+// The file is scanned on a copy of the state without holding the dataset's lock. The result
+// is saved only if no other refresh saved the dataset meanwhile; otherwise the scan starts
+// over from the state that one saved.
+func Refresh(ctx context.Context, id string, c classifier.Classifier, full bool) (*Registered, error) {
+	for attempt := 1; ; attempt++ {
+		d, err := refresh(ctx, id, c, full)
+		if !errors.Is(err, errRefreshedMeanwhile) {
+			return d, err
+		}
+		if attempt == refreshAttempts {
+			return nil, apperr.New(apperr.Conflict, "", fmt.Sprintf("dataset %q kept being refreshed concurrently; retry", id))
+		}
+	}
+}
+
+// errRefreshedMeanwhile means another refresh saved the dataset while this one was scanning.
+var errRefreshedMeanwhile = errors.New("dataset refreshed meanwhile")
+
+// refresh is one attempt of Refresh.
+func refresh(ctx context.Context, id string, c classifier.Classifier, full bool) (*Registered, error) {
+	log := logger.New().WithField("component", "dataset.registry").WithField("dataset_id", id)
+	// every load decodes a fresh copy, so d.State can be scanned without the lock
+	d, err := GetRegistered(id)
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == StatusSummarizing && isSummarizing(id) {
+		return nil, d.ready()
+	}
+	path, err := ResolvePath(d.Path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := cohort.Current()
+	if err != nil {
+		return nil, err
+	}
+	mark := d.State.mark()
+
+	// a dataset that never finished its first summary starts over
+	full = full || d.Status == StatusSummarizing || d.Status == StatusFailed
+	st := d.State
+	if st == nil || full || st.Classifier != c.Name() || st.CohortVersion != cfg.Version {
+		st = rebuiltState(st, c, cfg)
+	}
+	err = st.scan(ctx, path, d.Options, c, cfg)
+	if errors.Is(err, errRewritten) {
+		log.Warn("dataset rows changed; re-summarizing from scratch")
+		st = rebuiltState(d.State, c, cfg)
+		err = st.scan(ctx, path, d.Options, c, cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unlock := lockDataset(id)
+	defer unlock()
+	cur, err := GetRegistered(id)
+	if err != nil {
+		return nil, err
+	}
+	if cur.State.mark() != mark {
+		log.Info("dataset refreshed meanwhile; scanning again")
+		return nil, errRefreshedMeanwhile
+	}
+	// calls the pipeline added during the scan
+	keepCalls(st, cur.State)
+	cur.State, cur.Summary, cur.RefreshedAt = st, st.Summary(), time.Now().UTC()
+	cur.Status, cur.Error = StatusReady, ""
+	if err := saveRegistered(cur); err != nil {
+		return nil, err
+	}
+	return cur, nil
+}
+
+// GetAndRefresh returns the stored dataset at once and, when its file changed after the last
+// refresh or the cohort config changed since, refreshes it in the background so a later read
+// sees the change. A dataset still being summarized, or whose summary failed, is a conflict;
+// one left summarizing by a stopped process is summarized in the background.
+func GetAndRefresh(id string, c classifier.Classifier) (*Registered, error) {
+	d, err := GetRegistered(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.ready(); err != nil {
+		return nil, err
+	}
+	stale, err := d.stale()
+	if err != nil {
+		return nil, err
+	}
+	if d.Status == StatusSummarizing || stale {
+		refreshInBackground(id, c, d.Status == StatusSummarizing)
+	}
+	return d, nil
+}
+
+// stale reports whether d's file changed after the last refresh or the cohort config changed
+// since. A vanished file is not stale; the last summary keeps being served.
+func (d *Registered) stale() (bool, error) {
+	path, err := ResolvePath(d.Path)
+	if err != nil {
+		return false, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	cfg, err := cohort.Current()
+	if err != nil {
+		return false, err
+	}
+	return fi.ModTime().After(d.RefreshedAt) || (d.State != nil && d.State.CohortVersion != cfg.Version), nil
+}
+
+// refreshInBackground starts a refresh of dataset id unless this process is already
+// refreshing it.
+func refreshInBackground(id string, c classifier.Classifier, full bool) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if refreshing[id] {
+		return
+	}
+	refreshing[id] = true
+	go func() {
+		log := logger.New().WithField("component", "dataset.registry").WithField("dataset_id", id)
+		ctx, cancel := context.WithTimeout(context.Background(), registerTimeout)
+		defer cancel()
+		d, err := Refresh(ctx, id, c, full)
+		registryMu.Lock()
+		delete(refreshing, id)
+		registryMu.Unlock()
+		if err != nil {
+			log.WithError(err).Error("background refresh failed")
+			return
+		}
+		log.WithField("total_calls", d.Summary.TotalCalls).Info("dataset refreshed")
+	}()
+}
+
+// rebuiltState starts over on the rows but keeps the processed calls of prev, which are not
+// in the file.
+func rebuiltState(prev *SummaryState, c classifier.Classifier, cfg *cohort.Config) *SummaryState {
+	st := newSummaryState(c.Name(), cfg.Version)
+	keepCalls(st, prev)
+	return st
+}
+
+// keepCalls copies the processed calls of prev into st.
+func keepCalls(st, prev *SummaryState) {
+	if prev == nil {
+		return
+	}
+	for id, pc := range prev.ProcessedJobs {
+		st.ProcessedJobs[id] = pc
+	}
+}
+
+// scanMark identifies the rows a saved state counted and how they were labelled.
+type scanMark struct {
+	rows          int
+	rowsHash      string
+	classifier    string
+	cohortVersion int
+}
+
+// mark is what a refresh compares before saving; a nil state has counted nothing.
+func (s *SummaryState) mark() scanMark {
+	if s == nil {
+		return scanMark{}
+	}
+	return scanMark{s.Rows, s.RowsHash, s.Classifier, s.CohortVersion}
+}
+
+// AddProcessedCall counts a call the pipeline processed for dataset id. Repeated job ids
+// (retries) are ignored.
+func AddProcessedCall(id, jobID string, cls types.Classification) error {
+	unlock := lockDataset(id)
+	defer unlock()
+	d, err := GetRegistered(id)
+	if err != nil {
+		return err
+	}
+	if d.State == nil {
+		// version 0 makes the next refresh label the rows with the current cohorts
+		d.State = newSummaryState(cls.Method, 0)
+	}
+	if !d.State.addCall(jobID, cls) {
+		return nil
+	}
+	d.Summary = d.State.Summary()
+	return saveRegistered(d)
+}
+
+func saveRegistered(d *Registered) error {
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	if err := st.Put(registryCollection, d.ID, d); err != nil {
+		return fmt.Errorf("save dataset: %w", err)
+	}
+	return nil
+}
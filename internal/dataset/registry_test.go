@@ -0,0 +1,118 @@
+package dataset
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/types"
+)
+
+// gateClassifier classifies like flakyClassifier but holds rows that mention "slow" until
+// release is closed, after closing entered.
+type gateClassifier struct {
+	flakyClassifier
+	entered, release chan struct{}
+}
+
+func (g gateClassifier) Classify(ctx context.Context, text string) (types.Classification, error) {
+	if strings.Contains(text, "slow") {
+		close(g.entered)
+		<-g.release
+	}
+	return g.flakyClassifier.Classify(ctx, text)
+}
+
+func within(t *testing.T, what string, f func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		f()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s did not return while a refresh was scanning", what)
+	}
+}
+
+func TestRefreshDoesNotBlockUpdatesOrLoseThem(t *testing.T) {
+	testutil.DataDir(t)
+	root := t.TempDir()
+	t.Setenv("DATASET_DIR", root)
+	file := filepath.Join(root, "calls.jsonl")
+	if err := os.WriteFile(file, []byte(`{"transcript":"refund please"}`+"\n"+`{"transcript":"refund again"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Format: FormatJSONL, Mapping: ColumnMapping{Transcript: "transcript"}}
+	d, err := Register("calls", "calls.jsonl", opts, flakyClassifier{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if d, err = GetRegistered(d.ID); err != nil {
+			t.Fatal(err)
+		}
+		if d.Status == StatusReady {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("dataset still %s", d.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"transcript":"slow refund"}` + "\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	gate := gateClassifier{entered: make(chan struct{}), release: make(chan struct{})}
+	type result struct {
+		d   *Registered
+		err error
+	}
+	slow := make(chan result)
+	go func() {
+		d, err := Refresh(context.Background(), d.ID, gate, false)
+		slow <- result{d, err}
+	}()
+	<-gate.entered
+
+	within(t, "AddProcessedCall", func() {
+		if err := AddProcessedCall(d.ID, "job-1", types.Classification{Category: "billing/refund"}); err != nil {
+			t.Error(err)
+		}
+	})
+	within(t, "a second Refresh", func() {
+		if _, err := Refresh(context.Background(), d.ID, flakyClassifier{}, false); err != nil {
+			t.Error(err)
+		}
+	})
+	within(t, "GetAndRefresh", func() {
+		if _, err := GetAndRefresh(d.ID, flakyClassifier{}); err != nil {
+			t.Error(err)
+		}
+	})
+	close(gate.release)
+
+	res := <-slow
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	if res.d.State.Rows != 3 || res.d.State.ByCategory["billing/refund"] != 3 {
+		t.Errorf("rows %d by category %v, want the appended row counted once", res.d.State.Rows, res.d.State.ByCategory)
+	}
+	if _, ok := res.d.State.ProcessedJobs["job-1"]; !ok || res.d.Summary.TotalCalls != 4 {
+		t.Errorf("total calls %d, processed %v; want the call added during the scan kept", res.d.Summary.TotalCalls, res.d.State.ProcessedJobs)
+	}
+}
@@ -0,0 +1,86 @@
+package dataset
+
+import "fmt"
+
+// Row issue actions.
+const (
+	ActionSkipped = "skipped" // the row was not imported
+	ActionCoerced = "coerced" // the row was imported with the field set to its zero value
+)
+
+// maxIssueValue truncates offending values echoed back in a report.
+const maxIssueValue = 80
+
+// RowIssue explains why one row was skipped or a field of it was coerced.
+type RowIssue struct {
+	Line   string `json:"line"`
+	Column string `json:"column,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Reason string `json:"reason"`
+	Action string `json:"action"`
+}
+
+// ImportReport accounts for every data row of an import.
+type ImportReport struct {
+	TotalRows  int `json:"total_rows"`
+	Imported   int `json:"imported"`
+	Skipped    int `json:"skipped"`
+	Coerced    int `json:"coerced_rows"`
+	Duplicates int `json:"duplicates"`
+	// SkippedByReason and CoercedByColumn summarise Issues
+	SkippedByReason map[string]int `json:"skipped_by_reason"`
+	CoercedByColumn map[string]int `json:"coerced_by_column"`
+	Issues          []RowIssue     `json:"issues"`
+}
+
+func newImportReport() ImportReport {
+	return ImportReport{SkippedByReason: map[string]int{}, CoercedByColumn: map[string]int{}, Issues: []RowIssue{}}
+}
+
+// rowReport collects the issues of one row before it is committed to the report.
+type rowReport struct {
+	line    string
+	issues  []RowIssue
+	skipped string
+}
+
+func (r *rowReport) coerce(column, value, reason string) {
+	r.issues = append(r.issues, RowIssue{Line: r.line, Column: column, Value: truncate(value), Reason: reason, Action: ActionCoerced})
+}
+
+// skip marks the row as not imported. reason is also the key of SkippedByReason.
+func (r *rowReport) skip(column, value, reason, detail string) {
+	if r.skipped != "" {
+		return
+	}
+	r.skipped = reason
+	msg := reason
+	if detail != "" {
+		msg = fmt.Sprintf("%s: %s", reason, detail)
+	}
+	r.issues = append(r.issues, RowIssue{Line: r.line, Column: column, Value: truncate(value), Reason: msg, Action: ActionSkipped})
+}
+
+func (rep *ImportReport) add(r *rowReport) {
+	rep.TotalRows++
+	rep.Issues = append(rep.Issues, r.issues...)
+	if r.skipped != "" {
+		rep.Skipped++
+		rep.SkippedByReason[r.skipped]++
+		return
+	}
+	rep.Imported++
+	if len(r.issues) > 0 {
+		rep.Coerced++
+		for _, is := range r.issues {
+			rep.CoercedByColumn[is.Column]++
+		}
+	}
+}
+
+func truncate(s string) string {
+	if r := []rune(s); len(r) > maxIssueValue {
+		return string(r[:maxIssueValue]) + "…"
+	}
+	return s
+}
@@ -1,149 +1,294 @@
 package dataset
 
 import (
-	"fmt"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"os"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/sirupsen/logrus"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/cohort"
 	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/types"
 )
 
 type DatasetSummary struct {
 	TotalCalls            int                 `json:"total_calls"`
+	ProcessedCalls        int                 `json:"processed_calls"`
 	ByCategory            map[string]int      `json:"by_category"`
+	CategoryConfidence    map[string]float64  `json:"category_confidence"`
+	Classifier            string              `json:"classifier"`
 	ByCityTopN            map[string][]string `json:"by_city_top_issues"`
 	ByVintageBucket       map[string]float64  `json:"by_vintage_bucket_rate"`
 	TopExampleTranscripts []string            `json:"top_example_transcripts"`
 }
 
+// maxExamples is how many redacted example transcripts a summary carries.
+const maxExamples = 6
+
+// errRewritten means rows already counted have changed, so the state must be rebuilt.
+var errRewritten = errors.New("dataset rows changed since the last summary")
+
+// SummaryState holds the running aggregates behind a DatasetSummary, so a registered dataset
+// can fold in appended rows and processed calls without classifying everything again.
+type SummaryState struct {
+	// Rows counted so far and the hash of the header plus those rows
+	Rows     int    `json:"rows"`
+	RowsHash string `json:"rows_hash"`
+
+	Classifier string `json:"classifier"`
+	// CohortVersion is the cohort config the vintage buckets were labelled with
+	CohortVersion   int                       `json:"cohort_version"`
+	ByCategory      map[string]int            `json:"by_category"`
+	ConfidenceSum   map[string]float64        `json:"confidence_sum"`
+	ByCityCategory  map[string]map[string]int `json:"by_city_category"`
+	VintageCalls    map[string]int            `json:"vintage_calls"`
+	VintageConfused map[string]int            `json:"vintage_confused"`
+	Examples        []string                  `json:"examples"`
+	// NoExamples is set once redaction fails, so unredacted text is never kept
+	NoExamples bool `json:"no_examples,omitempty"`
+	// ProcessedJobs are calls from the live pipeline, keyed by job id so retries are not
+	// double counted. They are kept apart from the row aggregates to survive a rebuild.
+	ProcessedJobs map[string]ProcessedCall `json:"processed_jobs"`
+}
+
+// ProcessedCall is the classification of one pipeline call counted in a summary.
+type ProcessedCall struct {
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+func newSummaryState(classifierName string, cohortVersion int) *SummaryState {
+	return &SummaryState{
+		Classifier:      classifierName,
+		CohortVersion:   cohortVersion,
+		ByCategory:      map[string]int{},
+		ConfidenceSum:   map[string]float64{},
+		ByCityCategory:  map[string]map[string]int{},
+		VintageCalls:    map[string]int{},
+		VintageConfused: map[string]int{},
+		Examples:        []string{},
+		ProcessedJobs:   map[string]ProcessedCall{},
+	}
+}
+
 // LoadAndSummarize reads the dataset and produces a compact summary used as LLM context.
-func LoadAndSummarize(path string) (DatasetSummary, error) {
-	log := logger.New().WithField("component", "dataset.summary").WithField("path", path)
-	log.Info("opening dataset for summarization")
-	f, err := excelize.OpenFile(path)
+// Each transcript is categorized by c, the same classifier the live pipeline uses.
+func LoadAndSummarize(ctx context.Context, path string, opts Options, c classifier.Classifier) (DatasetSummary, error) {
+	cfg, err := cohort.Current()
 	if err != nil {
-		log.WithError(err).Error("open failed")
-		return DatasetSummary{}, fmt.Errorf("open: %w", err)
+		return DatasetSummary{}, err
 	}
-	sheets := f.GetSheetList()
-	if len(sheets) == 0 {
-		log.Error("no sheets found")
-		return DatasetSummary{}, fmt.Errorf("no sheets")
+	st := newSummaryState(c.Name(), cfg.Version)
+	if err := st.scan(ctx, path, opts, c, cfg); err != nil {
+		return DatasetSummary{}, err
 	}
-	rows, err := f.GetRows(sheets[0])
+	return st.Summary(), nil
+}
+
+// scan reads the dataset and adds every row after the first s.Rows. The skipped prefix is
+// hashed and compared with s.RowsHash; errRewritten means it no longer matches. Vintage is
+// bucketed with cfg, which must be the config s was started with.
+func (s *SummaryState) scan(ctx context.Context, path string, opts Options, c classifier.Classifier, cfg *cohort.Config) error {
+	log := logger.New().WithField("component", "dataset.summary").WithField("path", path)
+	log.WithField("known_rows", s.Rows).Info("opening dataset for summarization")
+	mapping, err := mappingFor(opts)
 	if err != nil {
-		log.WithError(err).Error("read rows failed")
-		return DatasetSummary{}, fmt.Errorf("read: %w", err)
+		return err
 	}
-	if len(rows) <= 1 {
-		log.Error("no data rows")
-		return DatasetSummary{}, fmt.Errorf("no data rows")
+	rd, err := Open(path, opts)
+	if err != nil {
+		log.WithError(err).Error("open failed")
+		return apperr.Wrap(apperr.InvalidInput, "", "open dataset", err)
 	}
+	defer rd.Close()
 
-	complaintTokens := []string{"price", "refund", "payment", "delivery", "broken", "verification", "register", "gst", "tax", "quality"}
-	byCat := map[string]int{}
-	byCityCounts := map[string]map[string]int{}
-	byVintageCount := map[string]int{}
-	byVintageConf := map[string]int{}
-	examples := []string{}
-
-	header := rows[0]
-	transcriptIdx := -1
-	cityIdx := -1
-	vintageIdx := -1
-	for i, h := range header {
-		n := strings.ToLower(strings.TrimSpace(h))
-		if transcriptIdx == -1 && (strings.Contains(n, "transcript") || strings.Contains(n, "text")) {
-			transcriptIdx = i
-		}
-		if cityIdx == -1 && strings.Contains(n, "city") {
-			cityIdx = i
-		}
-		if vintageIdx == -1 && (strings.Contains(n, "vintage") || strings.Contains(n, "month")) {
-			vintageIdx = i
-		}
-	}
-	if transcriptIdx == -1 {
-		if len(header) > 5 {
-			transcriptIdx = 5
-		} else {
-			transcriptIdx = -1
-		}
+	cols, err := mapping.resolve(rd.Header(), "transcript")
+	if err != nil {
+		log.WithError(err).Error("column mapping does not match dataset")
+		return apperr.Wrap(apperr.InvalidInput, "", "column mapping does not match dataset (see POST /datasets/inspect)", err)
 	}
-	log.WithFields(map[string]interface{}{
-		"transcriptIdx": transcriptIdx,
-		"cityIdx":       cityIdx,
-		"vintageIdx":    vintageIdx,
-	}).Info("detected summary column indices")
 
-	for i, r := range rows {
-		if i == 0 {
-			continue
-		}
-		text := ""
-		city := ""
-		vintage := ""
-		if transcriptIdx >= 0 && transcriptIdx < len(r) {
-			text = r[transcriptIdx]
+	h := sha256.New()
+	hashRow(h, rd.Header())
+	seen, added := 0, 0
+	var batch []pendingRow
+	for {
+		r, err := rd.Next()
+		if err == io.EOF {
+			break
 		}
-		if cityIdx >= 0 && cityIdx < len(r) {
-			city = strings.ToLower(strings.TrimSpace(r[cityIdx]))
-		}
-		if vintageIdx >= 0 && vintageIdx < len(r) {
-			vintage = strings.TrimSpace(r[vintageIdx])
+		var bad *RowError
+		if errors.As(err, &bad) {
+			// not counted, so the row is picked up if it is fixed and the prefix rehashed
+			log.WithError(bad.Err).WithField("line", bad.Line).Warn("skipping unreadable row")
+			continue
 		}
-		lower := strings.ToLower(text)
-		cat := "other"
-		for _, t := range complaintTokens {
-			if strings.Contains(lower, t) {
-				cat = t
-				break
-			}
+		if err != nil {
+			log.WithError(err).Error("read rows failed")
+			return apperr.Wrap(apperr.InvalidInput, "", "read rows", err)
 		}
-		byCat[cat]++
-		if city != "" {
-			if _, ok := byCityCounts[city]; !ok {
-				byCityCounts[city] = map[string]int{}
-			}
-			for _, t := range complaintTokens {
-				if strings.Contains(lower, t) {
-					byCityCounts[city][t]++
-				}
+		hashRow(h, r)
+		seen++
+		if seen <= s.Rows {
+			if seen == s.Rows && hex.EncodeToString(h.Sum(nil)) != s.RowsHash {
+				return errRewritten
 			}
+			continue
 		}
-		// vintage bucket
-		bucket := "12+"
-		if vintage != "" {
-			var vi int
-			fmt.Sscanf(vintage, "%d", &vi)
-			switch {
-			case vi <= 2:
-				bucket = "0-2"
-			case vi <= 6:
-				bucket = "2-6"
-			case vi <= 12:
-				bucket = "6-12"
-			default:
-				bucket = "12+"
+		batch = append(batch, pendingRow{line: rd.Line(), text: cell(r, cols.Transcript), city: strings.ToLower(cell(r, cols.City)), bucket: vintageBucket(cfg, cell(r, cols.Vintage))})
+		if len(batch) == classifyBatch {
+			if err := s.addRows(ctx, c, log, batch); err != nil {
+				return err
 			}
+			added += len(batch)
+			batch = batch[:0]
 		}
-		byVintageCount[bucket]++
-		if strings.Contains(lower, "confus") || strings.Contains(lower, "don't understand") || strings.Contains(lower, "how to") {
-			byVintageConf[bucket]++
+	}
+	if err := s.addRows(ctx, c, log, batch); err != nil {
+		return err
+	}
+	added += len(batch)
+	if seen < s.Rows {
+		return errRewritten
+	}
+	if seen == 0 {
+		log.Error("no data rows")
+		return apperr.New(apperr.InvalidInput, "", "no data rows")
+	}
+	s.Rows = seen
+	s.RowsHash = hex.EncodeToString(h.Sum(nil))
+	log.WithField("rows", seen).WithField("added", added).Info("dataset rows summarized")
+	return nil
+}
+
+func hashRow(h hash.Hash, row []string) {
+	for _, v := range row {
+		h.Write([]byte(v))
+		h.Write([]byte{0x1f})
+	}
+	h.Write([]byte{0x1e})
+}
+
+// vintageBucket labels a raw vintage cell the way Load reads it: blank or unparseable
+// values are unknown.
+func vintageBucket(cfg *cohort.Config, v string) string {
+	if n, ok := parseInt(v); ok && n >= 0 {
+		return cfg.VintageBucket(n)
+	}
+	return cfg.Vintage.Unknown
+}
+
+// classifyBatch rows are classified together by up to classifyWorkers goroutines, so an
+// LLM classifier has a bounded number of calls in flight instead of one per row in turn.
+const classifyBatch = 32
+
+// classifyWorkers is DATASET_CLASSIFY_WORKERS (default 4, at most 16).
+func classifyWorkers() int {
+	if n, err := strconv.Atoi(os.Getenv("DATASET_CLASSIFY_WORKERS")); err == nil && n > 0 {
+		return min(n, 16)
+	}
+	return 4
+}
+
+// pendingRow is a dataset row waiting for classification.
+type pendingRow struct {
+	line               string
+	text, city, bucket string
+}
+
+// addRows classifies rows concurrently and folds them in in order, so the kept examples are
+// the same as a serial scan. It stops with the context's error once ctx is done.
+func (s *SummaryState) addRows(ctx context.Context, c classifier.Classifier, log *logrus.Entry, rows []pendingRow) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	results := make([]types.Classification, len(rows))
+	errs := make([]error, len(rows))
+	sem := make(chan struct{}, classifyWorkers())
+	var wg sync.WaitGroup
+	for i, r := range rows {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i], errs[i] = c.Classify(ctx, r.text)
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for i, r := range rows {
+		s.addRow(log.WithField("row", r.line), r.text, r.city, r.bucket, results[i], errs[i])
+	}
+	return nil
+}
+
+func (s *SummaryState) addRow(log *logrus.Entry, text, city, bucket string, cls types.Classification, err error) {
+	if err != nil {
+		log.WithError(err).Warn("classification failed; counting row as other")
+		cls = types.Classification{Category: taxonomy.OtherID}
+	}
+	s.ByCategory[cls.Category]++
+	s.ConfidenceSum[cls.Category] += cls.Confidence
+	if city != "" {
+		if _, ok := s.ByCityCategory[city]; !ok {
+			s.ByCityCategory[city] = map[string]int{}
 		}
-		if len(examples) < 6 && text != "" {
-			examples = append(examples, text)
+		s.ByCityCategory[city][cls.Category]++
+	}
+	s.VintageCalls[bucket]++
+	if cls.Confused {
+		s.VintageConfused[bucket]++
+	}
+	if !s.NoExamples && len(s.Examples) < maxExamples && text != "" {
+		// examples are logged and fed to the LLM, so they get the same redaction as live calls
+		red, _, err := redact.Text(text)
+		if err != nil {
+			log.WithError(err).Error("redaction failed; skipping example transcripts")
+			s.NoExamples = true
+			return
 		}
+		s.Examples = append(s.Examples, red)
+	}
+}
+
+// addCall folds in a call processed by the live pipeline. It reports false when jobID was
+// already counted.
+func (s *SummaryState) addCall(jobID string, cls types.Classification) bool {
+	if _, ok := s.ProcessedJobs[jobID]; ok {
+		return false
 	}
+	s.ProcessedJobs[jobID] = ProcessedCall{Category: cls.Category, Confidence: cls.Confidence}
+	return true
+}
+
+// Summary renders the aggregates as the compact summary used for LLM context.
+func (s *SummaryState) Summary() DatasetSummary {
 	byCityTopN := map[string][]string{}
-	for city, m := range byCityCounts {
-		type pc struct{ p string; c int }
+	for city, m := range s.ByCityCategory {
+		type pc struct {
+			p string
+			c int
+		}
 		var arr []pc
 		for k, v := range m {
 			arr = append(arr, pc{k, v})
 		}
-		sort.Slice(arr, func(i, j int) bool { return arr[i].c > arr[j].c })
+		sort.Slice(arr, func(i, j int) bool { return arr[i].c > arr[j].c || arr[i].c == arr[j].c && arr[i].p < arr[j].p })
 		top := []string{}
 		for i := 0; i < len(arr) && i < 3; i++ {
 			top = append(top, arr[i].p)
@@ -151,29 +296,43 @@ func LoadAndSummarize(path string) (DatasetSummary, error) {
 		byCityTopN[city] = top
 	}
 	byVintageRate := map[string]float64{}
-	for k, tot := range byVintageCount {
+	for k, tot := range s.VintageCalls {
 		if tot == 0 {
 			byVintageRate[k] = 0
 		} else {
-			byVintageRate[k] = float64(byVintageConf[k]) / float64(tot)
+			byVintageRate[k] = float64(s.VintageConfused[k]) / float64(tot)
 		}
 	}
 
+	byCat := map[string]int{}
+	confSum := map[string]float64{}
+	for cat, n := range s.ByCategory {
+		byCat[cat], confSum[cat] = n, s.ConfidenceSum[cat]
+	}
+	for _, pc := range s.ProcessedJobs {
+		byCat[pc.Category]++
+		confSum[pc.Category] += pc.Confidence
+	}
+	catConfidence := map[string]float64{}
+	for cat, n := range byCat {
+		catConfidence[cat] = confSum[cat] / float64(n)
+	}
+
 	ds := DatasetSummary{
-		TotalCalls:            len(rows) - 1,
+		TotalCalls:            s.Rows + len(s.ProcessedJobs),
+		ProcessedCalls:        len(s.ProcessedJobs),
 		ByCategory:            byCat,
+		CategoryConfidence:    catConfidence,
+		Classifier:            s.Classifier,
 		ByCityTopN:            byCityTopN,
 		ByVintageBucket:       byVintageRate,
-		TopExampleTranscripts: examples,
+		TopExampleTranscripts: s.Examples,
 	}
+	log := logger.New().WithField("component", "dataset.summary")
 	log.WithFields(map[string]interface{}{
 		"total_calls": ds.TotalCalls,
 		"categories":  len(ds.ByCategory),
 		"cities":      len(ds.ByCityTopN),
-	}).Info("dataset summarization complete")
-	// log top example transcripts (full)
-	for i, ex := range ds.TopExampleTranscripts {
-		log.WithField("example_index", i).Debug("example transcript", ex)
-	}
-	return ds, nil
+	}).Debug("dataset summary rendered")
+	return ds
 }
@@ -0,0 +1,51 @@
+package dataset
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"voice-insights-go/internal/taxonomy"
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/types"
+)
+
+// flakyClassifier files every row as a refund and fails on rows that mention "timeout",
+// returning the zero classification as the real classifiers do on error.
+type flakyClassifier struct{}
+
+func (flakyClassifier) Name() string { return "flaky" }
+
+func (flakyClassifier) Classify(_ context.Context, text string) (types.Classification, error) {
+	if strings.Contains(text, "timeout") {
+		return types.Classification{}, errors.New("classifier unavailable")
+	}
+	return types.Classification{Category: "billing/refund", Confidence: 0.8}, nil
+}
+
+func TestSummaryCountsFailedClassificationsAsOther(t *testing.T) {
+	testutil.DataDir(t)
+	input := `{"transcript":"refund please","city":"Pune"}` + "\n" +
+		`{"transcript":"timeout on this one","city":"Pune"}` + "\n" +
+		`{"transcript":"refund again","city":"Delhi"}` + "\n"
+	opts := Options{Format: FormatJSONL, Mapping: ColumnMapping{Transcript: "transcript", City: "city"}}
+	sum, err := LoadAndSummarize(context.Background(), writeFile(t, "d.jsonl", input), opts, flakyClassifier{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"billing/refund": 2, taxonomy.OtherID: 1}
+	if len(sum.ByCategory) != len(want) || sum.ByCategory["billing/refund"] != 2 || sum.ByCategory[taxonomy.OtherID] != 1 {
+		t.Errorf("by category = %v, want %v", sum.ByCategory, want)
+	}
+	for city, top := range sum.ByCityTopN {
+		for _, issue := range top {
+			if issue == "" {
+				t.Errorf("city %s has an unnamed issue: %v", city, top)
+			}
+		}
+	}
+	if sum.TotalCalls != 3 || sum.CategoryConfidence[taxonomy.OtherID] != 0 {
+		t.Errorf("total %d, confidence %v", sum.TotalCalls, sum.CategoryConfidence)
+	}
+}
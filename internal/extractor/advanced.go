@@ -6,13 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
-	"strings"
+	"sort"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/llm"
 	"voice-insights-go/internal/logger"
+	transcriptpkg "voice-insights-go/internal/transcript"
 	"voice-insights-go/internal/types"
 )
 
@@ -82,11 +85,15 @@ STRICT RULES:
 ======================================================================
 1. **NO hallucinations** — if unsure, output 0 or empty.
 2. Base ALL insights on transcript + search results.
-3. DO NOT mention transcript text in output.
+3. DO NOT mention transcript text in output, except verbatim quotes in "grounding".
 4. DO NOT mention search results explicitly.
 5. DO NOT add extra fields or remove fields.
 6. DO NOT wrap JSON in quotes or backticks.
 7. **Return ONLY valid JSON matching SCHEMA v2.0 exactly.**
+8. Every list item (steps, missed opportunities, compliance flags, missed questions,
+   actions, red flags) MUST have a "grounding" entry: "field" is the JSON path of the list,
+   "item" repeats the list item exactly, and "quotes" holds 1–3 VERBATIM transcript spans
+   with the [turn] number they come from. Items you cannot quote MUST be left out.
 
 ======================================================================
 SCHEMA v2.0 (STRICT OUTPUT)
@@ -173,11 +180,20 @@ SCHEMA v2.0 (STRICT OUTPUT)
     "customer_ltv_bucket": "",
     "service_gap_identified": "",
     "fix_urgency_level": ""
-  }
+  },
+
+  "grounding": [
+    {
+      "field": "agent_analysis.missed_opportunities",
+      "item": "",
+      "quotes": [{"turn": 0, "text": ""}]
+    }
+  ]
 }
 
 ======================================================================
-SEARCH RESULTS (Top-K similar calls):
+SEARCH RESULTS (Top-K similar calls; "historical_context", when present, summarises past calls;
+"prior_contacts", when present, lists this customer's earlier calls — set repeat_issue from them):
 %s
 
 TRANSCRIPT:
@@ -192,11 +208,11 @@ Return ONLY valid JSON.
 
 
 // FetchSearchResults calls your /search API and returns unmarshalled JSON (any).
-func FetchSearchResults(searchAPIURL string, transcript string, k int, httpTimeout time.Duration) (any, error) {
+func FetchSearchResults(ctx context.Context, searchAPIURL string, transcript string, k int, httpTimeout time.Duration) (any, error) {
 	log := logger.New().WithField("component", "search-client")
 
 	if searchAPIURL == "" {
-		return nil, fmt.Errorf("SEARCH_API_URL not configured")
+		return nil, apperr.New(apperr.Internal, types.StageSearch, "SEARCH_API_URL not configured")
 	}
 
 	reqPayload := map[string]any{
@@ -205,42 +221,92 @@ func FetchSearchResults(searchAPIURL string, transcript string, k int, httpTimeo
 	}
 	reqBytes, _ := json.Marshal(reqPayload)
 
-	req, _ := http.NewRequest("POST", searchAPIURL, bytes.NewReader(reqBytes))
+	req, _ := http.NewRequestWithContext(ctx, "POST", searchAPIURL, bytes.NewReader(reqBytes))
 	req.Header.Set("Content-Type", "application/json")
 
 	client := &http.Client{Timeout: httpTimeout}
 	resp, err := client.Do(req)
 	if err != nil {
 		log.WithError(err).Error("search API request failed")
-		return nil, err
+		return nil, apperr.FromTransport(types.StageSearch, err)
 	}
 	defer resp.Body.Close()
 
 	body, _ := io.ReadAll(resp.Body)
 	log.Debug("search API raw response:\n" + string(body))
+	if resp.StatusCode >= 300 {
+		log.WithField("http_status", resp.StatusCode).Error("search API returned error status")
+		return nil, apperr.FromHTTPStatus(types.StageSearch, resp.StatusCode, string(body))
+	}
 
 	var parsed any
 	if err := json.Unmarshal(body, &parsed); err != nil {
 		log.WithError(err).Error("failed to parse search API JSON")
-		return nil, err
+		return nil, apperr.Wrap(apperr.ParseFailure, types.StageSearch, "invalid search API JSON", err)
 	}
 
 	return parsed, nil
 }
 
+// Search runs the /search API stage on its own so callers can attribute failures to it.
+func Search(ctx context.Context, transcript string, k int) (any, error) {
+	return FetchSearchResults(ctx, os.Getenv("SEARCH_API_URL"), transcript, k, 60*time.Second)
+}
+
+// WithHistory attaches a historical dataset summary to the search results, so the trend
+// fields can be judged against the whole call history and not only the top-k matches. A nil
+// history returns searchResults unchanged.
+func WithHistory(searchResults any, history any) any {
+	if history == nil {
+		return searchResults
+	}
+	return map[string]any{
+		"similar_calls":      searchResults,
+		"historical_context": history,
+	}
+}
+
+// WithPriorContacts attaches the caller's earlier contacts to the prompt evidence, so
+// repeat_issue can be judged from what the customer actually called about before. Nil
+// prior returns evidence unchanged.
+func WithPriorContacts(evidence any, prior any) any {
+	if prior == nil {
+		return evidence
+	}
+	if m, ok := evidence.(map[string]any); ok {
+		if _, wrapped := m["similar_calls"]; wrapped {
+			out := make(map[string]any, len(m)+1)
+			for k, v := range m {
+				out[k] = v
+			}
+			out["prior_contacts"] = prior
+			return out
+		}
+	}
+	return map[string]any{
+		"similar_calls":  evidence,
+		"prior_contacts": prior,
+	}
+}
+
 // ExtractAdvanced orchestrates search -> prompt build -> LLM -> parse
 // Keeps the same return signature types.KPIExtraction for compatibility.
-func ExtractAdvanced(transcript string, k int) (types.KPIExtraction, error) {
+func ExtractAdvanced(ctx context.Context, transcript string, k int) (types.KPIExtraction, error) {
+	if os.Getenv("USE_MOCK_LLM") == "true" {
+		return ExtractFromSearch(ctx, transcript, nil)
+	}
 
-	var (
-		httpTimeout     = 60 * time.Second
-		maxRetryTime    = 60 * time.Second
-		searchAPIURL    = os.Getenv("SEARCH_API_URL")
-		llmGatewayURL   = os.Getenv("LLM_GATEWAY_URL")
-		llmGatewayModel = os.Getenv("LLM_MODEL")
-		llmAPIKey       = os.Getenv("LLM_API_KEY")
-	)
+	// 1) call search API (k=3)
+	searchResults, err := Search(ctx, transcript, k)
+	if err != nil {
+		return types.KPIExtraction{}, fmt.Errorf("search API failed: %w", err)
+	}
+	return ExtractFromSearch(ctx, transcript, searchResults)
+}
 
+// ExtractFromSearch runs prompt build -> LLM -> parse against already fetched search results.
+// ctx cancels in-flight LLM requests and stops further retries.
+func ExtractFromSearch(ctx context.Context, transcript string, searchResults any) (types.KPIExtraction, error) {
 	log := logger.New().WithField("component", "extractor-advanced")
 
 	// mock
@@ -343,155 +409,88 @@ func ExtractAdvanced(transcript string, k int) (types.KPIExtraction, error) {
 		return mock, nil
 	}
 
-	// 1) call search API (k=3)
-	searchResults, err := FetchSearchResults(searchAPIURL, transcript, k, httpTimeout)
-	if err != nil {
-		return types.KPIExtraction{}, fmt.Errorf("search API failed: %w", err)
-	}
-
-	// 2) build prompt using search results + transcript
-	prompt := BuildAdvancedPrompt(transcript, searchResults)
-
-	// 3) prepare LLM request payload
-	if llmGatewayURL == "" || llmAPIKey == "" {
-		return types.KPIExtraction{}, fmt.Errorf("llm gateway not configured")
-	}
-	reqBody := map[string]any{
-		"model": llmGatewayModel,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"temperature": 0.0,
-	}
-	data, _ := json.MarshalIndent(reqBody, "", "  ")
-	log.Debug("LLM request payload (sizes):", "payload_len", len(data))
-
-	var extracted types.KPIExtraction
-	var lastErr error
-
-	// LLM call with retry/backoff
-	op := func() error {
-		ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
-		defer cancel()
-
-		req, _ := http.NewRequestWithContext(ctx, "POST", llmGatewayURL, bytes.NewReader(data))
-		req.Header.Set("Authorization", "Bearer "+llmAPIKey)
-		req.Header.Set("Content-Type", "application/json")
-
-		client := &http.Client{Timeout: httpTimeout}
-		resp, err := client.Do(req)
+	// 2) long calls: condense by map-reduce so the prompt fits the model context
+	if NeedsChunking(transcript, searchResults) {
+		digest, n, err := CondenseTranscript(ctx, transcript)
 		if err != nil {
-			lastErr = err
-			log.WithError(err).Warn("llm request failed")
-			return err
-		}
-		defer resp.Body.Close()
-
-		body, _ := io.ReadAll(resp.Body)
-		log.WithField("http_status", resp.StatusCode).Debug("llm raw:\n" + string(body))
-
-		// Try choices[0].message.content (OpenAI-like)
-		if inner := extractContentFromChoices(body); inner != "" {
-			log.Debug("extracted JSON from choices content")
-			if err := json.Unmarshal([]byte(inner), &extracted); err == nil {
-				lastErr = nil
-				return nil
-			}
-			log.WithError(err).Warn("unmarshal from choices content failed")
+			return types.KPIExtraction{}, fmt.Errorf("condense transcript: %w", err)
 		}
+		log.WithField("chunks", n).Info("using condensed transcript for extraction")
+		transcript = digest
+	}
 
-		// Fallback: find first balanced JSON in response body
-		if fallback := extractJSON(string(body)); fallback != "" {
-			if err := json.Unmarshal([]byte(fallback), &extracted); err == nil {
-				lastErr = nil
-				return nil
-			}
-			log.WithError(err).Warn("unmarshal from fallback JSON failed")
-		}
+	// 3) build prompt using search results + transcript (turn-numbered so quotes can cite turns)
+	prompt := BuildAdvancedPrompt(transcriptpkg.Numbered(transcript), searchResults)
 
-		// Couldn't parse; log and return temporary error to retry (unless 4xx)
-		lastErr = fmt.Errorf("no JSON found in LLM output")
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			// Permanent: don't retry on client errors
-			return backoff.Permanent(lastErr)
-		}
-		return lastErr
+	// 4) LLM call (retry/backoff + JSON recovery live in the llm client)
+	var extracted types.KPIExtraction
+	if err := llm.CompleteJSON(ctx, prompt, &extracted); err != nil {
+		return types.KPIExtraction{}, fmt.Errorf("llm extract failed: %w", err)
 	}
 
-	b := backoff.NewExponentialBackOff()
-	b.MaxElapsedTime = maxRetryTime
-
-	if err := backoff.Retry(op, b); err != nil {
-		return types.KPIExtraction{}, fmt.Errorf("llm extract failed: %w", lastErr)
+	if fixed := clampExtraction(&extracted); len(fixed) > 0 {
+		log.WithField("clamped", fixed).Warn("llm output out of range; values clamped")
 	}
 
 	log.WithField("parsed_kpi", fmt.Sprintf("%+v", extracted)).Info("parsed KPIExtraction")
 	return extracted, nil
 }
 
-// extractContentFromChoices attempts to read openai-style choices[0].message.content JSON
-func extractContentFromChoices(body []byte) string {
-	var obj map[string]any
-	if err := json.Unmarshal(body, &obj); err != nil {
-		return ""
+// clampExtraction pulls values outside the Schema v2 ranges the prompt asks for back into
+// range (scores and rates to 0..1, counts and lengths to >= 0, NaN to 0) and returns what it
+// changed as sorted "field=value" pairs. One stray score is not worth failing the call.
+func clampExtraction(x *types.KPIExtraction) []string {
+	unit := map[string]*float64{
+		"kpi.customer_talk_ratio":                   &x.KPI.CustomerTalkRatio,
+		"kpi.agent_talk_ratio":                      &x.KPI.AgentTalkRatio,
+		"kpi.frustration_score":                     &x.KPI.FrustrationScore,
+		"kpi.confusion_level":                       &x.KPI.ConfusionLevel,
+		"kpi.empathy_score":                         &x.KPI.EmpathyScore,
+		"kpi.resolution_likelihood":                 &x.KPI.ResolutionLikelihood,
+		"agent_analysis.rapport_score":              &x.AgentAnalysis.RapportScore,
+		"agent_analysis.professionalism_score":      &x.AgentAnalysis.ProfessionalismScore,
+		"agent_analysis.solution_accuracy_score":    &x.AgentAnalysis.SolutionAccuracyScore,
+		"conversation_quality.overall_score":        &x.ConversationQuality.OverallScore,
+		"conversation_quality.clarity_score":        &x.ConversationQuality.ClarityScore,
+		"conversation_quality.listening_score":      &x.ConversationQuality.ListeningScore,
+		"conversation_quality.relevance_score":      &x.ConversationQuality.RelevanceScore,
+		"conversation_quality.trust_building_score": &x.ConversationQuality.TrustBuildingScore,
+		"trend_insights.historical_resolution_rate": &x.TrendInsights.HistoricalResolutionRate,
+		"trend_insights.historical_escalation_rate": &x.TrendInsights.HistoricalEscalationRate,
+		"business_impact.risk_of_churn":             &x.BusinessImpact.RiskOfChurn,
 	}
-
-	choices, ok := obj["choices"].([]any)
-	if !ok || len(choices) == 0 {
-		return ""
-	}
-	c0, _ := choices[0].(map[string]any)
-	if c0 == nil {
-		return ""
-	}
-	msg, _ := c0["message"].(map[string]any)
-	if msg == nil {
-		return ""
-	}
-	content, _ := msg["content"].(string)
-	return extractJSON(content)
-}
-
-// extractJSON finds the first balanced JSON object in a string and returns it.
-// It strips common markdown fences first.
-func extractJSON(s string) string {
-	if s == "" {
-		return ""
-	}
-
-	// normalize newlines
-	s = strings.ReplaceAll(s, "\r\n", "\n")
-
-	// Remove markdown fences (commonly output by LLMs)
-	for _, r := range []string{"```json", "```yaml", "```text", "```", "`json", "`"} {
-		s = strings.ReplaceAll(s, r, "")
+	var fixed []string
+	for field, v := range unit {
+		if math.IsNaN(*v) || *v < 0 || *v > 1 {
+			fixed = append(fixed, fmt.Sprintf("%s=%v", field, *v))
+			if *v > 1 {
+				*v = 1
+			} else {
+				*v = 0
+			}
+		}
 	}
-
-	start := strings.Index(s, "{")
-	if start == -1 {
-		return ""
+	for field, v := range map[string]*float64{
+		"kpi.avg_sentence_length_customer": &x.KPI.AvgSentenceLengthCustomer,
+		"kpi.avg_sentence_length_agent":    &x.KPI.AvgSentenceLengthAgent,
+	} {
+		if math.IsNaN(*v) || *v < 0 {
+			fixed = append(fixed, fmt.Sprintf("%s=%v", field, *v))
+			*v = 0
+		}
 	}
-
-	depth := 0
-	for i := start; i < len(s); i++ {
-		switch s[i] {
-		case '{':
-			depth++
-		case '}':
-			depth--
-			if depth == 0 {
-				candidate := strings.TrimSpace(s[start : i+1])
-				// quick sanity check
-				var tmp any
-				if json.Unmarshal([]byte(candidate), &tmp) == nil {
-					return candidate
-				}
-				// otherwise return candidate anyway (best effort)
-				return candidate
-			}
+	for field, v := range map[string]*int{
+		"kpi.silence_seconds":                &x.KPI.SilenceSeconds,
+		"kpi.interruption_count":             &x.KPI.InterruptionCount,
+		"kpi.dead_air_instances":             &x.KPI.DeadAirInstances,
+		"kpi.topic_switch_count":             &x.KPI.TopicSwitchCount,
+		"trend_insights.similar_calls_count": &x.TrendInsights.SimilarCallsCount,
+	} {
+		if *v < 0 {
+			fixed = append(fixed, fmt.Sprintf("%s=%d", field, *v))
+			*v = 0
 		}
 	}
-
-	// no balanced found
-	return ""
+	sort.Strings(fixed)
+	return fixed
 }
@@ -0,0 +1,257 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/transcript"
+)
+
+// ChunkFindings is what the map step extracts from one transcript segment.
+type ChunkFindings struct {
+	IssuesRaised          []string `json:"issues_raised"`
+	StepsExplainedByAgent []string `json:"steps_explained_by_agent"`
+	MissedOpportunities   []string `json:"missed_opportunities"`
+	ComplianceFlags       []string `json:"compliance_flags"`
+	RedFlags              []string `json:"red_flags"`
+	CustomerCommitments   []string `json:"customer_commitments"`
+	AgentCommitments      []string `json:"agent_commitments"`
+	CustomerSentiment     string   `json:"customer_sentiment"`
+	FrustrationScore      float64  `json:"frustration_score"`
+	Summary               string   `json:"summary"`
+	// Quotes maps a list item above to verbatim spans supporting it, so the final prompt
+	// can still ground findings when it only sees the digest
+	Quotes map[string][]string `json:"quotes"`
+}
+
+// maxPromptTokens is the prompt budget (LLM_MAX_PROMPT_TOKENS, default 12000) above which
+// the transcript is condensed by map-reduce instead of being embedded verbatim.
+func maxPromptTokens() int {
+	if v, err := strconv.Atoi(os.Getenv("LLM_MAX_PROMPT_TOKENS")); err == nil && v > 0 {
+		return v
+	}
+	return 12000
+}
+
+// chunkOverlapTurns is how many turns consecutive chunks share (LLM_CHUNK_OVERLAP_TURNS, default 2).
+func chunkOverlapTurns() int {
+	if v, err := strconv.Atoi(os.Getenv("LLM_CHUNK_OVERLAP_TURNS")); err == nil && v >= 0 {
+		return v
+	}
+	return 2
+}
+
+// maxParallel bounds concurrent LLM calls (LLM_MAX_PARALLEL, default 4).
+func maxParallel() int {
+	if v, err := strconv.Atoi(os.Getenv("LLM_MAX_PARALLEL")); err == nil && v > 0 {
+		return v
+	}
+	return 4
+}
+
+// NeedsChunking reports whether the full Schema v2 prompt for transcript would exceed the budget.
+func NeedsChunking(transcriptText string, searchResults any) bool {
+	return transcript.EstimateTokens(BuildAdvancedPrompt(transcriptText, searchResults)) > maxPromptTokens()
+}
+
+// ChunkTranscript splits transcriptText into speaker-turn windows sized so each map prompt fits.
+func ChunkTranscript(transcriptText string) [][]transcript.Turn {
+	overhead := transcript.EstimateTokens(BuildChunkPrompt("", 1, 1))
+	budget := maxPromptTokens() - overhead
+	if budget < 500 {
+		budget = 500
+	}
+	return transcript.Chunk(transcript.Parse(transcriptText), budget, chunkOverlapTurns())
+}
+
+// BuildChunkPrompt builds the map-step prompt for one transcript segment.
+func BuildChunkPrompt(segment string, index, total int) string {
+	schema, _ := json.MarshalIndent(ChunkFindings{
+		IssuesRaised: []string{}, StepsExplainedByAgent: []string{}, MissedOpportunities: []string{},
+		ComplianceFlags: []string{}, RedFlags: []string{}, CustomerCommitments: []string{}, AgentCommitments: []string{},
+		Quotes: map[string][]string{"<list item>": {"[turn] verbatim words"}},
+	}, "", "  ")
+
+	return fmt.Sprintf(`You are an expert Call Quality and Customer Insights analyst.
+
+You are reading SEGMENT %d of %d of one long customer support call (Hinglish is common).
+Segments overlap by a few turns; report only what is said in THIS segment.
+
+Extract:
+- issues_raised: problems the customer raises
+- steps_explained_by_agent: guidance or steps the agent gives
+- missed_opportunities: things the agent should have done or asked but did not
+- compliance_flags: script or policy violations by the agent
+- red_flags: conduct problems (rudeness, false promises, defensiveness)
+- customer_commitments / agent_commitments: what each side agreed to do next
+- customer_sentiment: one word
+- frustration_score: 0.0–1.0
+- summary: 1–2 sentences
+- quotes: for every list item, 1–2 VERBATIM spans from the segment, prefixed with their [turn]
+
+STRICT RULES:
+1. **NO hallucinations** — if unsure, leave lists empty.
+2. Keep each list item a short phrase.
+3. **Return ONLY valid JSON matching this schema exactly:**
+%s
+
+SEGMENT:
+%s
+
+Return ONLY valid JSON.
+`, index, total, string(schema), segment)
+}
+
+// CondenseTranscript runs the map step over chunks of a long transcript in parallel and
+// reduces the findings into a compact digest that replaces the transcript in the final
+// Schema v2 prompt. It returns the digest and the number of chunks processed.
+func CondenseTranscript(ctx context.Context, transcriptText string) (string, int, error) {
+	log := logger.New().WithField("component", "extractor-chunked")
+
+	chunks := ChunkTranscript(transcriptText)
+	log.WithField("chunks", len(chunks)).WithField("est_tokens", transcript.EstimateTokens(transcriptText)).Info("condensing long transcript")
+
+	findings := make([]ChunkFindings, len(chunks))
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, maxParallel())
+	var wg sync.WaitGroup
+	for i, c := range chunks {
+		wg.Add(1)
+		go func(i int, c []transcript.Turn) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			errs[i] = llm.CompleteJSON(ctx, BuildChunkPrompt(renderNumbered(c), i+1, len(chunks)), &findings[i])
+		}(i, c)
+	}
+	wg.Wait()
+
+	var ok []ChunkFindings
+	var okIdx []int
+	var firstErr error
+	for i, err := range errs {
+		if err != nil {
+			log.WithError(err).WithField("chunk", i+1).Warn("chunk extraction failed")
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		ok = append(ok, findings[i])
+		okIdx = append(okIdx, i)
+	}
+	if len(ok) == 0 {
+		return "", len(chunks), fmt.Errorf("all %d transcript chunks failed: %w", len(chunks), firstErr)
+	}
+	return reduceFindings(ok, okIdx, chunks), len(chunks), nil
+}
+
+// reduceFindings merges chunk findings (deduplicating list items across overlapping chunks)
+// and renders them with per-speaker talk statistics as the digest for the final prompt.
+func reduceFindings(findings []ChunkFindings, idx []int, chunks [][]transcript.Turn) string {
+	var issues, steps, missed, compliance, red, custCommit, agentCommit []string
+	seen := map[string]bool{}
+	quoted := map[string][]string{}
+	add := func(dst *[]string, field string, items []string, quotes map[string][]string) {
+		for _, it := range items {
+			key := field + "|" + strings.ToLower(strings.Join(strings.Fields(it), " "))
+			if strings.TrimSpace(it) == "" {
+				continue
+			}
+			quoted[it] = append(quoted[it], quotes[it]...)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			*dst = append(*dst, it)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "CONDENSED TRANSCRIPT (long call processed in %d segments; findings merged in call order)\n\n", len(chunks))
+	b.WriteString("SEGMENT TIMELINE:\n")
+	for n, f := range findings {
+		add(&issues, "issue", f.IssuesRaised, f.Quotes)
+		add(&steps, "step", f.StepsExplainedByAgent, f.Quotes)
+		add(&missed, "missed", f.MissedOpportunities, f.Quotes)
+		add(&compliance, "compliance", f.ComplianceFlags, f.Quotes)
+		add(&red, "red", f.RedFlags, f.Quotes)
+		add(&custCommit, "cc", f.CustomerCommitments, f.Quotes)
+		add(&agentCommit, "ac", f.AgentCommitments, f.Quotes)
+		fmt.Fprintf(&b, "- segment %d: sentiment=%s frustration=%.2f — %s\n", idx[n]+1, f.CustomerSentiment, f.FrustrationScore, f.Summary)
+	}
+
+	section := func(title string, items []string) {
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		if len(items) == 0 {
+			b.WriteString("- (none)\n")
+		}
+		for _, it := range items {
+			fmt.Fprintf(&b, "- %s\n", it)
+			for _, q := range quoted[it] {
+				fmt.Fprintf(&b, "    quote: %s\n", q)
+			}
+		}
+	}
+	section("ISSUES RAISED", issues)
+	section("STEPS EXPLAINED BY AGENT", steps)
+	section("MISSED OPPORTUNITIES", missed)
+	section("COMPLIANCE FLAGS", compliance)
+	section("RED FLAGS", red)
+	section("CUSTOMER COMMITMENTS", custCommit)
+	section("AGENT COMMITMENTS", agentCommit)
+
+	// talk statistics are computed from the full transcript, not estimated by the model
+	words := map[string]int{}
+	turns := map[string]int{}
+	total := 0
+	counted := map[int]bool{}
+	for _, c := range chunks {
+		for _, t := range c {
+			if counted[t.Index] {
+				continue
+			}
+			counted[t.Index] = true
+			n := len(strings.Fields(t.Text))
+			words[t.Speaker] += n
+			turns[t.Speaker]++
+			total += n
+		}
+	}
+	speakers := make([]string, 0, len(words))
+	for sp := range words {
+		speakers = append(speakers, sp)
+	}
+	sort.Strings(speakers)
+	b.WriteString("\nSPEAKER STATISTICS (full call):\n")
+	for _, sp := range speakers {
+		n := words[sp]
+		name := sp
+		if name == "" {
+			name = "Unlabelled"
+		}
+		share := 0.0
+		if total > 0 {
+			share = float64(n) / float64(total)
+		}
+		fmt.Fprintf(&b, "- %s: %d turns, %d words, %.2f of words spoken\n", name, turns[sp], n, share)
+	}
+	return b.String()
+}
+
+// renderNumbered renders a chunk with the turns' global indices so quotes cite the full call.
+func renderNumbered(turns []transcript.Turn) string {
+	var b strings.Builder
+	for _, t := range turns {
+		fmt.Fprintf(&b, "[%d] %s: %s\n", t.Index, t.Speaker, t.Text)
+	}
+	return b.String()
+}
@@ -0,0 +1,313 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/logger"
+	transcriptpkg "voice-insights-go/internal/transcript"
+	"voice-insights-go/internal/types"
+)
+
+// Extraction modes. ModeSingle sends the monolithic Schema v2 prompt; ModeMultiPass
+// extracts each top-level block with its own focused prompt.
+const (
+	ModeSingle    = "single"
+	ModeMultiPass = "multipass"
+)
+
+// ModeFromEnv returns EXTRACTION_MODE, defaulting to ModeSingle.
+func ModeFromEnv() string {
+	if os.Getenv("EXTRACTION_MODE") == ModeMultiPass {
+		return ModeMultiPass
+	}
+	return ModeSingle
+}
+
+// extractionBlock describes one Schema v2 block extracted by its own sub-prompt.
+type extractionBlock struct {
+	Key    string // top-level JSON key in Schema v2
+	Focus  string // what the sub-prompt concentrates on
+	Ranges string // value-range rules that apply to this block
+	Schema any    // zero value rendered as the block's output schema
+	Merge  func(dst *types.KPIExtraction, src types.KPIExtraction)
+	// Grounded blocks contain list items and must return quotes for them
+	Grounded bool
+}
+
+var extractionBlocks = []extractionBlock{
+	{
+		Key:    "customer_problem",
+		Focus:  "what the customer called about: the primary issue, its urgency and severity (1-5), the customer's intent and whether it is a repeat issue.",
+		Schema: types.CustomerProblem{},
+		Merge:  func(d *types.KPIExtraction, s types.KPIExtraction) { d.CustomerProblem = s.CustomerProblem },
+	},
+	{
+		Key:      "agent_analysis",
+		Focus:    "how the agent handled the call: steps explained, correctness of guidance, missed opportunities, compliance flags and agent scores.",
+		Ranges:   "- rapport_score, professionalism_score, solution_accuracy_score: 0.0–1.0",
+		Schema:   types.AgentAnalysis{StepsExplainedByAgent: []string{}, MissedOpportunities: []string{}, ComplianceFlags: []string{}},
+		Merge:    func(d *types.KPIExtraction, s types.KPIExtraction) { d.AgentAnalysis = s.AgentAnalysis },
+		Grounded: true,
+	},
+	{
+		Key:   "kpi",
+		Focus: "conversation metrics: talk ratios, silence, interruptions, frustration, confusion, empathy, resolution likelihood, sentence lengths, dead air and topic switches.",
+		Ranges: `- customer_talk_ratio, agent_talk_ratio, frustration_score, confusion_level, empathy_score, resolution_likelihood: 0.0–1.0
+- silence_seconds, avg_sentence_length_customer, avg_sentence_length_agent: >= 0
+- interruption_count, dead_air_instances, topic_switch_count: >= 0 (integer)`,
+		Schema: types.KPIFields{},
+		Merge:  func(d *types.KPIExtraction, s types.KPIExtraction) { d.KPI = s.KPI },
+	},
+	{
+		Key:      "should_have_done",
+		Focus:    "the ideal resolution path, recommended follow-up, owning department, crucial questions the agent missed and data points not collected.",
+		Schema:   types.ShouldHaveDone{CrucialMissedQuestions: []string{}, RequiredDataPointsNotCollected: []string{}},
+		Merge:    func(d *types.KPIExtraction, s types.KPIExtraction) { d.ShouldHaveDone = s.ShouldHaveDone },
+		Grounded: true,
+	},
+	{
+		Key:      "actions",
+		Focus:    "concrete follow-up actions for executives, the customer and systems, their priority and whether escalation is required and why.",
+		Schema:   types.Actions{ExecutiveActionsRequired: []string{}, CustomerActionsRequired: []string{}, SystemActionsRequired: []string{}},
+		Merge:    func(d *types.KPIExtraction, s types.KPIExtraction) { d.Actions = s.Actions },
+		Grounded: true,
+	},
+	{
+		Key:      "conversation_quality",
+		Focus:    "overall conversation quality scores and red flags in the agent's conduct.",
+		Ranges:   "- overall_score, clarity_score, listening_score, relevance_score, trust_building_score: 0.0–1.0",
+		Schema:   types.ConversationQuality{RedFlags: []string{}},
+		Merge:    func(d *types.KPIExtraction, s types.KPIExtraction) { d.ConversationQuality = s.ConversationQuality },
+		Grounded: true,
+	},
+	{
+		Key:   "trend_insights_from_similar_calls",
+		Focus: "patterns across the TOP-K SEARCH RESULTS: similar call count, dominant issue category, city/vintage/engagement trends, root cause, playbook and historical rates.",
+		Ranges: `- similar_calls_count: >= 0 (integer)
+- historical_resolution_rate, historical_escalation_rate: 0.0–1.0`,
+		Schema: types.TrendInsights{},
+		Merge:  func(d *types.KPIExtraction, s types.KPIExtraction) { d.TrendInsights = s.TrendInsights },
+	},
+	{
+		Key:    "business_impact",
+		Focus:  "business consequences: churn risk, revenue opportunity loss, customer LTV bucket, the service gap and fix urgency.",
+		Ranges: "- risk_of_churn: 0.0–1.0",
+		Schema: types.BusinessImpact{},
+		Merge:  func(d *types.KPIExtraction, s types.KPIExtraction) { d.BusinessImpact = s.BusinessImpact },
+	},
+}
+
+// BlockKeys lists the Schema v2 blocks in prompt order.
+func BlockKeys() []string {
+	keys := make([]string, 0, len(extractionBlocks))
+	for _, b := range extractionBlocks {
+		keys = append(keys, b.Key)
+	}
+	return keys
+}
+
+// BuildBlockPrompt builds the focused sub-prompt for a single Schema v2 block.
+func BuildBlockPrompt(b extractionBlock, transcript string, searchResults any) string {
+	srJSON, _ := json.MarshalIndent(searchResults, "", "  ")
+	out := map[string]any{b.Key: b.Schema}
+	grounding := ""
+	if b.Grounded {
+		out["grounding"] = []types.GroundedFinding{{Field: b.Key + ".<list_field>", Quotes: []types.Quote{{}}}}
+		grounding = `6. Every list item MUST have a "grounding" entry: "field" is the JSON path of the list,
+   "item" repeats the list item exactly, and "quotes" holds 1–3 VERBATIM transcript spans
+   with the [turn] number they come from. Items you cannot quote MUST be left out.
+`
+	}
+	schema, _ := json.MarshalIndent(out, "", "  ")
+
+	return fmt.Sprintf(`You are an expert Call Quality, Customer Insights, and Resolution Intelligence engine.
+
+Your task:
+Analyze the CURRENT CALL TRANSCRIPT and the TOP-K SEARCH RESULTS and fill in ONLY the
+"%s" block of SCHEMA v2.0. Concentrate on %s
+
+======================================================================
+VALUE RANGES (clamp anything outside them)
+======================================================================
+%s
+
+======================================================================
+STRICT RULES:
+======================================================================
+1. **NO hallucinations** — if unsure, output 0 or empty.
+2. Base ALL insights on transcript + search results.
+3. DO NOT add extra fields or remove fields.
+4. DO NOT wrap JSON in quotes or backticks.
+5. **Return ONLY valid JSON matching the block schema exactly.**
+%s
+======================================================================
+BLOCK SCHEMA (STRICT OUTPUT)
+%s
+
+======================================================================
+SEARCH RESULTS (Top-K similar calls; "historical_context", when present, summarises past calls;
+"prior_contacts", when present, lists this customer's earlier calls — set repeat_issue from them):
+%s
+
+TRANSCRIPT:
+%s
+
+======================================================================
+Return ONLY valid JSON.
+`, b.Key, b.Focus, b.ranges(), grounding, string(schema), string(srJSON), transcript)
+}
+
+// ranges is the value-range rules of the block as listed in its prompt.
+func (b extractionBlock) ranges() string {
+	if b.Ranges == "" {
+		return "- severity: 1–5 (integer); all other fields are text, booleans or lists"
+	}
+	return b.Ranges
+}
+
+// rangeRetryPrompt asks for a block again after an answer with values out of range, naming
+// the rejected values and the ranges they break so the retry is not a repeat of the first.
+func rangeRetryPrompt(prompt string, b extractionBlock, rejected []string) string {
+	return fmt.Sprintf(`%s
+======================================================================
+YOUR PREVIOUS ANSWER WAS REJECTED. These values are outside their allowed ranges:
+- %s
+
+Allowed ranges:
+%s
+
+Answer again with every value inside its range.
+`, prompt, strings.Join(rejected, "\n- "), b.ranges())
+}
+
+// blockAttempts is how many times a block is asked when its output has values out of range;
+// the retry names the rejected values and the last answer is clamped. Transport and parse
+// failures are already retried inside the llm client.
+const blockAttempts = 2
+
+// ExtractMultiPass extracts each Schema v2 block with a focused prompt, in parallel, and merges
+// the results onto base. only limits the run to the named blocks (nil = all), which lets a job
+// retry re-run just the blocks that failed. A failed block leaves its part of base untouched and
+// is reported in the returned statuses; an error is returned only when every block failed.
+func ExtractMultiPass(ctx context.Context, transcript string, searchResults any, base types.KPIExtraction, only []string) (types.KPIExtraction, map[string]types.BlockStatus, error) {
+	log := logger.New().WithField("component", "extractor-multipass")
+
+	selected := extractionBlocks
+	if only != nil {
+		want := map[string]bool{}
+		for _, k := range only {
+			want[k] = true
+		}
+		selected = nil
+		for _, b := range extractionBlocks {
+			if want[b.Key] {
+				selected = append(selected, b)
+			}
+		}
+	}
+
+	statuses := map[string]types.BlockStatus{}
+	if os.Getenv("USE_MOCK_LLM") == "true" {
+		mock, err := ExtractFromSearch(ctx, transcript, searchResults)
+		for _, b := range selected {
+			b.Merge(&base, mock)
+			statuses[b.Key] = types.BlockStatus{Status: types.StageOK, Attempts: 1}
+		}
+		return base, statuses, err
+	}
+
+	// long calls: every block works from the same condensed transcript
+	if NeedsChunking(transcript, searchResults) {
+		digest, n, err := CondenseTranscript(ctx, transcript)
+		if err != nil {
+			return base, statuses, fmt.Errorf("condense transcript: %w", err)
+		}
+		log.WithField("chunks", n).Info("using condensed transcript for block prompts")
+		transcript = digest
+	}
+
+	transcript = transcriptpkg.Numbered(transcript)
+	sem := make(chan struct{}, maxParallel())
+
+	type outcome struct {
+		block extractionBlock
+		ext   types.KPIExtraction
+		tries int
+		err   error
+	}
+	results := make([]outcome, len(selected))
+	var wg sync.WaitGroup
+	for i, b := range selected {
+		wg.Add(1)
+		go func(i int, b extractionBlock) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			asked := BuildBlockPrompt(b, transcript, searchResults)
+			prompt := asked
+			out := outcome{block: b}
+			for out.tries < blockAttempts {
+				out.tries++
+				var ext types.KPIExtraction
+				if out.err = llm.CompleteJSON(ctx, prompt, &ext); out.err != nil {
+					break
+				}
+				fixed := clampExtraction(&ext)
+				if len(fixed) > 0 && out.tries < blockAttempts {
+					prompt = rangeRetryPrompt(asked, b, fixed)
+					continue
+				}
+				if len(fixed) > 0 {
+					log.WithField("block", b.Key).WithField("clamped", fixed).Warn("block output out of range; values clamped")
+				}
+				out.ext = ext
+				break
+			}
+			results[i] = out
+		}(i, b)
+	}
+	wg.Wait()
+
+	var firstErr error
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			statuses[r.block.Key] = types.BlockStatus{Status: types.StageFailed, Error: r.err.Error(), Attempts: r.tries}
+			log.WithError(r.err).WithField("block", r.block.Key).Warn("block extraction failed")
+			continue
+		}
+		r.block.Merge(&base, r.ext)
+		if r.block.Grounded {
+			base.Grounding = append(dropGrounding(base.Grounding, r.block.Key), r.ext.Grounding...)
+		}
+		statuses[r.block.Key] = types.BlockStatus{Status: types.StageOK, Attempts: r.tries}
+	}
+
+	log.WithField("blocks", len(selected)).WithField("failed", failed).Info("multi-pass extraction finished")
+	if len(selected) > 0 && failed == len(selected) {
+		return base, statuses, fmt.Errorf("all %d blocks failed: %w", failed, firstErr)
+	}
+	return base, statuses, nil
+}
+
+// dropGrounding removes entries belonging to block so a re-extracted block replaces its quotes.
+func dropGrounding(g []types.GroundedFinding, block string) []types.GroundedFinding {
+	out := g[:0:0]
+	for _, f := range g {
+		if !strings.HasPrefix(f.Field, block+".") {
+			out = append(out, f)
+		}
+	}
+	return out
+}
@@ -0,0 +1,111 @@
+package extractor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"voice-insights-go/internal/types"
+)
+
+var blockRe = regexp.MustCompile(`fill in ONLY the\s+"([a-z_]+)" block`)
+
+// fakeGateway answers block prompts from answers, one per call of each block (the last
+// repeats), and records the prompts it got. A block without answers gets a 400.
+type fakeGateway struct {
+	mu      sync.Mutex
+	answers map[string][]string
+	prompts map[string][]string
+}
+
+func (f *fakeGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Messages []struct {
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	body, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(body, &req)
+	prompt := req.Messages[0].Content
+	m := blockRe.FindStringSubmatch(prompt)
+	if m == nil {
+		http.Error(w, "not a block prompt", http.StatusBadRequest)
+		return
+	}
+	f.mu.Lock()
+	n := len(f.prompts[m[1]])
+	f.prompts[m[1]] = append(f.prompts[m[1]], prompt)
+	answers := f.answers[m[1]]
+	f.mu.Unlock()
+	if len(answers) == 0 {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	answer := answers[min(n, len(answers)-1)]
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{"message": map[string]string{"content": answer}}},
+	})
+}
+
+func TestExtractMultiPass(t *testing.T) {
+	gw := &fakeGateway{prompts: map[string][]string{}, answers: map[string][]string{
+		"kpi": {
+			`{"kpi": {"frustration_score": 1.7, "empathy_score": 0.4}}`,
+			`{"kpi": {"frustration_score": 0.7, "empathy_score": 0.4}}`,
+		},
+		"business_impact": {`{"business_impact": {"risk_of_churn": 2}}`},
+	}}
+	srv := httptest.NewServer(gw)
+	defer srv.Close()
+	t.Setenv("LLM_GATEWAY_URL", srv.URL)
+	t.Setenv("LLM_API_KEY", "test")
+	t.Setenv("USE_MOCK_LLM", "")
+
+	var base types.KPIExtraction
+	base.Actions.Priority = "high"
+	transcript := "Agent: Namaste, how can I help?\nCustomer: My refund has not come."
+	got, statuses, err := ExtractMultiPass(context.Background(), transcript, []any{}, base, []string{"kpi", "business_impact", "actions"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]types.BlockStatus{
+		"kpi":             {Status: types.StageOK, Attempts: 2},
+		"business_impact": {Status: types.StageOK, Attempts: 2},
+		"actions":         {Status: types.StageFailed, Attempts: 1},
+	}
+	for key, w := range want {
+		if s := statuses[key]; s.Status != w.Status || s.Attempts != w.Attempts {
+			t.Errorf("%s status = %+v, want %s after %d attempts", key, s, w.Status, w.Attempts)
+		}
+	}
+	if len(statuses) != len(want) || len(gw.prompts["customer_problem"]) != 0 {
+		t.Errorf("statuses %v: blocks outside only were run", statuses)
+	}
+
+	if got.KPI.FrustrationScore != 0.7 || got.BusinessImpact.RiskOfChurn != 1 || got.Actions.Priority != "high" {
+		t.Errorf("merged frustration %v, churn %v, priority %q; want the retried value, the clamped value and the base kept for the failed block",
+			got.KPI.FrustrationScore, got.BusinessImpact.RiskOfChurn, got.Actions.Priority)
+	}
+
+	kpi := gw.prompts["kpi"]
+	if len(kpi) != 2 {
+		t.Fatalf("kpi block asked %d times, want 2", len(kpi))
+	}
+	if strings.Contains(kpi[0], "WAS REJECTED") {
+		t.Error("first prompt already carries a rejection")
+	}
+	retry := kpi[1][len(kpi[0]):]
+	if !strings.HasPrefix(kpi[1], kpi[0]) || !strings.Contains(retry, "kpi.frustration_score=1.7") || !strings.Contains(retry, "frustration_score, confusion_level") {
+		t.Errorf("retry prompt should repeat the first and add the rejected value and its range, got addition:\n%s", retry)
+	}
+	if strings.Contains(retry, "empathy_score=") {
+		t.Errorf("retry names a value that was in range:\n%s", retry)
+	}
+}
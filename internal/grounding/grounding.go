@@ -0,0 +1,218 @@
+package grounding
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"voice-insights-go/internal/transcript"
+	"voice-insights-go/internal/types"
+)
+
+// Modes for handling list items whose quotes cannot be found in the transcript.
+const (
+	ModeFlag = "flag" // keep the item, mark it unverified
+	ModeDrop = "drop" // remove the item from the extraction
+)
+
+// Options configure verification.
+type Options struct {
+	Mode     string
+	MinScore float64 // fraction of quote tokens that must match a transcript span
+}
+
+// OptionsFromEnv reads GROUNDING_MODE (flag|drop, default flag) and GROUNDING_MIN_SCORE (default 0.8).
+func OptionsFromEnv() Options {
+	o := Options{Mode: ModeFlag, MinScore: 0.8}
+	if os.Getenv("GROUNDING_MODE") == ModeDrop {
+		o.Mode = ModeDrop
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("GROUNDING_MIN_SCORE"), 64); err == nil && v > 0 && v <= 1 {
+		o.MinScore = v
+	}
+	return o
+}
+
+// Report summarizes a verification run; it is attached to KPIResult.Evidence.
+type Report struct {
+	Mode       string   `json:"mode"`
+	Items      int      `json:"items"`
+	Verified   int      `json:"verified"`
+	Unverified []string `json:"unverified"`
+	Dropped    []string `json:"dropped"`
+}
+
+type listField struct {
+	Path string
+	List *[]string
+}
+
+// listFields returns the extraction lists that must be grounded, in schema order.
+func listFields(x *types.KPIExtraction) []listField {
+	return []listField{
+		{"agent_analysis.steps_explained_by_agent", &x.AgentAnalysis.StepsExplainedByAgent},
+		{"agent_analysis.missed_opportunities", &x.AgentAnalysis.MissedOpportunities},
+		{"agent_analysis.compliance_flags", &x.AgentAnalysis.ComplianceFlags},
+		{"should_have_done.crucial_missed_questions", &x.ShouldHaveDone.CrucialMissedQuestions},
+		{"should_have_done.required_data_points_not_collected", &x.ShouldHaveDone.RequiredDataPointsNotCollected},
+		{"actions.executive_actions_required", &x.Actions.ExecutiveActionsRequired},
+		{"actions.customer_actions_required", &x.Actions.CustomerActionsRequired},
+		{"actions.system_actions_required", &x.Actions.SystemActionsRequired},
+		{"conversation_quality.red_flags", &x.ConversationQuality.RedFlags},
+	}
+}
+
+// Verify checks every quote the model cited against the transcript and rebuilds
+// x.Grounding so it has exactly one entry per list item. Quotes are matched by text, not
+// by the turn number the model claimed; a matched quote's Turn is corrected to where it
+// was actually found. Items with no verified quote are flagged or dropped per opts.Mode.
+func Verify(x *types.KPIExtraction, transcriptText string, opts Options) Report {
+	turns := transcript.Parse(transcriptText)
+	index := make([][]string, len(turns))
+	for i, t := range turns {
+		index[i] = tokens(t.Text)
+	}
+
+	// group the model's grounding entries by field + normalized item
+	cited := map[string][]types.Quote{}
+	for _, g := range x.Grounding {
+		key := g.Field + "|" + normalize(g.Item)
+		cited[key] = append(cited[key], g.Quotes...)
+	}
+
+	rep := Report{Mode: opts.Mode, Unverified: []string{}, Dropped: []string{}}
+	var grounded []types.GroundedFinding
+	for _, lf := range listFields(x) {
+		field, list := lf.Path, lf.List
+		kept := (*list)[:0:0]
+		for _, item := range *list {
+			rep.Items++
+			f := types.GroundedFinding{Field: field, Item: item, Quotes: []types.Quote{}}
+			for _, q := range cited[field+"|"+normalize(item)] {
+				turn, score := bestMatch(tokens(q.Text), index, q.Turn)
+				q.Score = score
+				q.Verified = score >= opts.MinScore
+				if q.Verified {
+					q.Turn = turn
+					f.Verified = true
+				}
+				f.Quotes = append(f.Quotes, q)
+			}
+			if f.Verified {
+				rep.Verified++
+			} else {
+				rep.Unverified = append(rep.Unverified, field+": "+item)
+				if opts.Mode == ModeDrop {
+					rep.Dropped = append(rep.Dropped, field+": "+item)
+					continue
+				}
+			}
+			kept = append(kept, item)
+			grounded = append(grounded, f)
+		}
+		*list = kept
+	}
+	if grounded == nil {
+		grounded = []types.GroundedFinding{}
+	}
+	x.Grounding = grounded
+	return rep
+}
+
+// bestMatch returns the turn that best contains the quote, and the fraction of quote tokens
+// matched in order. Single turns are tried before a turn joined with the next one, since
+// quotes often straddle a turn boundary but one found whole belongs to its own turn. The
+// claimed turn is tried first so ties resolve in the model's favour.
+func bestMatch(q []string, turns [][]string, claimed int) (int, float64) {
+	if len(q) == 0 || len(turns) == 0 {
+		return claimed, 0
+	}
+	bestTurn, best := claimed, -1.0
+	try := func(i int, joined bool) {
+		span := turns[i]
+		if joined {
+			if i+1 >= len(turns) {
+				return
+			}
+			span = append(append([]string{}, span...), turns[i+1]...)
+		}
+		if s := float64(lcs(q, span)) / float64(len(q)); s > best {
+			bestTurn, best = i, s
+		}
+	}
+	for _, joined := range []bool{false, true} {
+		if claimed >= 0 && claimed < len(turns) {
+			try(claimed, joined)
+		}
+		for i := range turns {
+			if best >= 1 {
+				return bestTurn, best
+			}
+			try(i, joined)
+		}
+	}
+	return bestTurn, best
+}
+
+// lcs is the longest common token subsequence, treating near-identical tokens as equal so
+// transcription spelling drift ("kardijiye" vs "kardijie") does not break a match.
+func lcs(a, b []string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			switch {
+			case similar(a[i-1], b[j-1]):
+				cur[j] = prev[j-1] + 1
+			case prev[j] >= cur[j-1]:
+				cur[j] = prev[j]
+			default:
+				cur[j] = cur[j-1]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < 4 || len(rb) < 4 {
+		return false
+	}
+	return editDistance(ra, rb) <= 1
+}
+
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func tokens(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && !unicode.Is(unicode.Mn, r) && !unicode.Is(unicode.Mc, r)
+	})
+}
+
+func normalize(s string) string {
+	return strings.Join(tokens(s), " ")
+}
@@ -0,0 +1,131 @@
+package grounding
+
+import (
+	"reflect"
+	"testing"
+
+	"voice-insights-go/internal/types"
+)
+
+const transcriptText = `Agent: Namaste, how can I help you today?
+Customer: My refund for order 4411 has not come for two weeks.
+Agent: I will raise a ticket with the payments team.
+Customer: Please do it fast, aap jaldi kardijiye.
+Agent: Sure, anything else?`
+
+func TestBestMatch(t *testing.T) {
+	var index [][]string
+	for _, line := range []string{
+		"namaste how can i help you today",
+		"my refund for order 4411 has not come for two weeks",
+		"i will raise a ticket with the payments team",
+		"please do it fast aap jaldi kardijiye",
+	} {
+		index = append(index, tokens(line))
+	}
+	tests := []struct {
+		name    string
+		quote   string
+		claimed int
+		turn    int
+		score   float64
+	}{
+		{"exact, right turn", "raise a ticket with the payments team", 2, 2, 1},
+		{"wrong turn claimed is corrected", "refund for order 4411", 0, 1, 1},
+		{"spelling drift", "aap jaldi kardijie", 3, 3, 1},
+		{"straddles two turns", "payments team please do it fast", 2, 2, 1},
+		{"half invented", "refund was already processed yesterday", 1, 1, 1.0 / 5},
+		{"empty quote", "", 2, 2, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			turn, score := bestMatch(tokens(tt.quote), index, tt.claimed)
+			if turn != tt.turn || score != tt.score {
+				t.Errorf("bestMatch = turn %d score %v, want turn %d score %v", turn, score, tt.turn, tt.score)
+			}
+		})
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"kardijiye", "kardijie", true},
+		{"refund", "refunds", true},
+		{"cat", "cut", false}, // short words must match exactly
+		{"refund", "return", false},
+	}
+	for _, tt := range tests {
+		if got := similar(tt.a, tt.b); got != tt.want {
+			t.Errorf("similar(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// extraction has one grounded, one invented and one uncited item.
+func extraction() *types.KPIExtraction {
+	x := &types.KPIExtraction{}
+	x.Actions.ExecutiveActionsRequired = []string{"Raise a payments ticket", "Refund order 4411 today"}
+	x.ConversationQuality.RedFlags = []string{"Refund delayed"}
+	x.Grounding = []types.GroundedFinding{
+		{Field: "actions.executive_actions_required", Item: "raise a payments ticket!", Quotes: []types.Quote{
+			{Turn: 0, Text: "I will raise a ticket with the payments team"},
+		}},
+		{Field: "actions.executive_actions_required", Item: "Refund order 4411 today", Quotes: []types.Quote{
+			{Turn: 1, Text: "I have refunded your order just now"},
+		}},
+	}
+	return x
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		mode      string
+		executive []string
+		redFlags  []string
+		findings  int
+	}{
+		{ModeFlag, []string{"Raise a payments ticket", "Refund order 4411 today"}, []string{"Refund delayed"}, 3},
+		{ModeDrop, []string{"Raise a payments ticket"}, []string{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			x := extraction()
+			rep := Verify(x, transcriptText, Options{Mode: tt.mode, MinScore: 0.8})
+			if rep.Items != 3 || rep.Verified != 1 || len(rep.Unverified) != 2 {
+				t.Errorf("report = %+v, want 3 items with 1 verified", rep)
+			}
+			if wantDropped := 3 - tt.findings; len(rep.Dropped) != wantDropped {
+				t.Errorf("dropped = %v, want %d", rep.Dropped, wantDropped)
+			}
+			if !reflect.DeepEqual(x.Actions.ExecutiveActionsRequired, tt.executive) || !reflect.DeepEqual(x.ConversationQuality.RedFlags, tt.redFlags) {
+				t.Errorf("lists = %q and %q, want %q and %q", x.Actions.ExecutiveActionsRequired, x.ConversationQuality.RedFlags, tt.executive, tt.redFlags)
+			}
+			if len(x.Grounding) != tt.findings {
+				t.Fatalf("grounding has %d findings, want one per kept item (%d)", len(x.Grounding), tt.findings)
+			}
+			g := x.Grounding[0]
+			if !g.Verified || g.Quotes[0].Turn != 2 || g.Quotes[0].Score != 1 {
+				t.Errorf("grounded finding = %+v, want its quote moved to turn 2", g)
+			}
+			if tt.mode == ModeFlag {
+				if invented := x.Grounding[1]; invented.Verified || invented.Quotes[0].Verified || invented.Quotes[0].Turn != 1 {
+					t.Errorf("invented finding = %+v, want unverified at the claimed turn", invented)
+				}
+				if uncited := x.Grounding[2]; uncited.Verified || len(uncited.Quotes) != 0 {
+					t.Errorf("uncited finding = %+v", uncited)
+				}
+			}
+		})
+	}
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	t.Setenv("GROUNDING_MODE", "drop")
+	t.Setenv("GROUNDING_MIN_SCORE", "1.5")
+	if o := OptionsFromEnv(); o.Mode != ModeDrop || o.MinScore != 0.8 {
+		t.Errorf("options = %+v, want drop with the default score for an out-of-range value", o)
+	}
+}
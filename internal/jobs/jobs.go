@@ -0,0 +1,209 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/types"
+)
+
+const (
+	collection = "jobs"
+	// callIndex maps an external call id to the newest job processing that call
+	callIndex = "job_call_ids"
+)
+
+// ErrNotFound is returned when no job exists for the id.
+var ErrNotFound = errors.New("job not found")
+
+type Status string
+
+const (
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusPartial   Status = "partial"
+)
+
+// Artifacts are the intermediate outputs a retry resumes from.
+type Artifacts struct {
+	Transcript    string          `json:"transcript,omitempty"`
+	SearchResults json.RawMessage `json:"search_results,omitempty"`
+}
+
+// Job is one processed (or in-flight) call with per-stage progress.
+type Job struct {
+	ID        string `json:"id"`
+	AudioURL  string `json:"audio_url"`
+	K         int    `json:"k"`
+	Mode      string `json:"mode"`
+	AgentID   string `json:"agent_id,omitempty"`
+	Team      string `json:"team,omitempty"`
+	DatasetID string `json:"dataset_id,omitempty"`
+	// City, CallType and VintageMonths place the call in cohorts (see internal/cohort)
+	City          string `json:"city,omitempty"`
+	CallType      string `json:"call_type,omitempty"`
+	VintageMonths *int   `json:"vintage_months,omitempty"`
+	// CustomerID is the hashed caller id linking the call to others (see internal/customer)
+	CustomerID string `json:"customer_id,omitempty"`
+	// CallID is the caller's own id for the call (telephony or CRM), so outside systems can
+	// address it; reprocessing the same call keeps it
+	CallID string `json:"call_id,omitempty"`
+	// CallTime is when the call happened, when the caller knows it
+	CallTime  time.Time                         `json:"call_time,omitzero"`
+	Status    Status                            `json:"status"`
+	Stages    map[types.Stage]types.StageStatus `json:"stages"`
+	Artifacts Artifacts                         `json:"artifacts"`
+	Result    types.KPIResult                   `json:"result"`
+	Attempts  int                               `json:"attempts"`
+	CreatedAt time.Time                         `json:"created_at"`
+	UpdatedAt time.Time                         `json:"updated_at"`
+}
+
+// New creates a pending job for audioURL; mode selects single or multi-pass extraction.
+func New(audioURL string, k int, mode string) *Job {
+	now := time.Now().UTC()
+	stages := map[types.Stage]types.StageStatus{}
+	for _, s := range types.Stages {
+		stages[s] = types.StagePending
+	}
+	return &Job{
+		ID:        uuid.New().String(),
+		AudioURL:  audioURL,
+		K:         k,
+		Mode:      mode,
+		Status:    StatusRunning,
+		Stages:    stages,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// At is when the call happened: CallTime when known, else when the job was created.
+func (j *Job) At() time.Time {
+	if !j.CallTime.IsZero() {
+		return j.CallTime
+	}
+	return j.CreatedAt
+}
+
+// Done reports whether stage already completed in an earlier attempt.
+func (j *Job) Done(stage types.Stage) bool {
+	return j.Stages[stage] == types.StageOK
+}
+
+// Mark sets the status of stage.
+func (j *Job) Mark(stage types.Stage, status types.StageStatus) {
+	j.Stages[stage] = status
+}
+
+// Save persists the job in the default store.
+func Save(j *Job) error {
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	j.UpdatedAt = time.Now().UTC()
+	if err := st.Put(collection, j.ID, j); err != nil {
+		return err
+	}
+	if j.CallID == "" {
+		return nil
+	}
+	// the index follows the newest job of the call, so an old job's retry does not take it back
+	var idx callRef
+	if err := st.Get(callIndex, j.CallID, &idx); err == nil && idx.JobID != j.ID && idx.CreatedAt.After(j.CreatedAt) {
+		return nil
+	} else if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("load call index: %w", err)
+	}
+	return st.Put(callIndex, j.CallID, callRef{JobID: j.ID, CreatedAt: j.CreatedAt})
+}
+
+// callRef is an entry of the call id index.
+type callRef struct {
+	JobID     string    `json:"job_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ByCallID loads the newest job of an external call id.
+func ByCallID(callID string) (*Job, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var idx callRef
+	if err := st.Get(callIndex, callID, &idx); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("load call index: %w", err)
+	}
+	return Get(idx.JobID)
+}
+
+// Get loads a job by id.
+func Get(id string) (*Job, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var j Job
+	if err := st.Get(collection, id, &j); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("load job %s: %w", id, err)
+	}
+	return &j, nil
+}
+
+// List returns every stored job.
+func List() ([]*Job, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var out []*Job
+	err = st.List(collection, func(id string, raw json.RawMessage) error {
+		var j Job
+		if err := json.Unmarshal(raw, &j); err != nil {
+			return fmt.Errorf("decode job %s: %w", id, err)
+		}
+		out = append(out, &j)
+		return nil
+	})
+	return out, err
+}
+
+// briefJob decodes a stored job without its artifacts, which hold most of its size.
+type briefJob struct {
+	*Job
+	Artifacts struct{} `json:"artifacts"`
+}
+
+// ListWhere returns the jobs keep accepts, without their artifacts or transcript, so
+// aggregations over many calls do not hold every transcript in memory.
+func ListWhere(keep func(*Job) bool) ([]*Job, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var out []*Job
+	err = st.List(collection, func(id string, raw json.RawMessage) error {
+		b := briefJob{Job: &Job{}}
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return fmt.Errorf("decode job %s: %w", id, err)
+		}
+		b.Job.Result.Transcript = ""
+		if keep(b.Job) {
+			out = append(out, b.Job)
+		}
+		return nil
+	})
+	return out, err
+}
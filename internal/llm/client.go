@@ -0,0 +1,186 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/types"
+)
+
+var (
+	httpTimeout  = 60 * time.Second
+	maxRetryTime = 60 * time.Second
+)
+
+// Configured reports whether LLM_GATEWAY_URL and LLM_API_KEY are set.
+func Configured() bool {
+	return os.Getenv("LLM_GATEWAY_URL") != "" && os.Getenv("LLM_API_KEY") != ""
+}
+
+// CompleteJSON sends prompt to the OpenAI-compatible gateway and decodes the first JSON
+// object of the reply into target. Network errors, 5xx/429 responses and unparseable output
+// are retried with exponential backoff; other 4xx responses fail immediately.
+func CompleteJSON(ctx context.Context, prompt string, target any) error {
+	var (
+		llmGatewayURL   = os.Getenv("LLM_GATEWAY_URL")
+		llmGatewayModel = os.Getenv("LLM_MODEL")
+		llmAPIKey       = os.Getenv("LLM_API_KEY")
+	)
+
+	log := logger.New().WithField("component", "llm-client")
+
+	if llmGatewayURL == "" || llmAPIKey == "" {
+		return apperr.New(apperr.Internal, types.StageLLM, "llm gateway not configured")
+	}
+	reqBody := map[string]any{
+		"model": llmGatewayModel,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"temperature": 0.0,
+	}
+	data, _ := json.MarshalIndent(reqBody, "", "  ")
+	log.WithField("payload_len", len(data)).Debug("LLM request payload")
+
+	var lastErr error
+
+	// LLM call with retry/backoff
+	op := func() error {
+		reqCtx, cancel := context.WithTimeout(ctx, httpTimeout)
+		defer cancel()
+
+		req, _ := http.NewRequestWithContext(reqCtx, "POST", llmGatewayURL, bytes.NewReader(data))
+		req.Header.Set("Authorization", "Bearer "+llmAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{Timeout: httpTimeout}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = apperr.FromTransport(types.StageLLM, err)
+			log.WithError(err).Warn("llm request failed")
+			if ctx.Err() != nil {
+				return backoff.Permanent(lastErr)
+			}
+			return lastErr
+		}
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		log.WithField("http_status", resp.StatusCode).Debug("llm raw:\n" + string(body))
+
+		if resp.StatusCode >= 400 {
+			lastErr = apperr.FromHTTPStatus(types.StageLLM, resp.StatusCode, string(body))
+			if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+				// Permanent: don't retry on client errors
+				return backoff.Permanent(lastErr)
+			}
+			return lastErr
+		}
+
+		// Try choices[0].message.content (OpenAI-like)
+		if inner := extractContentFromChoices(body); inner != "" {
+			log.Debug("extracted JSON from choices content")
+			if err := json.Unmarshal([]byte(inner), target); err == nil {
+				lastErr = nil
+				return nil
+			}
+			log.WithError(err).Warn("unmarshal from choices content failed")
+		}
+
+		// Fallback: find first balanced JSON in response body
+		if fallback := extractJSON(string(body)); fallback != "" {
+			if err := json.Unmarshal([]byte(fallback), target); err == nil {
+				lastErr = nil
+				return nil
+			}
+			log.WithError(err).Warn("unmarshal from fallback JSON failed")
+		}
+
+		// Couldn't parse; log and return temporary error to retry
+		lastErr = apperr.New(apperr.ParseFailure, types.StageLLM, "no JSON found in LLM output")
+		return lastErr
+	}
+
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = maxRetryTime
+
+	if err := backoff.Retry(op, backoff.WithContext(b, ctx)); err != nil {
+		if ctx.Err() != nil {
+			return apperr.FromTransport(types.StageLLM, ctx.Err())
+		}
+		if lastErr == nil {
+			return err
+		}
+		return lastErr
+	}
+	return nil
+}
+
+// extractContentFromChoices attempts to read openai-style choices[0].message.content JSON
+func extractContentFromChoices(body []byte) string {
+	var obj map[string]any
+	if err := json.Unmarshal(body, &obj); err != nil {
+		return ""
+	}
+
+	choices, ok := obj["choices"].([]any)
+	if !ok || len(choices) == 0 {
+		return ""
+	}
+	c0, _ := choices[0].(map[string]any)
+	if c0 == nil {
+		return ""
+	}
+	msg, _ := c0["message"].(map[string]any)
+	if msg == nil {
+		return ""
+	}
+	content, _ := msg["content"].(string)
+	return extractJSON(content)
+}
+
+// extractJSON finds the first balanced JSON object in a string and returns it.
+// It strips common markdown fences first.
+func extractJSON(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	// normalize newlines
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+
+	// Remove markdown fences (commonly output by LLMs)
+	for _, r := range []string{"```json", "```yaml", "```text", "```", "`json", "`"} {
+		s = strings.ReplaceAll(s, r, "")
+	}
+
+	start := strings.Index(s, "{")
+	if start == -1 {
+		return ""
+	}
+
+	depth := 0
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return strings.TrimSpace(s[start : i+1])
+			}
+		}
+	}
+
+	// no balanced found
+	return ""
+}
@@ -0,0 +1,268 @@
+package notify
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"voice-insights-go/internal/logger"
+)
+
+//go:embed notify.json
+var defaultConfig []byte
+
+// Sink types.
+const (
+	TypeWebhook = "webhook" // JSON event POSTed with an HMAC-SHA256 signature
+	TypeSlack   = "slack"   // Slack-compatible incoming webhook
+	TypeEmail   = "email"   // plain-text mail over SMTP
+)
+
+// Sink is one notification destination. String fields may reference environment variables
+// as ${NAME}, so secrets stay out of the config file; a sink whose required fields expand
+// to nothing is disabled.
+type Sink struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	// URL and Secret are for webhook and slack sinks; Secret signs webhook bodies
+	URL    string `json:"url,omitempty"`
+	Secret string `json:"secret,omitempty"`
+	// SMTPAddr (host:port), credentials and addresses are for email sinks. Each To entry may
+	// hold several comma-separated addresses.
+	SMTPAddr string   `json:"smtp_addr,omitempty"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from,omitempty"`
+	To       []string `json:"to,omitempty"`
+}
+
+// Enabled reports whether the sink has what it needs to deliver.
+func (s Sink) Enabled() bool {
+	switch s.Type {
+	case TypeWebhook, TypeSlack:
+		return s.URL != ""
+	case TypeEmail:
+		return s.SMTPAddr != "" && s.From != "" && len(s.To) > 0
+	}
+	return false
+}
+
+// Rule sends an event to its sinks when every condition of When holds. When is a list of
+// "field op value" conditions joined by "&&", e.g. "requires_escalation == true" or
+// "risk_of_churn > 0.7 && city == mumbai".
+type Rule struct {
+	ID    string   `json:"id"`
+	When  string   `json:"when"`
+	Sinks []string `json:"sinks"`
+
+	conds []condition
+}
+
+// Config is the sink and rule configuration.
+type Config struct {
+	Sinks []Sink `json:"sinks"`
+	Rules []Rule `json:"rules"`
+
+	sinks map[string]Sink
+}
+
+// Sink returns the sink with id.
+func (c *Config) Sink(id string) (Sink, bool) {
+	s, ok := c.sinks[id]
+	return s, ok
+}
+
+// Parse decodes, expands and validates a configuration.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("decode notification config: %w", err)
+	}
+	c.sinks = map[string]Sink{}
+	for i, s := range c.Sinks {
+		s.URL, s.Secret = os.ExpandEnv(s.URL), os.ExpandEnv(s.Secret)
+		s.SMTPAddr, s.Username, s.Password, s.From = os.ExpandEnv(s.SMTPAddr), os.ExpandEnv(s.Username), os.ExpandEnv(s.Password), os.ExpandEnv(s.From)
+		var to []string
+		for _, t := range s.To {
+			for _, addr := range strings.Split(os.ExpandEnv(t), ",") {
+				if addr = strings.TrimSpace(addr); addr != "" {
+					to = append(to, addr)
+				}
+			}
+		}
+		s.To = to
+		switch {
+		case s.ID == "":
+			return nil, fmt.Errorf("sink %d: id is required", i)
+		case s.Type != TypeWebhook && s.Type != TypeSlack && s.Type != TypeEmail:
+			return nil, fmt.Errorf("sink %s: unknown type %q", s.ID, s.Type)
+		}
+		if _, dup := c.sinks[s.ID]; dup {
+			return nil, fmt.Errorf("duplicate sink id %q", s.ID)
+		}
+		c.Sinks[i] = s
+		c.sinks[s.ID] = s
+	}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %d: id is required", i)
+		}
+		conds, err := parseWhen(r.When)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+		r.conds = conds
+		for _, id := range r.Sinks {
+			if _, ok := c.sinks[id]; !ok {
+				return nil, fmt.Errorf("rule %s: unknown sink %q", r.ID, id)
+			}
+		}
+	}
+	return &c, nil
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Config
+	loadErr  error
+)
+
+// Load returns the configuration from NOTIFY_CONFIG_PATH, or the embedded default whose
+// sinks are configured through NOTIFY_* environment variables.
+func Load() (*Config, error) {
+	loadOnce.Do(func() {
+		log := logger.New().WithField("component", "notify")
+		data := defaultConfig
+		if path := os.Getenv("NOTIFY_CONFIG_PATH"); path != "" {
+			data, loadErr = os.ReadFile(path)
+			if loadErr != nil {
+				loadErr = fmt.Errorf("read notification config: %w", loadErr)
+				return
+			}
+		}
+		loaded, loadErr = Parse(data)
+		if loadErr == nil {
+			enabled := 0
+			for _, s := range loaded.Sinks {
+				if s.Enabled() {
+					enabled++
+				}
+			}
+			log.WithField("rules", len(loaded.Rules)).WithField("enabled_sinks", enabled).Info("notification config loaded")
+		}
+	})
+	return loaded, loadErr
+}
+
+// field kinds of the values rules can test.
+const (
+	kindBool = iota
+	kindNumber
+	kindString
+)
+
+var fieldKinds = map[string]int{
+	"requires_escalation":   kindBool,
+	"repeat_issue":          kindBool,
+	"confused":              kindBool,
+	"risk_of_churn":         kindNumber,
+	"frustration":           kindNumber,
+	"confusion":             kindNumber,
+	"resolution_likelihood": kindNumber,
+	"severity":              kindNumber,
+	"priority":              kindString,
+	"urgency_level":         kindString,
+	"issue":                 kindString,
+	"city":                  kindString,
+	"team":                  kindString,
+	"agent_id":              kindString,
+}
+
+type condition struct {
+	field string
+	op    string
+	b     bool
+	n     float64
+	s     string
+}
+
+var condRe = regexp.MustCompile(`^([a-z_]+)\s*(==|!=|>=|<=|>|<)\s*(.+)$`)
+
+func parseWhen(when string) ([]condition, error) {
+	var out []condition
+	for _, part := range strings.Split(when, "&&") {
+		part = strings.TrimSpace(part)
+		m := condRe.FindStringSubmatch(part)
+		if m == nil {
+			return nil, fmt.Errorf("condition %q: want \"field op value\"", part)
+		}
+		c := condition{field: m[1], op: m[2]}
+		kind, ok := fieldKinds[c.field]
+		if !ok {
+			return nil, fmt.Errorf("condition %q: unknown field %q", part, c.field)
+		}
+		val := strings.Trim(strings.TrimSpace(m[3]), `"'`)
+		ordered := c.op != "==" && c.op != "!="
+		var err error
+		switch kind {
+		case kindBool:
+			c.b, err = strconv.ParseBool(val)
+		case kindNumber:
+			c.n, err = strconv.ParseFloat(val, 64)
+		case kindString:
+			c.s = strings.ToLower(val)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("condition %q: bad value: %w", part, err)
+		}
+		if ordered && kind != kindNumber {
+			return nil, fmt.Errorf("condition %q: %s only applies to numbers", part, c.op)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// matches reports whether e satisfies every condition of r.
+func (r Rule) matches(e Event) bool {
+	for _, c := range r.conds {
+		if !c.eval(e) {
+			return false
+		}
+	}
+	return len(r.conds) > 0
+}
+
+func (c condition) eval(e Event) bool {
+	switch fieldKinds[c.field] {
+	case kindBool:
+		v := e.boolField(c.field)
+		return (c.op == "==") == (v == c.b)
+	case kindNumber:
+		v := e.numberField(c.field)
+		switch c.op {
+		case "==":
+			return v == c.n
+		case "!=":
+			return v != c.n
+		case ">":
+			return v > c.n
+		case ">=":
+			return v >= c.n
+		case "<":
+			return v < c.n
+		case "<=":
+			return v <= c.n
+		}
+	case kindString:
+		v := strings.ToLower(e.stringField(c.field))
+		return (c.op == "==") == (v == c.s)
+	}
+	return false
+}
@@ -0,0 +1,414 @@
+// Package notify delivers escalations and other rule-matched call events to webhooks, Slack
+// and email, with retries, deduplication per job, rule and sink, and a persisted delivery log.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+)
+
+const collection = "notify_deliveries"
+
+// Delivery statuses.
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Event is what a sink receives about one call. Free text is redacted before it leaves the
+// service; RiskOfChurn is the risk to act on, blended with the churn model when one is
+// trained.
+type Event struct {
+	ID                 string    `json:"id"`
+	Rule               string    `json:"rule"`
+	JobID              string    `json:"job_id"`
+	AudioURL           string    `json:"audio_url"`
+	CallTime           time.Time `json:"call_time"`
+	AgentID            string    `json:"agent_id,omitempty"`
+	Team               string    `json:"team,omitempty"`
+	City               string    `json:"city,omitempty"`
+	Issue              string    `json:"issue"`
+	PrimaryIssue       string    `json:"primary_issue"`
+	Priority           string    `json:"priority"`
+	UrgencyLevel       string    `json:"urgency_level"`
+	Severity           int       `json:"severity"`
+	RequiresEscalation bool      `json:"requires_escalation"`
+	EscalationReason   string    `json:"escalation_reason"`
+	RepeatIssue        bool      `json:"repeat_issue"`
+	Confused           bool      `json:"confused"`
+	RiskOfChurn        float64   `json:"risk_of_churn"`
+	Frustration        float64   `json:"frustration"`
+	Confusion          float64   `json:"confusion"`
+	ResolutionLikely   float64   `json:"resolution_likelihood"`
+}
+
+// EventFromJob builds the event of a processed job; Rule and ID are set per matched rule.
+func EventFromJob(j *jobs.Job) Event {
+	x := j.Result.KPI
+	e := Event{
+		JobID:              j.ID,
+		AudioURL:           j.AudioURL,
+		CallTime:           j.At(),
+		AgentID:            j.AgentID,
+		Team:               j.Team,
+		City:               j.City,
+		Issue:              taxonomy.OtherID,
+		PrimaryIssue:       redactText(x.CustomerProblem.PrimaryIssue),
+		Priority:           x.Actions.Priority,
+		UrgencyLevel:       x.CustomerProblem.UrgencyLevel,
+		Severity:           x.CustomerProblem.Severity,
+		RequiresEscalation: x.Actions.RequiresEscalation,
+		EscalationReason:   redactText(x.Actions.EscalationReason),
+		RepeatIssue:        x.CustomerProblem.RepeatIssue,
+		RiskOfChurn:        j.Result.ChurnRisk(),
+		Frustration:        x.KPI.FrustrationScore,
+		Confusion:          x.KPI.ConfusionLevel,
+		ResolutionLikely:   x.KPI.ResolutionLikelihood,
+	}
+	if is := j.Result.Issues; is != nil && is.PrimaryIssue.NodeID != "" {
+		e.Issue = is.PrimaryIssue.NodeID
+	}
+	if cls := j.Result.Classification; cls != nil {
+		e.Confused = cls.Confused
+	}
+	return e
+}
+
+// redactText masks PII in text bound for a sink; if redaction fails the text is dropped.
+func redactText(s string) string {
+	red, _, err := redact.Text(s)
+	if err != nil {
+		return ""
+	}
+	return red
+}
+
+func (e Event) boolField(name string) bool {
+	switch name {
+	case "requires_escalation":
+		return e.RequiresEscalation
+	case "repeat_issue":
+		return e.RepeatIssue
+	case "confused":
+		return e.Confused
+	}
+	return false
+}
+
+func (e Event) numberField(name string) float64 {
+	switch name {
+	case "risk_of_churn":
+		return e.RiskOfChurn
+	case "frustration":
+		return e.Frustration
+	case "confusion":
+		return e.Confusion
+	case "resolution_likelihood":
+		return e.ResolutionLikely
+	case "severity":
+		return float64(e.Severity)
+	}
+	return 0
+}
+
+func (e Event) stringField(name string) string {
+	switch name {
+	case "priority":
+		return e.Priority
+	case "urgency_level":
+		return e.UrgencyLevel
+	case "issue":
+		return e.Issue
+	case "city":
+		return e.City
+	case "team":
+		return e.Team
+	case "agent_id":
+		return e.AgentID
+	}
+	return ""
+}
+
+// Summary is the one-line human description used by Slack and email.
+func (e Event) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s", e.Rule, e.Issue)
+	if e.Priority != "" {
+		fmt.Fprintf(&b, " (priority %s)", e.Priority)
+	}
+	var who []string
+	for _, kv := range [][2]string{{"agent", e.AgentID}, {"team", e.Team}, {"city", e.City}} {
+		if kv[1] != "" {
+			who = append(who, kv[0]+" "+kv[1])
+		}
+	}
+	if len(who) > 0 {
+		fmt.Fprintf(&b, " — %s", strings.Join(who, ", "))
+	}
+	if e.EscalationReason != "" {
+		fmt.Fprintf(&b, ". Reason: %s", e.EscalationReason)
+	}
+	fmt.Fprintf(&b, ". Risk of churn %.2f. Job %s", e.RiskOfChurn, e.JobID)
+	return b.String()
+}
+
+// Delivery is the log entry of one event sent to one sink. Its id is job.rule.sink, so a
+// call re-processed after a retry is never notified twice.
+type Delivery struct {
+	ID          string    `json:"id"`
+	Rule        string    `json:"rule"`
+	Sink        string    `json:"sink"`
+	SinkType    string    `json:"sink_type"`
+	JobID       string    `json:"job_id"`
+	Status      string    `json:"status"`
+	Attempts    int       `json:"attempts"`
+	HTTPStatus  int       `json:"http_status,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+	Event       Event     `json:"event"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	DeliveredAt time.Time `json:"delivered_at,omitzero"`
+}
+
+// maxRetryTime bounds how long one delivery is retried, from NOTIFY_MAX_RETRY_SEC
+// (default 300).
+func maxRetryTime() time.Duration {
+	if v, err := strconv.Atoi(os.Getenv("NOTIFY_MAX_RETRY_SEC")); err == nil && v > 0 {
+		return time.Duration(v) * time.Second
+	}
+	return 5 * time.Minute
+}
+
+// Dispatch evaluates the rules against a processed job and delivers every match to the
+// enabled sinks of the rule in the background. It returns the deliveries it started; ones
+// already logged for the same job, rule and sink are skipped.
+func Dispatch(j *jobs.Job) ([]Delivery, error) {
+	if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+		return nil, nil
+	}
+	cfg, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	log := logger.New().WithField("component", "notify").WithField("job_id", j.ID)
+	base := EventFromJob(j)
+	var started []Delivery
+	for _, r := range cfg.Rules {
+		if !r.matches(base) {
+			continue
+		}
+		e := base
+		e.Rule, e.ID = r.ID, j.ID+"."+r.ID
+		for _, sinkID := range r.Sinks {
+			sink, _ := cfg.Sink(sinkID)
+			if !sink.Enabled() {
+				continue
+			}
+			id := e.ID + "." + sinkID
+			now := time.Now().UTC()
+			d := Delivery{ID: id, Rule: r.ID, Sink: sinkID, SinkType: sink.Type, JobID: j.ID, Status: StatusPending, Event: e, CreatedAt: now, UpdatedAt: now}
+			// create-if-absent, so concurrent dispatches of the same job cannot both send
+			if err := st.Create(collection, id, d); errors.Is(err, store.ErrExists) {
+				log.WithField("delivery_id", id).Info("already notified; skipping duplicate")
+				continue
+			} else if err != nil {
+				return started, fmt.Errorf("save delivery: %w", err)
+			}
+			started = append(started, d)
+			go deliver(d, sink)
+		}
+	}
+	return started, nil
+}
+
+// deliver sends d with exponential backoff until it succeeds, fails permanently or
+// maxRetryTime passes, logging every attempt.
+func deliver(d Delivery, sink Sink) {
+	log := logger.New().WithField("component", "notify").WithField("delivery_id", d.ID).WithField("sink", d.Sink)
+	ctx, cancel := context.WithTimeout(context.Background(), maxRetryTime())
+	defer cancel()
+
+	op := func() error {
+		d.Attempts++
+		status, err := send(ctx, sink, d)
+		d.HTTPStatus, d.LastError = status, ""
+		if err != nil {
+			d.LastError = err.Error()
+			log.WithError(err).WithField("attempt", d.Attempts).Warn("delivery attempt failed")
+			_ = save(&d)
+			if status >= 400 && status < 500 && status != 429 {
+				return backoff.Permanent(err)
+			}
+			return err
+		}
+		return nil
+	}
+	b := backoff.NewExponentialBackOff()
+	b.MaxElapsedTime = 0 // bounded by ctx
+	if err := backoff.Retry(op, backoff.WithContext(b, ctx)); err != nil {
+		d.Status = StatusFailed
+		log.WithError(err).WithField("attempts", d.Attempts).Error("delivery failed")
+	} else {
+		d.Status = StatusDelivered
+		d.DeliveredAt = time.Now().UTC()
+		log.WithField("attempts", d.Attempts).Info("notification delivered")
+	}
+	if err := save(&d); err != nil {
+		log.WithError(err).Error("failed to log delivery")
+	}
+}
+
+// stale reports a pending delivery whose sender is gone: nothing has touched it for longer
+// than the retry budget, so the process that owned it must have stopped.
+func stale(d Delivery, now time.Time) bool {
+	return d.Status == StatusPending && now.Sub(d.UpdatedAt) > maxRetryTime()+time.Minute
+}
+
+// Resume sends again every delivery a previous process left pending. It runs once at
+// startup, before any new delivery is started, so every pending record is orphaned;
+// deliveries whose sink is gone are marked failed.
+func Resume() (int, error) {
+	cfg, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	pending, err := List(StatusPending, "")
+	if err != nil {
+		return 0, err
+	}
+	log := logger.New().WithField("component", "notify")
+	resumed := 0
+	for _, d := range pending {
+		sink, ok := cfg.Sink(d.Sink)
+		if !ok || !sink.Enabled() {
+			d.Status, d.LastError = StatusFailed, fmt.Sprintf("sink %q is no longer configured", d.Sink)
+			if err := save(&d); err != nil {
+				return resumed, err
+			}
+			continue
+		}
+		log.WithField("delivery_id", d.ID).Info("resuming pending delivery")
+		go deliver(d, sink)
+		resumed++
+	}
+	return resumed, nil
+}
+
+// redeliverMu serialises Redeliver's status check with the flip to pending, so concurrent
+// calls for the same failed delivery send it once.
+var redeliverMu sync.Mutex
+
+// Redeliver sends a failed delivery again in the background. A pending delivery that has
+// gone stale counts as failed; any other status is a Conflict.
+func Redeliver(id string) (Delivery, error) {
+	cfg, err := Load()
+	if err != nil {
+		return Delivery{}, err
+	}
+	redeliverMu.Lock()
+	defer redeliverMu.Unlock()
+	d, err := Get(id)
+	if err != nil {
+		return Delivery{}, err
+	}
+	if d.Status != StatusFailed && !stale(d, time.Now()) {
+		return d, apperr.New(apperr.Conflict, "", fmt.Sprintf("delivery is %s; only failed deliveries can be retried", d.Status))
+	}
+	sink, ok := cfg.Sink(d.Sink)
+	if !ok || !sink.Enabled() {
+		return d, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("sink %q is no longer configured", d.Sink))
+	}
+	d.Status, d.Attempts = StatusPending, 0
+	if err := save(&d); err != nil {
+		return d, err
+	}
+	go deliver(d, sink)
+	return d, nil
+}
+
+// Test sends a sample event to sink id once, without logging it.
+func Test(ctx context.Context, id string) (int, error) {
+	cfg, err := Load()
+	if err != nil {
+		return 0, err
+	}
+	sink, ok := cfg.Sink(id)
+	if !ok {
+		return 0, apperr.New(apperr.NotFound, "", fmt.Sprintf("sink %q not configured", id))
+	}
+	if !sink.Enabled() {
+		return 0, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("sink %q is disabled; set its environment variables", id))
+	}
+	e := Event{ID: "test." + id, Rule: "test", JobID: "test", Issue: taxonomy.OtherID, PrimaryIssue: "test notification", Priority: "low", CallTime: time.Now().UTC()}
+	return send(ctx, sink, Delivery{ID: e.ID + "." + id, Rule: e.Rule, Sink: id, SinkType: sink.Type, Event: e})
+}
+
+// Get loads a delivery.
+func Get(id string) (Delivery, error) {
+	st, err := store.Default()
+	if err != nil {
+		return Delivery{}, err
+	}
+	var d Delivery
+	if err := st.Get(collection, id, &d); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return d, apperr.New(apperr.NotFound, "", fmt.Sprintf("delivery %q not found", id))
+		}
+		return d, fmt.Errorf("load delivery: %w", err)
+	}
+	return d, nil
+}
+
+// List returns the delivery log, newest first, optionally filtered by status and job.
+func List(status, jobID string) ([]Delivery, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	out := []Delivery{}
+	err = st.List(collection, func(_ string, raw json.RawMessage) error {
+		var d Delivery
+		if err := json.Unmarshal(raw, &d); err != nil {
+			return err
+		}
+		if (status == "" || d.Status == status) && (jobID == "" || d.JobID == jobID) {
+			out = append(out, d)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, err
+}
+
+func save(d *Delivery) error {
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	d.UpdatedAt = time.Now().UTC()
+	if err := st.Put(collection, d.ID, d); err != nil {
+		return fmt.Errorf("save delivery: %w", err)
+	}
+	return nil
+}
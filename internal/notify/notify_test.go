@@ -0,0 +1,256 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/testutil"
+)
+
+func TestParseWhen(t *testing.T) {
+	tests := []struct {
+		when    string
+		wantErr bool
+	}{
+		{"requires_escalation == true", false},
+		{"risk_of_churn > 0.7 && city == 'Mumbai'", false},
+		{"severity >= 4 && priority != low", false},
+		{"risk_of_churn > high", true},
+		{"city > mumbai", true},
+		{"requires_escalation == maybe", true},
+		{"mood == angry", true},
+		{"risk_of_churn", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			if _, err := parseWhen(tt.when); (err != nil) != tt.wantErr {
+				t.Errorf("parseWhen err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		when string
+		e    Event
+		want bool
+	}{
+		{"escalated", "requires_escalation == true", Event{RequiresEscalation: true}, true},
+		{"not escalated", "requires_escalation == true", Event{}, false},
+		{"risky in the city, case folded", "risk_of_churn > 0.7 && city == mumbai", Event{RiskOfChurn: 0.8, City: "Mumbai"}, true},
+		{"risky elsewhere", "risk_of_churn > 0.7 && city == mumbai", Event{RiskOfChurn: 0.8, City: "Pune"}, false},
+		{"threshold is exclusive", "risk_of_churn > 0.7", Event{RiskOfChurn: 0.7}, false},
+		{"severity is numeric", "severity >= 4", Event{Severity: 4}, true},
+		{"not equal", "priority != low", Event{Priority: "High"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conds, err := parseWhen(tt.when)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := (Rule{conds: conds}).matches(tt.e); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+	if (Rule{}).matches(Event{RequiresEscalation: true}) {
+		t.Error("a rule without conditions matched")
+	}
+}
+
+func TestParseExpandsAndValidates(t *testing.T) {
+	t.Setenv("NOTIFY_WEBHOOK_URL", "https://hooks.example.com/vi")
+	t.Setenv("NOTIFY_EMAIL_TO", "a@example.com, b@example.com")
+	cfg, err := Parse(defaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := cfg.Sink("webhook"); !s.Enabled() || s.URL != "https://hooks.example.com/vi" {
+		t.Errorf("webhook sink = %+v", s)
+	}
+	if s, _ := cfg.Sink("slack"); s.Enabled() {
+		t.Errorf("slack sink without a url is enabled: %+v", s)
+	}
+	if s, _ := cfg.Sink("email"); len(s.To) != 2 || s.Enabled() {
+		t.Errorf("email sink = %+v, want two recipients and disabled without smtp", s)
+	}
+
+	for _, bad := range []string{
+		`{"sinks":[{"id":"x","type":"pager"}]}`,
+		`{"sinks":[{"id":"x","type":"slack"},{"id":"x","type":"slack"}]}`,
+		`{"rules":[{"id":"r","when":"confused == true","sinks":["nowhere"]}]}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%s) succeeded", bad)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	// the well-known HMAC-SHA256 example
+	got := Sign("key", []byte("The quick brown fox jumps over the lazy dog"))
+	if want := "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"; got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+// receiver is a webhook endpoint that answers with the queued statuses, then 200, and checks
+// each body against its signature.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	calls    int
+	badSigs  int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.calls++
+	if r.Header.Get(HeaderSignature) != "sha256="+Sign("s3cret", body) || !json.Valid(body) {
+		rc.badSigs++
+	}
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) counts() (calls, badSigs int) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.calls, rc.badSigs
+}
+
+// useReceiver points the notification config at a webhook served by rc until t ends.
+func useReceiver(t *testing.T, rc *receiver) {
+	t.Helper()
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+	cfg, err := Parse([]byte(`{
+		"sinks": [{"id": "hook", "type": "webhook", "url": "` + srv.URL + `", "secret": "s3cret"}],
+		"rules": [{"id": "escalation", "when": "requires_escalation == true", "sinks": ["hook"]}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	loadOnce.Do(func() {})
+	prev := loaded
+	loaded, loadErr = cfg, nil
+	t.Cleanup(func() { loaded = prev })
+}
+
+func escalated() *jobs.Job {
+	j := jobs.New("https://calls/x.wav", 3, "single")
+	j.Status = jobs.StatusCompleted
+	j.Result.KPI.Actions.RequiresEscalation = true
+	return j
+}
+
+// waitFor polls delivery id until it has status.
+func waitFor(t *testing.T, id, status string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		d, err := Get(id)
+		if err == nil && d.Status == status {
+			return d
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivery %s = %+v, %v; want %s", id, d, err, status)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestDispatchRetriesAndDedupes(t *testing.T) {
+	testutil.DataDir(t)
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable}}
+	useReceiver(t, rc)
+	j := escalated()
+
+	started, err := Dispatch(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(started) != 1 || started[0].ID != j.ID+".escalation.hook" {
+		t.Fatalf("started %+v, want one delivery to the hook", started)
+	}
+	d := waitFor(t, started[0].ID, StatusDelivered)
+	if d.Attempts != 2 || d.HTTPStatus != http.StatusOK || d.LastError != "" {
+		t.Errorf("delivery = %+v, want delivered on the second attempt", d)
+	}
+
+	again, err := Dispatch(j)
+	if err != nil || len(again) != 0 {
+		t.Errorf("re-dispatch started %d deliveries, err %v; want none", len(again), err)
+	}
+	calls, badSigs := rc.counts()
+	if calls != 2 || badSigs != 0 {
+		t.Errorf("receiver got %d calls, %d with a bad signature; want 2 and 0", calls, badSigs)
+	}
+
+	calm := escalated()
+	calm.Result.KPI.Actions.RequiresEscalation = false
+	if none, err := Dispatch(calm); err != nil || len(none) != 0 {
+		t.Errorf("unmatched job started %d deliveries, err %v", len(none), err)
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	testutil.DataDir(t)
+	rc := &receiver{statuses: []int{http.StatusBadRequest}}
+	useReceiver(t, rc)
+
+	started, err := Dispatch(escalated())
+	if err != nil || len(started) != 1 {
+		t.Fatalf("Dispatch = %+v, %v", started, err)
+	}
+	id := started[0].ID
+	if d := waitFor(t, id, StatusFailed); d.Attempts != 1 || d.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("a 4xx should fail at once: %+v", d)
+	}
+
+	var wg sync.WaitGroup
+	var ok, conflicts atomic.Int32
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			switch _, err := Redeliver(id); {
+			case err == nil:
+				ok.Add(1)
+			case apperr.KindOf(err) == apperr.Conflict:
+				conflicts.Add(1)
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if ok.Load() != 1 || conflicts.Load() != 4 {
+		t.Errorf("concurrent redelivers: %d accepted, %d conflicts; want 1 and 4", ok.Load(), conflicts.Load())
+	}
+	if d := waitFor(t, id, StatusDelivered); d.Attempts != 1 {
+		t.Errorf("redelivered = %+v", d)
+	}
+	if _, err := Redeliver(id); apperr.KindOf(err) != apperr.Conflict {
+		t.Errorf("redelivering a delivered notification: err = %v, want conflict", err)
+	}
+	if calls, _ := rc.counts(); calls != 2 {
+		t.Errorf("receiver got %d calls, want 2", calls)
+	}
+}
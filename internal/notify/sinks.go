@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+const httpTimeout = 10 * time.Second
+
+// Headers set on webhook deliveries. The signature is "sha256=" and the hex HMAC-SHA256 of
+// the raw body keyed with the sink secret; it is omitted when the sink has no secret.
+const (
+	HeaderSignature = "X-Signature-256"
+	HeaderDelivery  = "X-Delivery-ID"
+	HeaderEvent     = "X-Event-Rule"
+)
+
+// send makes one delivery attempt. The int is the HTTP status, 0 for email or when no
+// response arrived.
+func send(ctx context.Context, s Sink, d Delivery) (int, error) {
+	switch s.Type {
+	case TypeWebhook:
+		body, err := json.Marshal(d.Event)
+		if err != nil {
+			return 0, err
+		}
+		h := http.Header{}
+		h.Set(HeaderDelivery, d.ID)
+		h.Set(HeaderEvent, d.Rule)
+		if s.Secret != "" {
+			h.Set(HeaderSignature, "sha256="+Sign(s.Secret, body))
+		}
+		return post(ctx, s.URL, body, h)
+	case TypeSlack:
+		body, err := json.Marshal(slackPayload(d.Event))
+		if err != nil {
+			return 0, err
+		}
+		return post(ctx, s.URL, body, http.Header{})
+	case TypeEmail:
+		return 0, sendMail(ctx, s, d.Event)
+	}
+	return 0, fmt.Errorf("unknown sink type %q", s.Type)
+}
+
+// Sign is the hex HMAC-SHA256 of body keyed with secret, for receivers to verify webhooks.
+func Sign(secret string, body []byte) string {
+	m := hmac.New(sha256.New, []byte(secret))
+	m.Write(body)
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+func post(ctx context.Context, url string, body []byte, h http.Header) (int, error) {
+	reqCtx, cancel := context.WithTimeout(ctx, httpTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header = h
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: httpTimeout}).Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return resp.StatusCode, nil
+}
+
+// slackPayload is an incoming-webhook message: text for notifications plus a block with
+// the key fields.
+func slackPayload(e Event) map[string]any {
+	fields := []map[string]string{}
+	for _, kv := range [][2]string{
+		{"Issue", e.Issue},
+		{"Priority", e.Priority},
+		{"Risk of churn", fmt.Sprintf("%.2f", e.RiskOfChurn)},
+		{"Agent / team", strings.Trim(e.AgentID+" / "+e.Team, " /")},
+		{"City", e.City},
+		{"Job", e.JobID},
+	} {
+		if kv[1] != "" {
+			fields = append(fields, map[string]string{"type": "mrkdwn", "text": "*" + kv[0] + "*\n" + kv[1]})
+		}
+	}
+	return map[string]any{
+		"text": e.Summary(),
+		"blocks": []map[string]any{
+			{"type": "section", "text": map[string]string{"type": "mrkdwn", "text": "*" + e.Rule + "*: " + e.PrimaryIssue}},
+			{"type": "section", "fields": fields},
+		},
+	}
+}
+
+func sendMail(ctx context.Context, s Sink, e Event) error {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: [voice-insights] %s: %s\r\n", s.From, strings.Join(s.To, ", "), e.Rule, e.Issue)
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(e.Summary() + "\r\n\r\n")
+	fmt.Fprintf(&msg, "Primary issue: %s\r\nCall time: %s\r\nAudio: %s\r\n", e.PrimaryIssue, e.CallTime.Format(time.RFC3339), e.AudioURL)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.SMTPAddr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	// smtp.SendMail has no context; run it aside so a hung server cannot outlive ctx
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(s.SMTPAddr, auth, s.From, s.To, []byte(msg.String())) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(httpTimeout * 3):
+		return fmt.Errorf("smtp %s: timed out", s.SMTPAddr)
+	}
+}
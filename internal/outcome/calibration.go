@@ -0,0 +1,259 @@
+package outcome
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"voice-insights-go/internal/scorecard"
+)
+
+// DefaultBins is the number of equal-width reliability bins over [0, 1].
+const DefaultBins = 10
+
+// minReliable is the sample below which the markdown report warns that numbers are noisy.
+const minReliable = 30
+
+// Bin is one reliability bin: calls whose score fell in [From, To) and how often the
+// predicted event actually happened for them.
+type Bin struct {
+	From          float64 `json:"from"`
+	To            float64 `json:"to"`
+	Outcomes      int     `json:"outcomes"`
+	MeanPredicted float64 `json:"mean_predicted"`
+	ObservedRate  float64 `json:"observed_rate"`
+}
+
+// Calibration compares one score against the outcome it predicts. A well-calibrated score
+// has each bin's observed rate near its mean prediction, so a low ECE; Brier is the mean
+// squared error and BrierSkill its improvement over always predicting the base rate. AUC
+// says how well the score ranks positives above negatives, regardless of calibration.
+// BrierSkill and AUC are nil when every outcome is the same.
+type Calibration struct {
+	Score         string   `json:"score"`
+	Target        string   `json:"target"`
+	Outcomes      int      `json:"outcomes"`
+	Positives     int      `json:"positives"`
+	BaseRate      float64  `json:"base_rate"`
+	MeanPredicted float64  `json:"mean_predicted"`
+	Brier         float64  `json:"brier"`
+	BrierSkill    *float64 `json:"brier_skill,omitempty"`
+	ECE           float64  `json:"ece"`
+	AUC           *float64 `json:"auc,omitempty"`
+	Bins          []Bin    `json:"bins"`
+}
+
+// FollowUps counts calls with a recommended follow-up and, of the outcomes that say whether
+// a follow-up was done, how often resolution followed. The rates are nil when no final
+// outcome falls in the group.
+type FollowUps struct {
+	Recommended         int      `json:"recommended"`
+	Reported            int      `json:"reported"`
+	Done                int      `json:"done"`
+	ResolvedWhenDone    *float64 `json:"resolved_when_done,omitempty"`
+	ResolvedWhenSkipped *float64 `json:"resolved_when_skipped,omitempty"`
+}
+
+// Report is the calibration of resolution likelihood and churn risk over calls in a window.
+type Report struct {
+	Window     scorecard.Window `json:"window"`
+	Outcomes   int              `json:"outcomes"`
+	Pending    int              `json:"pending"`
+	Resolution Calibration      `json:"resolution"`
+	Churn      Calibration      `json:"churn"`
+	FollowUps  FollowUps        `json:"followups"`
+}
+
+type sample struct {
+	p   float64
+	hit bool
+}
+
+// Calibrate builds the report from outcomes of calls placed in w. Resolution uses final
+// (resolved or unresolved) outcomes; churn uses outcomes that say whether the customer left.
+func Calibrate(all []Outcome, w scorecard.Window, bins int) Report {
+	if bins <= 0 {
+		bins = DefaultBins
+	}
+	rep := Report{Window: w}
+	var res, churn []sample
+	var done, skipped [2]int // resolved, final
+	for _, o := range all {
+		if !w.Contains(o.Prediction.CallTime) {
+			continue
+		}
+		rep.Outcomes++
+		resolved, known := o.Resolved()
+		if known {
+			res = append(res, sample{o.Prediction.ResolutionLikelihood, resolved})
+		} else {
+			rep.Pending++
+		}
+		if o.Churned != nil {
+			churn = append(churn, sample{o.Prediction.RiskOfChurn, *o.Churned})
+		}
+		f := &rep.FollowUps
+		if o.Prediction.RecommendedFollowUp != "" {
+			f.Recommended++
+		}
+		if o.FollowUpDone == nil {
+			continue
+		}
+		f.Reported++
+		group := &skipped
+		if *o.FollowUpDone {
+			f.Done++
+			group = &done
+		}
+		if known {
+			group[1]++
+			if resolved {
+				group[0]++
+			}
+		}
+	}
+	rep.Resolution = calibrate("resolution_likelihood", "resolved", res, bins)
+	rep.Churn = calibrate("risk_of_churn", "churned", churn, bins)
+	rep.FollowUps.ResolvedWhenDone = rate(done)
+	rep.FollowUps.ResolvedWhenSkipped = rate(skipped)
+	return rep
+}
+
+func rate(g [2]int) *float64 {
+	if g[1] == 0 {
+		return nil
+	}
+	r := float64(g[0]) / float64(g[1])
+	return &r
+}
+
+func calibrate(score, target string, samples []sample, bins int) Calibration {
+	c := Calibration{Score: score, Target: target, Outcomes: len(samples), Bins: make([]Bin, bins)}
+	for i := range c.Bins {
+		c.Bins[i].From, c.Bins[i].To = float64(i)/float64(bins), float64(i+1)/float64(bins)
+	}
+	if len(samples) == 0 {
+		return c
+	}
+	hits := make([]int, bins)
+	for i, s := range samples {
+		p := math.Min(math.Max(s.p, 0), 1)
+		samples[i].p = p
+		y := 0.0
+		if s.hit {
+			y = 1
+			c.Positives++
+		}
+		c.MeanPredicted += p
+		c.Brier += (p - y) * (p - y)
+		b := min(int(p*float64(bins)), bins-1)
+		c.Bins[b].Outcomes++
+		c.Bins[b].MeanPredicted += p
+		if s.hit {
+			hits[b]++
+		}
+	}
+	n := float64(len(samples))
+	c.BaseRate = float64(c.Positives) / n
+	c.MeanPredicted /= n
+	c.Brier /= n
+	for i := range c.Bins {
+		b := &c.Bins[i]
+		if b.Outcomes == 0 {
+			continue
+		}
+		b.MeanPredicted /= float64(b.Outcomes)
+		b.ObservedRate = float64(hits[i]) / float64(b.Outcomes)
+		c.ECE += float64(b.Outcomes) / n * math.Abs(b.ObservedRate-b.MeanPredicted)
+	}
+	if c.Positives > 0 && c.Positives < len(samples) {
+		skill := 1 - c.Brier/(c.BaseRate*(1-c.BaseRate))
+		c.BrierSkill = &skill
+		auc := auc(samples, c.Positives)
+		c.AUC = &auc
+	}
+	return c
+}
+
+// AUC is the ranking quality of scores against labels (see Calibration). It is 0.5 when
+// every label is the same.
+func AUC(scores []float64, labels []bool) float64 {
+	samples := make([]sample, len(scores))
+	positives := 0
+	for i := range scores {
+		samples[i] = sample{scores[i], labels[i]}
+		if labels[i] {
+			positives++
+		}
+	}
+	if positives == 0 || positives == len(samples) {
+		return 0.5
+	}
+	return auc(samples, positives)
+}
+
+// auc is the Mann-Whitney estimate: the chance a random positive outscores a random
+// negative, counting ties as half.
+func auc(samples []sample, positives int) float64 {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].p < samples[j].p })
+	var rankSum float64
+	for i := 0; i < len(samples); {
+		j := i
+		for j < len(samples) && samples[j].p == samples[i].p {
+			j++
+		}
+		mid := float64(i+j+1) / 2 // mean 1-based rank of the tie group
+		for k := i; k < j; k++ {
+			if samples[k].hit {
+				rankSum += mid
+			}
+		}
+		i = j
+	}
+	pos, neg := float64(positives), float64(len(samples)-positives)
+	return (rankSum - pos*(pos+1)/2) / (pos * neg)
+}
+
+// Markdown renders the report.
+func Markdown(rep Report) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Outcome calibration\n\n")
+	fmt.Fprintf(&b, "Calls %s to %s: %d outcomes, %d still pending.\n\n", rep.Window.From.Format("2006-01-02"), rep.Window.To.Format("2006-01-02"), rep.Outcomes, rep.Pending)
+	for _, c := range []Calibration{rep.Resolution, rep.Churn} {
+		fmt.Fprintf(&b, "## %s vs %s\n\n", c.Score, c.Target)
+		if c.Outcomes == 0 {
+			b.WriteString("No outcomes yet.\n\n")
+			continue
+		}
+		if c.Outcomes < minReliable {
+			fmt.Fprintf(&b, "_Only %d outcomes; treat these numbers as noisy._\n\n", c.Outcomes)
+		}
+		fmt.Fprintf(&b, "- Outcomes: %d (%s rate %.2f, mean predicted %.2f)\n", c.Outcomes, c.Target, c.BaseRate, c.MeanPredicted)
+		fmt.Fprintf(&b, "- Brier: %.3f%s\n", c.Brier, optional(", skill %+.2f", c.BrierSkill))
+		fmt.Fprintf(&b, "- ECE: %.3f\n", c.ECE)
+		fmt.Fprintf(&b, "- AUC: %s\n\n", optional("%.2f", c.AUC))
+		b.WriteString("| Score | Calls | Mean predicted | Observed |\n|---|---|---|---|\n")
+		for _, bin := range c.Bins {
+			if bin.Outcomes > 0 {
+				fmt.Fprintf(&b, "| %.1f–%.1f | %d | %.2f | %.2f |\n", bin.From, bin.To, bin.Outcomes, bin.MeanPredicted, bin.ObservedRate)
+			}
+		}
+		b.WriteString("\n")
+	}
+	f := rep.FollowUps
+	b.WriteString("## Follow-ups\n\n")
+	fmt.Fprintf(&b, "- Recommended: %d, reported: %d, done: %d\n", f.Recommended, f.Reported, f.Done)
+	fmt.Fprintf(&b, "- Resolved when done: %s; when skipped: %s\n", optional("%.2f", f.ResolvedWhenDone), optional("%.2f", f.ResolvedWhenSkipped))
+	return b.String()
+}
+
+func optional(format string, v *float64) string {
+	if v == nil {
+		if strings.HasPrefix(format, ",") {
+			return ""
+		}
+		return "n/a"
+	}
+	return fmt.Sprintf(format, *v)
+}
@@ -0,0 +1,113 @@
+package outcome
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/scorecard"
+)
+
+func TestCalibrate(t *testing.T) {
+	s := func(p float64, hit bool) sample { return sample{p, hit} }
+	tests := []struct {
+		name    string
+		samples []sample
+		brier   float64
+		ece     float64
+		skill   *float64
+		auc     *float64
+	}{
+		{"perfect", []sample{s(0, false), s(1, true)}, 0, 0, ptr(1), ptr(1)},
+		{"coin flip on a balanced set", []sample{s(0.5, true), s(0.5, false)}, 0.25, 0, ptr(0), ptr(0.5)},
+		{"overconfident", []sample{s(0.9, false), s(0.9, true), s(0.1, false), s(0.1, false)}, 0.21, 0.25, ptr(1 - 0.21/0.1875), ptr(2.5 / 3)},
+		{"one class has no skill or auc", []sample{s(0.2, false), s(0.4, false)}, 0.1, 0.3, nil, nil},
+		{"scores are clamped", []sample{s(1.5, true), s(-0.2, false)}, 0, 0, ptr(1), ptr(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := calibrate("score", "target", tt.samples, 2)
+			if c.Outcomes != len(tt.samples) || len(c.Bins) != 2 || c.Bins[1].From != 0.5 {
+				t.Errorf("outcomes=%d bins=%+v", c.Outcomes, c.Bins)
+			}
+			if !near(c.Brier, tt.brier) || !near(c.ECE, tt.ece) {
+				t.Errorf("brier=%v ece=%v, want %v and %v", c.Brier, c.ECE, tt.brier, tt.ece)
+			}
+			if !nearPtr(c.BrierSkill, tt.skill) || !nearPtr(c.AUC, tt.auc) {
+				t.Errorf("skill=%v auc=%v, want %v and %v", deref(c.BrierSkill), deref(c.AUC), deref(tt.skill), deref(tt.auc))
+			}
+		})
+	}
+
+	if c := calibrate("score", "target", nil, DefaultBins); c.Outcomes != 0 || len(c.Bins) != DefaultBins || c.AUC != nil {
+		t.Errorf("empty calibration = %+v", c)
+	}
+}
+
+func TestAUC(t *testing.T) {
+	tests := []struct {
+		name   string
+		scores []float64
+		labels []bool
+		want   float64
+	}{
+		{"ranked", []float64{0.1, 0.4, 0.6, 0.9}, []bool{false, false, true, true}, 1},
+		{"reversed", []float64{0.9, 0.6, 0.4, 0.1}, []bool{false, false, true, true}, 0},
+		{"ties count half", []float64{0.5, 0.5, 0.5}, []bool{true, false, false}, 0.5},
+		{"one swap", []float64{0.1, 0.7, 0.6, 0.9}, []bool{false, false, true, true}, 0.75},
+		{"one class", []float64{0.2, 0.8}, []bool{true, true}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AUC(tt.scores, tt.labels); !near(got, tt.want) {
+				t.Errorf("AUC = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalibrateReport(t *testing.T) {
+	at := time.Date(2026, 4, 10, 10, 0, 0, 0, time.UTC)
+	yes, no := true, false
+	out := func(status string, resolution, churn float64, churned, done *bool, callTime time.Time) Outcome {
+		return Outcome{Status: status, Churned: churned, FollowUpDone: done, Prediction: Prediction{
+			ResolutionLikelihood: resolution, RiskOfChurn: churn, RecommendedFollowUp: "call back", CallTime: callTime,
+		}}
+	}
+	all := []Outcome{
+		out(StatusResolved, 0.8, 0.1, &no, &yes, at),
+		out(StatusUnresolved, 0.3, 0.7, &yes, &no, at),
+		out(StatusResolved, 0.6, 0.2, nil, &no, at),
+		out(StatusPending, 0.5, 0.5, nil, &yes, at),
+		out(StatusResolved, 0.9, 0.1, &no, nil, at.AddDate(0, -1, 0)), // outside the window
+	}
+	rep := Calibrate(all, scorecard.Window{From: at.AddDate(0, 0, -1), To: at.AddDate(0, 0, 1)}, 0)
+	if rep.Outcomes != 4 || rep.Pending != 1 || rep.Resolution.Outcomes != 3 || rep.Churn.Outcomes != 2 {
+		t.Errorf("report counts = %d outcomes, %d pending, %d resolution, %d churn", rep.Outcomes, rep.Pending, rep.Resolution.Outcomes, rep.Churn.Outcomes)
+	}
+	if len(rep.Resolution.Bins) != DefaultBins || rep.Resolution.Positives != 2 {
+		t.Errorf("resolution = %+v", rep.Resolution)
+	}
+	fu := rep.FollowUps
+	if fu.Recommended != 4 || fu.Reported != 4 || fu.Done != 2 || !nearPtr(fu.ResolvedWhenDone, ptr(1)) || !nearPtr(fu.ResolvedWhenSkipped, ptr(0.5)) {
+		t.Errorf("follow-ups = %+v done=%v skipped=%v", fu, deref(fu.ResolvedWhenDone), deref(fu.ResolvedWhenSkipped))
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func nearPtr(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return near(*a, *b)
+}
+
+func ptr(v float64) *float64 { return &v }
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
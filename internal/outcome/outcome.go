@@ -0,0 +1,212 @@
+// Package outcome records what actually happened after a call (was the issue resolved, did
+// the customer churn, was the recommended follow-up done) and measures how well the
+// extraction's resolution likelihood and churn risk predicted it.
+package outcome
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+)
+
+const collection = "outcomes"
+
+// Resolution statuses. Pending outcomes are still open and are left out of calibration.
+const (
+	StatusResolved   = "resolved"
+	StatusUnresolved = "unresolved"
+	StatusPending    = "pending"
+)
+
+// Update is what CRM or ops post for a call. Nil fields keep their recorded value, so
+// systems can report resolution and churn separately.
+type Update struct {
+	Status       string     `json:"status,omitempty"`
+	Churned      *bool      `json:"churned,omitempty"`
+	FollowUpDone *bool      `json:"followup_done,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
+}
+
+// SourceLLM marks a Prediction's RiskOfChurn as the LLM's own score.
+const SourceLLM = "llm"
+
+// Prediction is the part of the call's extraction an outcome is judged against, captured
+// when the outcome is first recorded. RiskOfChurn is always the LLM's score, which is what
+// calibration measures; the trained model's probability is kept beside it, since the model
+// is fitted on these same outcomes and judging it here would be in-sample.
+type Prediction struct {
+	ResolutionLikelihood float64   `json:"resolution_likelihood"`
+	RiskOfChurn          float64   `json:"risk_of_churn"`
+	RiskOfChurnSource    string    `json:"risk_of_churn_source"`
+	RecommendedFollowUp  string    `json:"recommended_followup,omitempty"`
+	Issue                string    `json:"issue"`
+	AgentID              string    `json:"agent_id,omitempty"`
+	Team                 string    `json:"team,omitempty"`
+	CallTime             time.Time `json:"call_time"`
+	// ModelChurn is the churn model's probability and ModelVersion the model, when one
+	// scored the call
+	ModelChurn   *float64 `json:"model_churn,omitempty"`
+	ModelVersion int      `json:"model_version,omitempty"`
+}
+
+// Outcome is the stored result of a call, keyed by its job id. CallID is the call's
+// external id, when the job has one.
+type Outcome struct {
+	JobID        string     `json:"job_id"`
+	CallID       string     `json:"call_id,omitempty"`
+	Status       string     `json:"status"`
+	Churned      *bool      `json:"churned,omitempty"`
+	FollowUpDone *bool      `json:"followup_done,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	// Notes are redacted before storage
+	Notes      string     `json:"notes,omitempty"`
+	Prediction Prediction `json:"prediction"`
+	Revisions  int        `json:"revisions"`
+	RecordedAt time.Time  `json:"recorded_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// Resolved reports whether the outcome is final, and if so whether the issue was resolved.
+func (o Outcome) Resolved() (resolved, known bool) {
+	switch o.Status {
+	case StatusResolved:
+		return true, true
+	case StatusUnresolved:
+		return false, true
+	}
+	return false, false
+}
+
+// PredictionOf captures the scores of a processed job.
+func PredictionOf(j *jobs.Job) Prediction {
+	x := j.Result.KPI
+	issue := taxonomy.OtherID
+	if is := j.Result.Issues; is != nil && is.PrimaryIssue.NodeID != "" {
+		issue = is.PrimaryIssue.NodeID
+	}
+	p := Prediction{
+		ResolutionLikelihood: x.KPI.ResolutionLikelihood,
+		RiskOfChurn:          x.BusinessImpact.RiskOfChurn,
+		RiskOfChurnSource:    SourceLLM,
+		RecommendedFollowUp:  strings.TrimSpace(x.ShouldHaveDone.RecommendedFollowUp),
+		Issue:                issue,
+		AgentID:              j.AgentID,
+		Team:                 j.Team,
+		CallTime:             j.At(),
+	}
+	if c := j.Result.Churn; c != nil {
+		prob := c.Probability
+		p.RiskOfChurn, p.ModelChurn, p.ModelVersion = c.LLM, &prob, c.ModelVersion
+	}
+	return p
+}
+
+// Record applies u to the outcome of a processed job and stores it.
+func Record(j *jobs.Job, u Update) (Outcome, error) {
+	if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+		return Outcome{}, apperr.New(apperr.InvalidInput, "", "job has no extraction to compare against")
+	}
+	u.Status = strings.ToLower(strings.TrimSpace(u.Status))
+	switch u.Status {
+	case "", StatusResolved, StatusUnresolved, StatusPending:
+	default:
+		return Outcome{}, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("status must be %s, %s or %s", StatusResolved, StatusUnresolved, StatusPending))
+	}
+	if u.Status == "" && u.Churned == nil && u.FollowUpDone == nil && u.ResolvedAt == nil && u.Notes == "" {
+		return Outcome{}, apperr.New(apperr.InvalidInput, "", "outcome has nothing to record")
+	}
+
+	st, err := store.Default()
+	if err != nil {
+		return Outcome{}, err
+	}
+	now := time.Now().UTC()
+	o := Outcome{JobID: j.ID, Status: StatusPending, RecordedAt: now}
+	if err := st.Get(collection, j.ID, &o); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Outcome{}, fmt.Errorf("load outcome: %w", err)
+	}
+	if u.Status != "" {
+		o.Status = u.Status
+	}
+	if u.Churned != nil {
+		o.Churned = u.Churned
+	}
+	if u.FollowUpDone != nil {
+		o.FollowUpDone = u.FollowUpDone
+	}
+	if u.ResolvedAt != nil {
+		t := u.ResolvedAt.UTC()
+		o.ResolvedAt = &t
+	}
+	if o.Status == StatusResolved && o.ResolvedAt == nil {
+		o.ResolvedAt = &now
+	}
+	if u.Source != "" {
+		o.Source = u.Source
+	}
+	if u.Notes != "" {
+		if o.Notes, _, err = redact.Text(u.Notes); err != nil {
+			return Outcome{}, apperr.Wrap(apperr.Internal, "", "redact notes", err)
+		}
+	}
+	// the first snapshot is what the call was judged on; later updates must not move it
+	if o.Revisions == 0 {
+		o.Prediction = PredictionOf(j)
+	}
+	o.CallID = j.CallID
+	o.Revisions++
+	o.UpdatedAt = now
+	if err := st.Put(collection, j.ID, o); err != nil {
+		return Outcome{}, fmt.Errorf("save outcome: %w", err)
+	}
+	return o, nil
+}
+
+// Get returns the outcome of a job.
+func Get(jobID string) (Outcome, error) {
+	st, err := store.Default()
+	if err != nil {
+		return Outcome{}, err
+	}
+	var o Outcome
+	if err := st.Get(collection, jobID, &o); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return o, apperr.New(apperr.NotFound, "", "no outcome recorded for job")
+		}
+		return o, fmt.Errorf("load outcome: %w", err)
+	}
+	return o, nil
+}
+
+// List returns every stored outcome, most recent call first, optionally with one status.
+func List(status string) ([]Outcome, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	out := []Outcome{}
+	err = st.List(collection, func(id string, raw json.RawMessage) error {
+		var o Outcome
+		if err := json.Unmarshal(raw, &o); err != nil {
+			return fmt.Errorf("decode outcome %s: %w", id, err)
+		}
+		if status == "" || o.Status == status {
+			out = append(out, o)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Prediction.CallTime.After(out[j].Prediction.CallTime) })
+	return out, err
+}
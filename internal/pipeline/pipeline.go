@@ -48,20 +48,7 @@ package pipeline
 // 	enr := types.EnrichedRecord{
 // 		CallRecord:    r,
 // 		Extraction:    ext,
-// 		VintageBucket: vintageBucket(r.VintageMonth),
+// 		VintageBucket: cohortCfg.VintageBucket(r.VintageMonth), // cohort.Current()
 // 	}
 // 	return enr, nil
 // }
-
-// func vintageBucket(v int) string {
-// 	switch {
-// 	case v <= 2:
-// 		return "0-2M"
-// 	case v <= 6:
-// 		return "2-6M"
-// 	case v <= 12:
-// 		return "6-12M"
-// 	default:
-// 		return "12+M"
-// 	}
-// }
@@ -1,33 +1,86 @@
 package processor
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
+	"voice-insights-go/internal/anomaly"
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/churn"
+	"voice-insights-go/internal/classifier"
+	"voice-insights-go/internal/compliance"
+	"voice-insights-go/internal/customer"
+	"voice-insights-go/internal/dataset"
 	"voice-insights-go/internal/extractor"
+	"voice-insights-go/internal/grounding"
+	"voice-insights-go/internal/jobs"
 	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/notify"
+	"voice-insights-go/internal/ticketing"
+	"voice-insights-go/internal/transcript"
+	"voice-insights-go/internal/taxonomy"
 	"voice-insights-go/internal/transcription"
+	"voice-insights-go/internal/trends"
 	"voice-insights-go/internal/types"
 )
 
 // ProcessSingleCall advanced flow (returns types.KPIResult)
 // NOTE: The DatasetSummary is removed because insights now come purely from k-relevant search.
-func ProcessSingleCall(audioURL string, k int, timeout time.Duration) (types.KPIResult, error) {
-	log := logger.New().WithField("component", "processor")
+// timeout bounds the whole run on top of ctx; when either expires the work in flight is
+// abandoned and the partial result is returned with FailedStage set.
+func ProcessSingleCall(ctx context.Context, audioURL string, k int, timeout time.Duration) (types.KPIResult, error) {
+	return Process(ctx, jobs.New(audioURL, k, extractor.ModeFromEnv()), timeout)
+}
+
+// RetryJob resumes a stored job from its first stage that has not completed,
+// reusing the stored transcript and search results instead of calling the vendors again.
+// Only failed and partial jobs are retried; any other status is a Conflict.
+func RetryJob(ctx context.Context, id string, timeout time.Duration) (types.KPIResult, error) {
+	job, err := jobs.Get(id)
+	if errors.Is(err, jobs.ErrNotFound) {
+		return types.KPIResult{}, apperr.New(apperr.NotFound, "", "job "+id+" not found")
+	}
+	if err != nil {
+		return types.KPIResult{}, apperr.Wrap(apperr.Internal, "", "load job", err)
+	}
+	if job.Status != jobs.StatusFailed && job.Status != jobs.StatusPartial {
+		return types.KPIResult{}, apperr.New(apperr.Conflict, "", fmt.Sprintf("job %s is %s; only failed or partial jobs can be retried", id, job.Status))
+	}
+	return Process(ctx, job, timeout)
+}
+
+// Process runs (or resumes) job through transcription -> search -> extraction and persists
+// progress after every stage.
+func Process(ctx context.Context, job *jobs.Job, timeout time.Duration) (types.KPIResult, error) {
+	log := logger.New().WithField("component", "processor").WithField("job_id", job.ID)
 	start := time.Now()
 
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	job.Attempts++
+	job.Status = jobs.StatusRunning
+
 	// initialize a safe default KPIResult with v2-complete fields
 	res := types.KPIResult{
-		AudioURL:   audioURL,
-		Transcript: "",
+		AudioURL:   job.AudioURL,
+		Transcript: job.Artifacts.Transcript,
 		KPI:        emptyExtractionV2(),
 		Evidence:   map[string]interface{}{},
 		DurationMs: 0,
 		Error:      "",
+		AgentID:    job.AgentID,
+		Team:       job.Team,
+		CallTime:   job.At(),
+		JobID:      job.ID,
+		Stages:     job.Stages,
 	}
 
-	log = log.WithField("audio_url", audioURL)
+	log = log.WithField("audio_url", job.AudioURL).WithField("attempt", job.Attempts)
 	log.Info("processor start")
 
 	// -------------------------------------------------------------
@@ -46,49 +99,160 @@ Speaker 2: I understand sir, I will investigate.`
 			"mode": "mock",
 			"reason": "USE_MOCK_LLM=true",
 		}
+		for _, s := range types.Stages {
+			job.Mark(s, types.StageOK)
+		}
 
 		res.DurationMs = time.Since(start).Milliseconds()
 		log.Info("MOCK PROCESSOR: returning synthetic KPIResult")
-		return res, nil
+		return complete(job, res), nil
 	}
 
 	// -------------------------------------------------------------
-	// STEP 1 — TRANSCRIPTION
+	// STEP 1 — TRANSCRIPTION (skipped when a previous attempt stored it)
 	// -------------------------------------------------------------
-	tr, err := transcription.GetTranscript(audioURL)
-	if err != nil {
-		res.Error = fmt.Sprintf("transcription error: %v", err)
-		res.DurationMs = time.Since(start).Milliseconds()
-		log.WithError(err).Warn("transcription failed")
-		return res, err
+	if !job.Done(types.StageTranscription) {
+		tr, err := transcription.GetTranscript(ctx, job.AudioURL)
+		if err != nil {
+			return failStage(job, res, start, types.StageTranscription, fmt.Errorf("transcription error: %w", err))
+		}
+		job.Artifacts.Transcript = tr
+		job.Mark(types.StageTranscription, types.StageOK)
+		saveJob(job)
 	}
+	tr := job.Artifacts.Transcript
 	res.Transcript = tr
 	log.WithField("transcript_len", len(tr)).Info("got transcript")
 
 	// -------------------------------------------------------------
-	// STEP 2 — EXTRACTION (search + LLM)
+	// STEP 2 — SEARCH (k-relevant similar calls)
 	// -------------------------------------------------------------
-	kpiExtract, err := extractor.ExtractAdvanced(tr, k) // No dataset summary, extractor handles search internally
+	var searchResults any
+	if job.Done(types.StageSearch) {
+		if err := json.Unmarshal(job.Artifacts.SearchResults, &searchResults); err != nil {
+			// stored artifact is unusable; fall back to searching again
+			job.Mark(types.StageSearch, types.StagePending)
+		}
+	}
+	if !job.Done(types.StageSearch) {
+		sr, err := extractor.Search(ctx, tr, job.K)
+		if err != nil {
+			return failStage(job, res, start, types.StageSearch, fmt.Errorf("search API failed: %w", err))
+		}
+		searchResults = sr
+		job.Artifacts.SearchResults, _ = json.Marshal(sr)
+		job.Mark(types.StageSearch, types.StageOK)
+		saveJob(job)
+	}
+
+	// -------------------------------------------------------------
+	// STEP 3 — EXTRACTION (LLM)
+	// -------------------------------------------------------------
+	// a registered dataset's persisted summary gives the trend fields historical context
+	promptEvidence := searchResults
+	if job.DatasetID != "" {
+		if d, err := dataset.GetRegistered(job.DatasetID); err != nil {
+			log.WithError(err).WithField("dataset_id", job.DatasetID).Warn("dataset summary unavailable; extracting without history")
+		} else {
+			promptEvidence = extractor.WithHistory(searchResults, d.Summary)
+		}
+	}
+	// a linked caller's earlier contacts tell the model whether this is a repeat issue
+	customerOpts := customer.OptionsFromEnv()
+	prior, err := customer.PriorContacts(job, customerOpts)
 	if err != nil {
-		res.Error = fmt.Sprintf("llm extraction error: %v", err)
-		res.DurationMs = time.Since(start).Milliseconds()
-		log.WithError(err).Warn("llm extraction failed")
-		return res, err
+		log.WithError(err).WithField("customer_id", job.CustomerID).Warn("prior contacts unavailable; extracting without them")
+	}
+	promptEvidence = extractor.WithPriorContacts(promptEvidence, customer.PromptContext(prior, customerOpts))
+
+	var kpiExtract types.KPIExtraction
+	if job.Mode == extractor.ModeMultiPass {
+		// a partial earlier attempt keeps its good blocks; only the failed ones are re-asked
+		base, only := emptyExtractionV2(), []string(nil)
+		if job.Stages[types.StageLLM] == types.StagePartial {
+			base, only = job.Result.KPI, failedBlocks(job.Result.Blocks)
+			// the earlier attempt's rule flags are re-derived by the audit below
+			if rules, err := compliance.Load(); err == nil {
+				compliance.StripRuleFlags(&base, rules)
+			}
+		}
+		ext, blocks, err := extractor.ExtractMultiPass(ctx, tr, promptEvidence, base, only)
+		res.Blocks = mergeBlocks(job.Result.Blocks, blocks)
+		if err != nil {
+			return failStage(job, res, start, types.StageLLM, fmt.Errorf("llm extraction error: %w", err))
+		}
+		kpiExtract = ext
+		job.Mark(types.StageLLM, types.StageOK)
+		if len(failedBlocks(res.Blocks)) > 0 {
+			job.Mark(types.StageLLM, types.StagePartial)
+		}
+	} else {
+		ext, err := extractor.ExtractFromSearch(ctx, tr, promptEvidence)
+		if err != nil {
+			return failStage(job, res, start, types.StageLLM, fmt.Errorf("llm extraction error: %w", err))
+		}
+		kpiExtract = ext
+		job.Mark(types.StageLLM, types.StageOK)
 	}
 
 	// ensure nil-slices are not nil
 	normalizeExtractionV2(&kpiExtract)
 
+	// tie every list item back to what was actually said; unverifiable items are flagged or dropped
+	groundingReport := grounding.Verify(&kpiExtract, tr, grounding.OptionsFromEnv())
+	log.WithField("verified", groundingReport.Verified).WithField("unverified", len(groundingReport.Unverified)).Info("grounding verified")
+
+	// deterministic script audit; failed rules are merged into the model's compliance flags
+	if rules, err := compliance.Load(); err != nil {
+		log.WithError(err).Error("compliance rules unavailable; skipping audit")
+	} else {
+		complianceReport := compliance.Check(tr, rules)
+		compliance.MergeLLMFlags(&complianceReport, &kpiExtract)
+		res.Compliance = &complianceReport
+	}
+
+	// stable categories for reporting; the raw strings stay in the extraction
+	issues := taxonomy.Classify(ctx, kpiExtract)
+	res.Issues = &issues
+	res.Repeat = customer.Detect(job, prior, issues.PrimaryIssue.NodeID, customerOpts)
+
+	// transcript-level category and confusion, same classifier as the dataset summary
+	if c, err := classifier.FromEnv(); err != nil {
+		log.WithError(err).Error("classifier unavailable; skipping classification")
+	} else if cls, err := c.Classify(ctx, tr); err != nil {
+		log.WithError(err).WithField("classifier", c.Name()).Warn("classification failed")
+	} else {
+		res.Classification = &cls
+		if job.DatasetID != "" {
+			if err := dataset.AddProcessedCall(job.DatasetID, job.ID, cls); err != nil {
+				log.WithError(err).WithField("dataset_id", job.DatasetID).Warn("failed to add call to dataset summary")
+			}
+		}
+	}
+
+	// a trained churn model scores the call next to the LLM; the extraction keeps the LLM's
+	// risk_of_churn, so retries and calibration never see a blended value as the LLM's, and
+	// everything acting on churn risk reads the blend through res.ChurnRisk()
+	in := churn.Input{KPI: kpiExtract, Repeat: res.Repeat, VintageMonths: job.VintageMonths, City: job.City}
+	if pred, err := churn.Score(in); err != nil {
+		log.WithError(err).Warn("churn model unavailable; scoring with the llm risk_of_churn only")
+	} else {
+		res.Churn = pred
+	}
+
 	res.KPI = kpiExtract
 	log.WithField("primary_issue", kpiExtract.CustomerProblem.PrimaryIssue).Info("llm extracted KPI")
 
 	// -------------------------------------------------------------
-	// STEP 3 — EVIDENCE BLOCK (search-based)
+	// STEP 4 — EVIDENCE BLOCK (search-based)
 	// -------------------------------------------------------------
 	res.Evidence = map[string]interface{}{
 		"insight_source":  "k-relevant-search",
 		"transcript_chars": len(tr),
+		"transcript_est_tokens": transcript.EstimateTokens(tr),
+		"transcript_condensed":  extractor.NeedsChunking(tr, promptEvidence),
 		"has_trends":       true,
+		"grounding":        groundingReport,
 		"similarity_info": map[string]interface{}{
 			"similar_calls_count": kpiExtract.TrendInsights.SimilarCallsCount,
 			"dominant_issue":      kpiExtract.TrendInsights.DominantIssueCategory,
@@ -101,13 +265,84 @@ Speaker 2: I understand sir, I will investigate.`
 		"duration_ms": res.DurationMs,
 	}).Info("processor completed")
 
-	return res, nil
+	return complete(job, res), nil
 }
 
 /* ------------------------------------------------------------
    HELPERS
 ------------------------------------------------------------ */
 
+// failStage records which stage aborted the run, persists the partial job so it can be
+// retried, and returns the partial result with the typed error the API maps to a status.
+func failStage(job *jobs.Job, res types.KPIResult, start time.Time, stage types.Stage, err error) (types.KPIResult, error) {
+	typed := apperr.Ensure(err, stage)
+	res.Error = err.Error()
+	res.FailedStage = stage
+	res.ErrorInfo = apperr.Info(typed)
+	res.DurationMs = time.Since(start).Milliseconds()
+	logger.New().WithField("component", "processor").WithField("stage", stage).WithField("kind", typed.Kind).WithError(err).Warn("pipeline stage failed")
+
+	job.Mark(stage, types.StageFailed)
+	job.Status = jobs.StatusFailed
+	job.Result = res
+	saveJob(job)
+	return res, typed
+}
+
+// complete stores the finished result on the job, folds it into the trend rollups, checks
+// its issue for a spike and sends the notifications its rules call for.
+func complete(job *jobs.Job, res types.KPIResult) types.KPIResult {
+	job.Status = jobs.StatusCompleted
+	if job.Stages[types.StageLLM] == types.StagePartial {
+		job.Status = jobs.StatusPartial
+	}
+	job.Result = res
+	saveJob(job)
+	log := logger.New().WithField("component", "processor").WithField("job_id", job.ID)
+	if err := trends.Record(job); err != nil {
+		log.WithError(err).Error("failed to record call in trend rollups")
+	} else if _, err := anomaly.CheckCall(job, anomaly.OptionsFromEnv()); err != nil {
+		log.WithError(err).Error("spike check failed")
+	}
+	if _, err := notify.Dispatch(job); err != nil {
+		log.WithError(err).Error("notification dispatch failed")
+	}
+	if err := ticketing.Dispatch(job); err != nil {
+		log.WithError(err).Error("ticket dispatch failed")
+	}
+	return res
+}
+
+// failedBlocks lists the blocks of a multi-pass run that did not succeed.
+func failedBlocks(blocks map[string]types.BlockStatus) []string {
+	var out []string
+	for k, b := range blocks {
+		if b.Status != types.StageOK {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// mergeBlocks overlays the statuses of a retry onto those of earlier attempts.
+func mergeBlocks(prev, next map[string]types.BlockStatus) map[string]types.BlockStatus {
+	out := map[string]types.BlockStatus{}
+	for k, v := range prev {
+		out[k] = v
+	}
+	for k, v := range next {
+		out[k] = v
+	}
+	return out
+}
+
+// saveJob persists progress; a store failure only costs resumability, so it is logged, not returned.
+func saveJob(job *jobs.Job) {
+	if err := jobs.Save(job); err != nil {
+		logger.New().WithField("component", "processor").WithField("job_id", job.ID).WithError(err).Error("failed to persist job")
+	}
+}
+
 // returns a fully zeroed Schema v2 extraction object
 func emptyExtractionV2() types.KPIExtraction {
 	return types.KPIExtraction{
@@ -119,6 +354,7 @@ func emptyExtractionV2() types.KPIExtraction {
 		ConversationQuality:  types.ConversationQuality{RedFlags: []string{}},
 		TrendInsights:        types.TrendInsights{},
 		BusinessImpact:       types.BusinessImpact{},
+		Grounding:            []types.GroundedFinding{},
 	}
 }
 
@@ -151,6 +387,9 @@ func normalizeExtractionV2(x *types.KPIExtraction) {
 	if x.ConversationQuality.RedFlags == nil {
 		x.ConversationQuality.RedFlags = []string{}
 	}
+	if x.Grounding == nil {
+		x.Grounding = []types.GroundedFinding{}
+	}
 }
 
 // synthetic mock object (schema v2)
@@ -0,0 +1,365 @@
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind names a category of personal data; it is also the label used in placeholders.
+type Kind string
+
+const (
+	Phone       Kind = "PHONE"
+	Email       Kind = "EMAIL"
+	GSTIN       Kind = "GSTIN"
+	PAN         Kind = "PAN"
+	Aadhaar     Kind = "AADHAAR"
+	BankAccount Kind = "BANK_ACCOUNT"
+	IFSC        Kind = "IFSC"
+	UPI         Kind = "UPI"
+)
+
+// Mode selects what a detected value is replaced with.
+type Mode string
+
+const (
+	ModeOff      Mode = "off"      // leave text untouched
+	ModeMask     Mode = "mask"     // [PHONE]
+	ModeHash     Mode = "hash"     // [PHONE:1a2b3c4d5e6f], stable per value, not reversible
+	ModeTokenize Mode = "tokenize" // [PHONE:tok_...], reversible through the vault
+)
+
+type detector struct {
+	kind  Kind
+	re    *regexp.Regexp
+	valid func(string) bool // optional extra check on the match
+}
+
+// spokenDigit maps digit words, English and Hindi (romanized and Devanagari), to digits.
+var spokenDigit = map[string]byte{
+	"zero": '0', "one": '1', "two": '2', "three": '3', "four": '4', "five": '5', "six": '6', "seven": '7', "eight": '8', "nine": '9',
+	"shunya": '0', "shoonya": '0', "ek": '1', "do": '2', "teen": '3', "tin": '3', "char": '4', "chaar": '4', "paanch": '5', "panch": '5',
+	"chhe": '6', "chhah": '6', "che": '6', "saat": '7', "sat": '7', "aath": '8', "nau": '9',
+	"शून्य": '0', "एक": '1', "दो": '2', "तीन": '3', "चार": '4', "पांच": '5', "पाँच": '5', "छह": '6', "छः": '6', "सात": '7', "आठ": '8', "नौ": '9',
+}
+
+// spokenNumber matches a run of at least five spoken digits ("nine eight double seven ...",
+// "nau aath saat ..."), single numerals allowed among them. The detectors using it check
+// how many digits the run stands for.
+var spokenNumber = func() *regexp.Regexp {
+	words := make([]string, 0, len(spokenDigit)+1)
+	for w := range spokenDigit {
+		words = append(words, regexp.QuoteMeta(w))
+	}
+	// longest first, so a word is never cut short by another that is its prefix
+	sort.Slice(words, func(i, j int) bool {
+		return len(words[i]) > len(words[j]) || len(words[i]) == len(words[j]) && words[i] < words[j]
+	})
+	digit := `(?:(?:double|triple)\s+)?(?:` + strings.Join(append(words, `[0-9]`), "|") + `)`
+	return regexp.MustCompile(`(?i)` + digit + `(?:[\s,\-]+` + digit + `){4,17}`)
+}()
+
+// detectors run in priority order; a later detector never replaces text an earlier one claimed.
+// Email runs before UPI (a UPI handle has no dot in its domain), GSTIN before PAN (a GSTIN
+// embeds a PAN) and Aadhaar/phone before the generic bank account digit run. A kind may
+// have several detectors: account numbers are also read out in groups or as digit words.
+var detectors = []detector{
+	{kind: Email, re: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)},
+	{kind: UPI, re: regexp.MustCompile(`[A-Za-z0-9._\-]{2,256}@[A-Za-z]{2,64}`)},
+	{kind: GSTIN, re: regexp.MustCompile(`(?i)\d{2}[a-z]{5}\d{4}[a-z][1-9a-z]z[0-9a-z]`)},
+	{kind: PAN, re: regexp.MustCompile(`(?i)[a-z]{3}[abcfghljpt][a-z]\d{4}[a-z]`)},
+	{kind: IFSC, re: regexp.MustCompile(`(?i)[a-z]{4}0[a-z0-9]{6}`), valid: ifsc},
+	{kind: Aadhaar, re: regexp.MustCompile(`[2-9]\d{3}[ \-]?\d{4}[ \-]?\d{4}`), valid: verhoeff},
+	{kind: Phone, re: regexp.MustCompile(`(?:\+91[ \-]?|0091[ \-]?|0)?[6-9]\d{4}[ \-]?\d{5}`)},
+	{kind: BankAccount, re: regexp.MustCompile(`\d{9,18}`)},
+	{kind: BankAccount, re: regexp.MustCompile(`\d{3,6}(?:[ \-]\d{2,6}){1,5}`), valid: digits(9, 18)},
+	{kind: Aadhaar, re: spokenNumber, valid: func(s string) bool { return len(Normalize(Aadhaar, s)) == 12 && verhoeff(s) }},
+	{kind: Phone, re: spokenNumber, valid: spokenPhone},
+	{kind: BankAccount, re: spokenNumber, valid: digits(9, 18)},
+}
+
+// AllKinds lists every detector kind in priority order.
+func AllKinds() []Kind {
+	out := make([]Kind, 0, len(detectors))
+	seen := map[Kind]bool{}
+	for _, d := range detectors {
+		if !seen[d.kind] {
+			seen[d.kind] = true
+			out = append(out, d.kind)
+		}
+	}
+	return out
+}
+
+// Config controls a redaction run.
+type Config struct {
+	Mode    Mode
+	Kinds   map[Kind]bool // nil = all kinds
+	HashKey []byte        // HMAC key for ModeHash
+	Vault   *Vault        // required for ModeTokenize
+}
+
+// ConfigFromEnv reads PII_REDACTION_MODE (off|mask|hash|tokenize, default mask),
+// PII_REDACT_KINDS (comma-separated kinds, default all), PII_HASH_KEY (falls back to
+// PII_VAULT_KEY) and PII_VAULT_KEY. A mode whose key is missing is an error rather than a
+// silent downgrade, so a misconfigured deployment never sends raw values out.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Mode: Mode(strings.ToLower(strings.TrimSpace(os.Getenv("PII_REDACTION_MODE"))))}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeMask
+	}
+	switch cfg.Mode {
+	case ModeOff, ModeMask:
+	case ModeHash:
+		key := os.Getenv("PII_HASH_KEY")
+		if key == "" {
+			key = os.Getenv("PII_VAULT_KEY")
+		}
+		if key == "" {
+			return cfg, fmt.Errorf("PII_REDACTION_MODE=hash requires PII_HASH_KEY or PII_VAULT_KEY")
+		}
+		cfg.HashKey = []byte(key)
+	case ModeTokenize:
+		v, err := DefaultVault()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Vault = v
+	default:
+		return cfg, fmt.Errorf("unknown PII_REDACTION_MODE %q", cfg.Mode)
+	}
+
+	if list := os.Getenv("PII_REDACT_KINDS"); list != "" {
+		cfg.Kinds = map[Kind]bool{}
+		for _, k := range strings.Split(list, ",") {
+			cfg.Kinds[Kind(strings.ToUpper(strings.TrimSpace(k)))] = true
+		}
+	}
+	return cfg, nil
+}
+
+// Report counts what a run replaced. It never contains the values themselves.
+type Report struct {
+	Mode   Mode         `json:"mode"`
+	Counts map[Kind]int `json:"counts"`
+}
+
+// Total is the number of replaced values.
+func (r Report) Total() int {
+	n := 0
+	for _, c := range r.Counts {
+		n += c
+	}
+	return n
+}
+
+type span struct {
+	start, end int
+	kind       Kind
+}
+
+// Redact replaces every detected value in text according to cfg.
+func Redact(text string, cfg Config) (string, Report, error) {
+	rep := Report{Mode: cfg.Mode, Counts: map[Kind]int{}}
+	if cfg.Mode == ModeOff || text == "" {
+		return text, rep, nil
+	}
+
+	var spans []span
+	for _, d := range detectors {
+		if cfg.Kinds != nil && !cfg.Kinds[d.kind] {
+			continue
+		}
+		for _, loc := range d.re.FindAllStringIndex(text, -1) {
+			s := span{loc[0], loc[1], d.kind}
+			if !standalone(text, s) || (d.valid != nil && !d.valid(text[s.start:s.end])) || overlaps(spans, s) {
+				continue
+			}
+			spans = append(spans, s)
+		}
+	}
+	if len(spans) == 0 {
+		return text, rep, nil
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+	var b strings.Builder
+	last := 0
+	for _, s := range spans {
+		ph, err := placeholder(s.kind, text[s.start:s.end], cfg)
+		if err != nil {
+			return "", rep, err
+		}
+		b.WriteString(text[last:s.start])
+		b.WriteString(ph)
+		last = s.end
+		rep.Counts[s.kind]++
+	}
+	b.WriteString(text[last:])
+	return b.String(), rep, nil
+}
+
+// Text redacts text with the configuration from the environment.
+func Text(text string) (string, Report, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return "", Report{Mode: cfg.Mode}, err
+	}
+	return Redact(text, cfg)
+}
+
+func placeholder(kind Kind, value string, cfg Config) (string, error) {
+	switch cfg.Mode {
+	case ModeHash:
+		return fmt.Sprintf("[%s:%s]", kind, Hash(cfg.HashKey, kind, value)), nil
+	case ModeTokenize:
+		tok, err := cfg.Vault.Tokenize(kind, value)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("[%s:%s]", kind, tok), nil
+	default:
+		return "[" + string(kind) + "]", nil
+	}
+}
+
+// Hash returns a short keyed digest of the normalized value, so the same number spoken with
+// different spacing or prefixes maps to the same placeholder across calls.
+func Hash(key []byte, kind Kind, value string) string {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(string(kind) + ":" + Normalize(kind, value)))
+	return hex.EncodeToString(m.Sum(nil))[:12]
+}
+
+// Normalize canonicalizes a detected value: digit words turned into digits, separators
+// removed, case folded, and the country/trunk prefix stripped from phone numbers.
+func Normalize(kind Kind, value string) string {
+	if (kind == Phone || kind == Aadhaar || kind == BankAccount) && strings.IndexFunc(value, unicode.IsLetter) >= 0 {
+		value = spokenDigits(value)
+	}
+	v := strings.ToLower(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' {
+			return -1
+		}
+		return r
+	}, value))
+	if kind == Phone {
+		v = strings.TrimPrefix(v, "+")
+		if len(v) > 10 {
+			v = v[len(v)-10:]
+		}
+	}
+	return v
+}
+
+// standalone rejects matches glued to surrounding letters or digits (e.g. the middle of a
+// longer number or word); Go's regexp has no lookbehind, so the check is done here.
+func standalone(text string, s span) bool {
+	if s.start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:s.start])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	if s.end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[s.end:])
+		if isWordRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func overlaps(spans []span, s span) bool {
+	for _, o := range spans {
+		if s.start < o.end && o.start < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// ifsc checks the branch code (the six characters after the bank code and the 0) has a
+// digit, as nearly every real branch code does, so words such as "ABCD0EFGHIJ" are left.
+func ifsc(s string) bool {
+	return strings.IndexFunc(s[5:], unicode.IsDigit) >= 0
+}
+
+// digits accepts a value standing for min to max digits.
+func digits(min, max int) func(string) bool {
+	return func(s string) bool {
+		n := Normalize(BankAccount, s)
+		return len(n) >= min && len(n) <= max && strings.Trim(n, "0123456789") == ""
+	}
+}
+
+// spokenPhone accepts a spoken run that reads as a mobile number, with or without the
+// 0 or 91 prefix.
+func spokenPhone(s string) bool {
+	n := spokenDigits(s)
+	switch {
+	case len(n) == 12 && strings.HasPrefix(n, "91"):
+		n = n[2:]
+	case len(n) == 11 && n[0] == '0':
+		n = n[1:]
+	}
+	return len(n) == 10 && n[0] >= '6' && strings.Trim(n, "0123456789") == ""
+}
+
+// spokenDigits turns a run of digit words into the digits it reads as, expanding "double"
+// and "triple". Words it does not know are kept, lowercased.
+func spokenDigits(s string) string {
+	var b strings.Builder
+	repeat := 1
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return unicode.IsSpace(r) || r == ',' || r == '-' }) {
+		switch d, ok := spokenDigit[w]; {
+		case w == "double":
+			repeat = 2
+			continue
+		case w == "triple":
+			repeat = 3
+			continue
+		case ok:
+			w = string(d)
+		}
+		b.WriteString(strings.Repeat(w, repeat))
+		repeat = 1
+	}
+	return b.String()
+}
+
+// verhoeff validates the Aadhaar check digit so arbitrary 12-digit numbers are not
+// reported as Aadhaar (they still fall through to the bank account detector).
+func verhoeff(s string) bool {
+	d := [10][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 2, 3, 4, 0, 6, 7, 8, 9, 5},
+		{2, 3, 4, 0, 1, 7, 8, 9, 5, 6}, {3, 4, 0, 1, 2, 8, 9, 5, 6, 7},
+		{4, 0, 1, 2, 3, 9, 5, 6, 7, 8}, {5, 9, 8, 7, 6, 0, 4, 3, 2, 1},
+		{6, 5, 9, 8, 7, 1, 0, 4, 3, 2}, {7, 6, 5, 9, 8, 2, 1, 0, 4, 3},
+		{8, 7, 6, 5, 9, 3, 2, 1, 0, 4}, {9, 8, 7, 6, 5, 4, 3, 2, 1, 0},
+	}
+	p := [8][10]int{
+		{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, {1, 5, 7, 6, 2, 8, 3, 0, 9, 4},
+		{5, 8, 0, 3, 7, 9, 6, 1, 4, 2}, {8, 9, 1, 6, 0, 4, 3, 5, 2, 7},
+		{9, 4, 5, 3, 1, 2, 6, 8, 7, 0}, {4, 2, 8, 6, 5, 7, 3, 9, 0, 1},
+		{2, 7, 9, 3, 8, 0, 6, 4, 1, 5}, {7, 0, 4, 6, 9, 1, 3, 2, 5, 8},
+	}
+	digits := Normalize(Aadhaar, s)
+	c := 0
+	for i := 0; i < len(digits); i++ {
+		c = d[c][p[i%8][digits[len(digits)-1-i]-'0']]
+	}
+	return c == 0
+}
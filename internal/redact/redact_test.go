@@ -0,0 +1,110 @@
+package redact
+
+import "testing"
+
+func TestRedactMask(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"email", "mail me at ravi.k@example.co.in please", "mail me at [EMAIL] please"},
+		{"upi", "pay to ravi@okaxis", "pay to [UPI]"},
+		{"pan", "PAN is ABCPK1234F", "PAN is [PAN]"},
+		{"gstin not pan", "GST 27ABCPK1234F1Z5 registered", "GST [GSTIN] registered"},
+		{"ifsc", "IFSC HDFC0001234 hai", "IFSC [IFSC] hai"},
+		{"ifsc lowercase", "ifsc sbin0012345", "ifsc [IFSC]"},
+		{"ifsc without branch digits is a word", "code ABCD0EFGHIJ", "code ABCD0EFGHIJ"},
+		{"phone", "call 98765 43210 now", "call [PHONE] now"},
+		{"phone with country code", "+91-9876543210", "[PHONE]"},
+		{"aadhaar with valid check digit", "Aadhaar 2341 2341 2346", "Aadhaar [AADHAAR]"},
+		{"twelve digits failing verhoeff are an account", "number 234123412345", "number [BANK_ACCOUNT]"},
+		{"account digits", "account 123456789012345", "account [BANK_ACCOUNT]"},
+		{"account in groups", "account 1234 5678 9012 34 hai", "account [BANK_ACCOUNT] hai"},
+		{"account with hyphens", "account 123-456-789-012", "account [BANK_ACCOUNT]"},
+		{"spoken phone", "call me on nine eight double seven six five four three two one", "call me on [PHONE]"},
+		{"spoken hinglish phone", "number hai nau aath saat chhe paanch chaar teen do ek shunya", "number hai [PHONE]"},
+		{"spoken devanagari account", "खाता एक दो तीन चार पांच छह सात आठ नौ शून्य एक है", "खाता [BANK_ACCOUNT] है"},
+		{"short spoken run is kept", "ek do teen char paanch", "ek do teen char paanch"},
+		{"small numbers are kept", "in 2023 I paid 500 rupees", "in 2023 I paid 500 rupees"},
+		{"glued to a word is kept", "ref ABC9876543210", "ref ABC9876543210"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := Redact(tt.in, Config{Mode: ModeMask})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactKindsAndCounts(t *testing.T) {
+	in := "mail a@b.com, phone 9876543210, IFSC HDFC0001234"
+	got, rep, err := Redact(in, Config{Mode: ModeMask, Kinds: map[Kind]bool{Phone: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "mail a@b.com, phone [PHONE], IFSC HDFC0001234"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if rep.Total() != 1 || rep.Counts[Phone] != 1 {
+		t.Errorf("counts = %v, want one phone", rep.Counts)
+	}
+}
+
+func TestHashIsStableAcrossSpellings(t *testing.T) {
+	key := []byte("k")
+	tests := []struct {
+		name string
+		kind Kind
+		a, b string
+	}{
+		{"phone prefix and spacing", Phone, "+91 98765 43210", "9876543210"},
+		{"spoken phone", Phone, "nine eight double seven six five four three two one", "9877654321"},
+		{"account groups", BankAccount, "1234 5678 9012", "123456789012"},
+		{"ifsc case", IFSC, "hdfc0001234", "HDFC0001234"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ha, hb := Hash(key, tt.kind, tt.a), Hash(key, tt.kind, tt.b); ha != hb {
+				t.Errorf("Hash(%q) = %s, Hash(%q) = %s", tt.a, ha, tt.b, hb)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		in   string
+		want string
+	}{
+		{Phone, "0091 98765-43210", "9876543210"},
+		{Phone, "triple nine eight seven six five four three two", "9998765432"},
+		{BankAccount, "nau, aath, saat, chhe", "9876"},
+		{Aadhaar, "2341-2341-2346", "234123412346"},
+		{Email, "Ravi@Example.com", "ravi@example.com"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.kind, tt.in); got != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, want %q", tt.kind, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestAllKindsListsEachKindOnce(t *testing.T) {
+	seen := map[Kind]bool{}
+	for _, k := range AllKinds() {
+		if seen[k] {
+			t.Errorf("kind %s listed twice", k)
+		}
+		seen[k] = true
+	}
+	if got := len(seen); got != 8 {
+		t.Errorf("got %d kinds %v, want 8", got, AllKinds())
+	}
+}
@@ -0,0 +1,150 @@
+package redact
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/store"
+)
+
+const vaultCollection = "pii_vault"
+
+// Vault maps tokens back to the values they replaced. Values are encrypted with AES-GCM
+// under a key derived from PII_VAULT_KEY, so the data directory alone does not reveal them.
+type Vault struct {
+	st     *store.Store
+	aead   cipher.AEAD
+	idKey  []byte
+	keyTag string // identifies the key an entry was sealed with
+}
+
+type vaultEntry struct {
+	Kind       Kind      `json:"kind"`
+	KeyTag     string    `json:"key_tag"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewVault opens a vault in st keyed by secret.
+func NewVault(st *store.Store, secret string) (*Vault, error) {
+	if secret == "" {
+		return nil, errors.New("empty vault key")
+	}
+	sum := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	m := hmac.New(sha256.New, sum[:])
+	m.Write([]byte("token-id"))
+	idKey := m.Sum(nil)
+	return &Vault{st: st, aead: aead, idKey: idKey, keyTag: hex.EncodeToString(idKey[:4])}, nil
+}
+
+var (
+	defaultVault     *Vault
+	defaultVaultErr  error
+	defaultVaultOnce sync.Once
+)
+
+// DefaultVault opens the vault in the default store with PII_VAULT_KEY.
+func DefaultVault() (*Vault, error) {
+	defaultVaultOnce.Do(func() {
+		secret := os.Getenv("PII_VAULT_KEY")
+		if secret == "" {
+			defaultVaultErr = errors.New("PII_VAULT_KEY is required for tokenization")
+			return
+		}
+		st, err := store.Default()
+		if err != nil {
+			defaultVaultErr = err
+			return
+		}
+		defaultVault, defaultVaultErr = NewVault(st, secret)
+	})
+	return defaultVault, defaultVaultErr
+}
+
+// Tokenize stores value and returns its token. Tokens are deterministic per value and key,
+// so repeated mentions (and re-processing the same call) reuse one vault entry.
+func (v *Vault) Tokenize(kind Kind, value string) (string, error) {
+	m := hmac.New(sha256.New, v.idKey)
+	m.Write([]byte(string(kind) + ":" + Normalize(kind, value)))
+	tok := "tok_" + hex.EncodeToString(m.Sum(nil))[:16]
+
+	var existing vaultEntry
+	if err := v.st.Get(vaultCollection, tok, &existing); err == nil {
+		return tok, nil
+	} else if !errors.Is(err, store.ErrNotFound) {
+		return "", fmt.Errorf("vault lookup: %w", err)
+	}
+
+	nonce := make([]byte, v.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("vault nonce: %w", err)
+	}
+	e := vaultEntry{
+		Kind:       kind,
+		KeyTag:     v.keyTag,
+		Nonce:      nonce,
+		Ciphertext: v.aead.Seal(nil, nonce, []byte(value), []byte(tok)),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := v.st.Put(vaultCollection, tok, e); err != nil {
+		return "", fmt.Errorf("vault write: %w", err)
+	}
+	return tok, nil
+}
+
+// Reveal returns the original value behind tok.
+func (v *Vault) Reveal(tok string) (string, error) {
+	var e vaultEntry
+	if err := v.st.Get(vaultCollection, tok, &e); err != nil {
+		return "", err
+	}
+	if e.KeyTag != v.keyTag {
+		return "", fmt.Errorf("token %s was sealed with a different vault key", tok)
+	}
+	plain, err := v.aead.Open(nil, e.Nonce, e.Ciphertext, []byte(tok))
+	if err != nil {
+		return "", fmt.Errorf("decrypt %s: %w", tok, err)
+	}
+	return string(plain), nil
+}
+
+var tokenRe = regexp.MustCompile(`\[([A-Z_]+):(tok_[0-9a-f]{16})\]`)
+
+// Detokenize replaces every vault token in text with its original value and returns how
+// many were revealed. Tokens missing from the vault are left in place.
+func (v *Vault) Detokenize(text string) (string, int, error) {
+	var firstErr error
+	n := 0
+	out := tokenRe.ReplaceAllStringFunc(text, func(m string) string {
+		tok := tokenRe.FindStringSubmatch(m)[2]
+		val, err := v.Reveal(tok)
+		if err != nil {
+			if !errors.Is(err, store.ErrNotFound) && firstErr == nil {
+				firstErr = err
+			}
+			return m
+		}
+		n++
+		return val
+	})
+	return out, n, firstErr
+}
@@ -0,0 +1,310 @@
+package scorecard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/types"
+)
+
+// Window is the half-open time range [From, To) that calls are aggregated over.
+type Window struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// LastDays is the window ending now and covering the previous n days.
+func LastDays(n int, now time.Time) Window {
+	return Window{From: now.AddDate(0, 0, -n), To: now}
+}
+
+// IST is the zone calendar days and weeks are cut in, on scorecards as on trend series, and
+// the zone of dataset timestamps that carry none.
+var IST = time.FixedZone("IST", 5*3600+1800)
+
+// Week is the Monday-to-Monday (IST) week containing day.
+func Week(day time.Time) Window {
+	y, m, d := day.In(IST).Date()
+	midnight := time.Date(y, m, d, 0, 0, 0, 0, IST)
+	offset := (int(midnight.Weekday()) + 6) % 7 // days since Monday
+	from := midnight.AddDate(0, 0, -offset)
+	return Window{From: from, To: from.AddDate(0, 0, 7)}
+}
+
+// Contains reports whether t falls in the window.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.From) && t.Before(w.To)
+}
+
+// Count is a recurring item and how many calls it appeared in.
+type Count struct {
+	Item  string `json:"item"`
+	Count int    `json:"count"`
+}
+
+// Scorecard aggregates one agent's processed calls over a window. Percentile ranks are
+// 0–100 against the other agents of the same team over the same window.
+type Scorecard struct {
+	AgentID string `json:"agent_id"`
+	Team    string `json:"team"`
+	Window  Window `json:"window"`
+	Calls   int    `json:"calls"`
+
+	AvgRapport              float64 `json:"avg_rapport_score"`
+	AvgProfessionalism      float64 `json:"avg_professionalism_score"`
+	AvgSolutionAccuracy     float64 `json:"avg_solution_accuracy_score"`
+	AvgResolutionLikelihood float64 `json:"avg_resolution_likelihood"`
+
+	// CompliancePassRate is passed / evaluated over every rule of every audited call
+	CompliancePassRate float64            `json:"compliance_pass_rate"`
+	ComplianceByRule   map[string]float64 `json:"compliance_by_rule"`
+
+	MissedOpportunities    int     `json:"missed_opportunities"`
+	MissedPerCall          float64 `json:"missed_opportunities_per_call"`
+	TopMissedOpportunities []Count `json:"top_missed_opportunities"`
+
+	// Composite is the mean of the three agent scores, compliance pass rate and resolution likelihood
+	Composite      float64            `json:"composite_score"`
+	PercentileRank float64            `json:"percentile_rank"`
+	Percentiles    map[string]float64 `json:"metric_percentiles"`
+	TeamAgents     int                `json:"team_agents"`
+}
+
+// metrics are the per-agent values ranked against the team.
+func (s Scorecard) metrics() map[string]float64 {
+	return map[string]float64{
+		"rapport":               s.AvgRapport,
+		"professionalism":       s.AvgProfessionalism,
+		"solution_accuracy":     s.AvgSolutionAccuracy,
+		"resolution_likelihood": s.AvgResolutionLikelihood,
+		"compliance_pass_rate":  s.CompliancePassRate,
+		// fewer missed opportunities is better, so rank the negation
+		"missed_per_call": -s.MissedPerCall,
+	}
+}
+
+const unassignedTeam = "unassigned"
+
+// Scored reports whether j counts toward the scorecards of w: it has an agent id, happened
+// in w and got through extraction.
+func Scored(j *jobs.Job, w Window) bool {
+	return j.AgentID != "" && w.Contains(j.At()) && (j.Status == jobs.StatusCompleted || j.Status == jobs.StatusPartial)
+}
+
+// Build computes scorecards for every agent with a completed call in w, ranked within team.
+// Jobs that are not Scored are ignored.
+func Build(all []*jobs.Job, w Window) []Scorecard {
+	type acc struct {
+		card       Scorecard
+		lastSeen   time.Time
+		passed     int
+		evaluated  int
+		rulePassed map[string]int
+		ruleTotal  map[string]int
+		missed     map[string]int
+		missedText map[string]string
+	}
+	byAgent := map[string]*acc{}
+	for _, j := range all {
+		if !Scored(j, w) {
+			continue
+		}
+		a := byAgent[j.AgentID]
+		if a == nil {
+			a = &acc{
+				card:       Scorecard{AgentID: j.AgentID, Window: w},
+				rulePassed: map[string]int{}, ruleTotal: map[string]int{},
+				missed: map[string]int{}, missedText: map[string]string{},
+			}
+			byAgent[j.AgentID] = a
+		}
+		// an agent who moved teams is reported under the team of their latest call
+		if j.Team != "" && !j.At().Before(a.lastSeen) {
+			a.card.Team, a.lastSeen = j.Team, j.At()
+		}
+
+		x := j.Result.KPI
+		c := &a.card
+		c.Calls++
+		c.AvgRapport += x.AgentAnalysis.RapportScore
+		c.AvgProfessionalism += x.AgentAnalysis.ProfessionalismScore
+		c.AvgSolutionAccuracy += x.AgentAnalysis.SolutionAccuracyScore
+		c.AvgResolutionLikelihood += x.KPI.ResolutionLikelihood
+
+		if rep := j.Result.Compliance; rep != nil {
+			for _, r := range rep.Results {
+				if r.Status == types.ComplianceNotApplicable {
+					continue
+				}
+				a.evaluated++
+				a.ruleTotal[r.RuleID]++
+				if r.Status == types.CompliancePass {
+					a.passed++
+					a.rulePassed[r.RuleID]++
+				}
+			}
+		}
+
+		// count each distinct missed item once per call
+		seen := map[string]bool{}
+		for _, m := range append(append([]string{}, x.AgentAnalysis.MissedOpportunities...), x.ShouldHaveDone.CrucialMissedQuestions...) {
+			key := strings.ToLower(strings.Join(strings.Fields(m), " "))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			c.MissedOpportunities++
+			a.missed[key]++
+			if _, ok := a.missedText[key]; !ok {
+				a.missedText[key] = strings.TrimSpace(m)
+			}
+		}
+	}
+
+	cards := make([]Scorecard, 0, len(byAgent))
+	for _, a := range byAgent {
+		c := a.card
+		n := float64(c.Calls)
+		c.AvgRapport /= n
+		c.AvgProfessionalism /= n
+		c.AvgSolutionAccuracy /= n
+		c.AvgResolutionLikelihood /= n
+		c.MissedPerCall = float64(c.MissedOpportunities) / n
+		if c.Team == "" {
+			c.Team = unassignedTeam
+		}
+
+		c.ComplianceByRule = map[string]float64{}
+		for id, total := range a.ruleTotal {
+			c.ComplianceByRule[id] = float64(a.rulePassed[id]) / float64(total)
+		}
+		// with no audited calls the rate is unknown; treat it as neutral rather than failing
+		c.CompliancePassRate = 1
+		if a.evaluated > 0 {
+			c.CompliancePassRate = float64(a.passed) / float64(a.evaluated)
+		}
+
+		c.TopMissedOpportunities = []Count{}
+		for key, cnt := range a.missed {
+			c.TopMissedOpportunities = append(c.TopMissedOpportunities, Count{Item: a.missedText[key], Count: cnt})
+		}
+		sort.Slice(c.TopMissedOpportunities, func(i, j int) bool {
+			ti, tj := c.TopMissedOpportunities[i], c.TopMissedOpportunities[j]
+			if ti.Count != tj.Count {
+				return ti.Count > tj.Count
+			}
+			return ti.Item < tj.Item
+		})
+		if len(c.TopMissedOpportunities) > 5 {
+			c.TopMissedOpportunities = c.TopMissedOpportunities[:5]
+		}
+
+		c.Composite = (c.AvgRapport + c.AvgProfessionalism + c.AvgSolutionAccuracy + c.CompliancePassRate + c.AvgResolutionLikelihood) / 5
+		cards = append(cards, c)
+	}
+
+	rank(cards)
+	sort.Slice(cards, func(i, j int) bool {
+		if cards[i].Team != cards[j].Team {
+			return cards[i].Team < cards[j].Team
+		}
+		if cards[i].Composite != cards[j].Composite {
+			return cards[i].Composite > cards[j].Composite
+		}
+		return cards[i].AgentID < cards[j].AgentID
+	})
+	return cards
+}
+
+// rank fills the percentile fields of each card against its team.
+func rank(cards []Scorecard) {
+	teams := map[string][]int{}
+	for i, c := range cards {
+		teams[c.Team] = append(teams[c.Team], i)
+	}
+	for _, idx := range teams {
+		for _, i := range idx {
+			c := &cards[i]
+			c.TeamAgents = len(idx)
+			c.Percentiles = map[string]float64{}
+			composite := make([]float64, 0, len(idx))
+			for _, j := range idx {
+				composite = append(composite, cards[j].Composite)
+			}
+			c.PercentileRank = percentileRank(c.Composite, composite)
+			for name, v := range c.metrics() {
+				values := make([]float64, 0, len(idx))
+				for _, j := range idx {
+					values = append(values, cards[j].metrics()[name])
+				}
+				c.Percentiles[name] = percentileRank(v, values)
+			}
+		}
+	}
+}
+
+// percentileRank is the share of values below v, counting ties as half, scaled to 0–100.
+func percentileRank(v float64, values []float64) float64 {
+	below, equal := 0, 0
+	for _, x := range values {
+		switch {
+		case x < v:
+			below++
+		case x == v:
+			equal++
+		}
+	}
+	return 100 * (float64(below) + 0.5*float64(equal)) / float64(len(values))
+}
+
+// ForAgent returns the scorecard of one agent, ranked against their team.
+func ForAgent(all []*jobs.Job, agentID string, w Window) (Scorecard, bool) {
+	for _, c := range Build(all, w) {
+		if c.AgentID == agentID {
+			return c, true
+		}
+	}
+	return Scorecard{}, false
+}
+
+// ForTeam returns the scorecards of a team's agents, best composite first.
+func ForTeam(all []*jobs.Job, team string, w Window) []Scorecard {
+	out := []Scorecard{}
+	for _, c := range Build(all, w) {
+		if c.Team == team {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// TeamMarkdown renders a team's scorecards as the weekly report team leads receive.
+func TeamMarkdown(team string, w Window, cards []Scorecard) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Team %s — agent scorecards\n\n", team)
+	fmt.Fprintf(&b, "%s to %s, %d agents\n\n", w.From.Format("2006-01-02"), w.To.Add(-time.Second).Format("2006-01-02"), len(cards))
+	if len(cards) == 0 {
+		b.WriteString("No processed calls in this window.\n")
+		return b.String()
+	}
+	b.WriteString("| Agent | Calls | Composite | Percentile | Rapport | Professionalism | Accuracy | Compliance | Missed/call | Resolution |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, c := range cards {
+		fmt.Fprintf(&b, "| %s | %d | %.2f | %.0f | %.2f | %.2f | %.2f | %.0f%% | %.1f | %.2f |\n",
+			c.AgentID, c.Calls, c.Composite, c.PercentileRank, c.AvgRapport, c.AvgProfessionalism,
+			c.AvgSolutionAccuracy, c.CompliancePassRate*100, c.MissedPerCall, c.AvgResolutionLikelihood)
+	}
+	for _, c := range cards {
+		if len(c.TopMissedOpportunities) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s — recurring missed opportunities\n\n", c.AgentID)
+		for _, m := range c.TopMissedOpportunities {
+			fmt.Fprintf(&b, "- %s (%d of %d calls)\n", m.Item, m.Count, c.Calls)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,29 @@
+package scorecard
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekIsCutInIST(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 0, 0, 0, 0, IST)
+	tests := []struct {
+		name string
+		day  time.Time
+		from time.Time
+	}{
+		{"monday early morning IST is still sunday in UTC", time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC), monday},
+		{"monday midnight IST", monday, monday},
+		{"sunday late evening IST", time.Date(2026, 3, 8, 23, 59, 0, 0, IST), monday},
+		{"next monday", time.Date(2026, 3, 9, 1, 0, 0, 0, IST), monday.AddDate(0, 0, 7)},
+		{"sunday before", time.Date(2026, 3, 1, 18, 0, 0, 0, time.UTC), monday.AddDate(0, 0, -7)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := Week(tt.day)
+			if !w.From.Equal(tt.from) || !w.To.Equal(tt.from.AddDate(0, 0, 7)) || !w.Contains(tt.day) {
+				t.Errorf("Week(%v) = %v to %v, want from %v", tt.day, w.From, w.To, tt.from)
+			}
+		})
+	}
+}
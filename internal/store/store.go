@@ -0,0 +1,204 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"voice-insights-go/internal/logger"
+)
+
+// ErrNotFound is returned by Get when no record exists for the id.
+var ErrNotFound = errors.New("record not found")
+
+// ErrExists is returned by Create when a record already exists for the id.
+var ErrExists = errors.New("record already exists")
+
+// Store persists JSON documents as one file per record under <root>/<collection>/<id>.json.
+// Writes go through a temp file + rename so readers never observe partial records.
+type Store struct {
+	root string
+	mu   sync.RWMutex
+}
+
+var (
+	defaultMu    sync.Mutex
+	defaultStore *Store
+)
+
+// Default returns the process-wide store rooted at DATA_DIR (default ./data).
+func Default() (*Store, error) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultStore == nil {
+		root := os.Getenv("DATA_DIR")
+		if root == "" {
+			root = "data"
+		}
+		s, err := New(root)
+		if err != nil {
+			return nil, err
+		}
+		defaultStore = s
+	}
+	return defaultStore, nil
+}
+
+// SetDefault makes s the process-wide store and returns a func that puts the previous one
+// back. Tests use it to give each test its own directory.
+func SetDefault(s *Store) (restore func()) {
+	defaultMu.Lock()
+	prev := defaultStore
+	defaultStore = s
+	defaultMu.Unlock()
+	return func() {
+		defaultMu.Lock()
+		defaultStore = prev
+		defaultMu.Unlock()
+	}
+}
+
+// New opens (and creates if needed) a store rooted at dir.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	logger.New().WithField("component", "store").WithField("root", dir).Info("opened data store")
+	return &Store{root: dir}, nil
+}
+
+// Put writes v as the record id in collection, replacing any previous version.
+func (s *Store) Put(collection, id string, v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := s.writeTemp(collection, id, v)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(collection, id))
+}
+
+// Create writes v as the record id only if none exists yet, and returns ErrExists
+// otherwise. The check and the write are one step (a hard link of the temp file, which
+// fails when the target exists), so two writers can never both create the same record.
+func (s *Store) Create(collection, id string, v any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tmp, err := s.writeTemp(collection, id, v)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	if err := os.Link(tmp, s.path(collection, id)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return ErrExists
+		}
+		return fmt.Errorf("create %s/%s: %w", collection, id, err)
+	}
+	return nil
+}
+
+// writeTemp writes v to a temp file in the collection dir and returns its path. The caller
+// holds s.mu.
+func (s *Store) writeTemp(collection, id string, v any) (string, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal %s/%s: %w", collection, id, err)
+	}
+	dir := filepath.Join(s.root, collection)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create collection %s: %w", collection, err)
+	}
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write %s/%s: %w", collection, id, err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("close %s/%s: %w", collection, id, err)
+	}
+	return tmp.Name(), nil
+}
+
+// Get loads record id from collection into v.
+func (s *Store) Get(collection, id string, v any) error {
+	s.mu.RLock()
+	data, err := os.ReadFile(s.path(collection, id))
+	s.mu.RUnlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("read %s/%s: %w", collection, id, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s/%s: %w", collection, id, err)
+	}
+	return nil
+}
+
+// Delete removes record id; deleting a missing record is not an error.
+func (s *Store) Delete(collection, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.Remove(s.path(collection, id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete %s/%s: %w", collection, id, err)
+	}
+	return nil
+}
+
+// List calls fn for every record in collection, ordered by id.
+func (s *Store) List(collection string, fn func(id string, raw json.RawMessage) error) error {
+	s.mu.RLock()
+	entries, err := os.ReadDir(filepath.Join(s.root, collection))
+	s.mu.RUnlock()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("list %s: %w", collection, err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		id, err := url.PathUnescape(strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		s.mu.RLock()
+		data, err := os.ReadFile(filepath.Join(s.root, collection, name))
+		s.mu.RUnlock()
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("read %s/%s: %w", collection, id, err)
+		}
+		if err := fn(id, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// path escapes id so arbitrary identifiers (URLs, phone hashes) stay inside the collection dir.
+func (s *Store) path(collection, id string) string {
+	return filepath.Join(s.root, collection, url.PathEscape(id)+".json")
+}
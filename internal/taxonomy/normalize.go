@@ -0,0 +1,222 @@
+package taxonomy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"voice-insights-go/internal/llm"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/types"
+)
+
+// Normalization methods, in the order they are tried.
+const (
+	MethodExact      = "exact"      // raw equals a node name
+	MethodSynonym    = "synonym"    // raw equals a configured synonym
+	MethodCache      = "cache"      // raw was classified by the LLM before
+	MethodSimilarity = "similarity" // token overlap with a name or synonym
+	MethodLLM        = "llm"        // LLM picked the node
+	MethodUnmatched  = "unmatched"  // fell back to the other node
+)
+
+// cacheCollection remembers LLM decisions so the same raw string always maps to the same
+// node, which keeps reports stable across runs.
+const cacheCollection = "taxonomy_cache"
+
+// minSimilarity is the token overlap score needed to accept a similarity match.
+const minSimilarity = 0.6
+
+type cachedDecision struct {
+	NodeID     string  `json:"node_id"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Normalize maps a free-form issue string to a taxonomy node: exact name, synonym, cached
+// decision, token similarity, then (if configured) an LLM classification step. The raw
+// value is always kept alongside the canonical one.
+func Normalize(ctx context.Context, raw string) (types.CanonicalIssue, error) {
+	t, err := Current()
+	if err != nil {
+		return types.CanonicalIssue{Raw: raw}, err
+	}
+	res := resolve(ctx, t, raw)
+	res.TaxonomyVersion = t.Version
+	res.Path = t.Path(res.NodeID)
+	return res, nil
+}
+
+func resolve(ctx context.Context, t *Taxonomy, raw string) types.CanonicalIssue {
+	log := logger.New().WithField("component", "taxonomy.normalize")
+	key := normalize(raw)
+	out := types.CanonicalIssue{Raw: raw, NodeID: OtherID, Method: MethodUnmatched}
+	if key == "" {
+		return out
+	}
+
+	for _, n := range t.Nodes {
+		if normalize(n.Name) == key || normalize(n.ID) == key {
+			out.NodeID, out.Method, out.Confidence = n.ID, MethodExact, 1
+			return out
+		}
+	}
+	for _, n := range t.Nodes {
+		for _, s := range n.Synonyms {
+			if normalize(s) == key {
+				out.NodeID, out.Method, out.Confidence = n.ID, MethodSynonym, 1
+				return out
+			}
+		}
+	}
+
+	st, stErr := store.Default()
+	if stErr == nil {
+		var c cachedDecision
+		if err := st.Get(cacheCollection, cacheKey(key), &c); err == nil {
+			if _, ok := t.Node(c.NodeID); ok {
+				out.NodeID, out.Method, out.Confidence = c.NodeID, MethodCache, c.Confidence
+				return out
+			}
+		}
+	}
+
+	if id, score := bestSimilar(t, key); score >= minSimilarity {
+		out.NodeID, out.Method, out.Confidence = id, MethodSimilarity, score
+		return out
+	}
+
+	if !llm.Configured() {
+		return out
+	}
+	var ans struct {
+		NodeID     string  `json:"node_id"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := llm.CompleteJSON(ctx, classifyPrompt(t, raw), &ans); err != nil {
+		log.WithError(err).WithField("raw", raw).Warn("llm classification failed")
+		return out
+	}
+	if _, ok := t.Node(ans.NodeID); !ok {
+		log.WithField("raw", raw).WithField("node_id", ans.NodeID).Warn("llm returned unknown node")
+		return out
+	}
+	out.NodeID, out.Method, out.Confidence = ans.NodeID, MethodLLM, clamp01(ans.Confidence)
+	if stErr == nil {
+		if err := st.Put(cacheCollection, cacheKey(key), cachedDecision{NodeID: out.NodeID, Confidence: out.Confidence}); err != nil {
+			log.WithError(err).Warn("failed to cache classification")
+		}
+	}
+	return out
+}
+
+// bestSimilar scores raw against every name and synonym by stemmed token overlap (Dice).
+// Ties go to the deeper node, so a specific category beats its parent.
+func bestSimilar(t *Taxonomy, key string) (string, float64) {
+	q := tokenSet(key)
+	bestID, best, bestDepth := "", 0.0, -1
+	for _, n := range t.Nodes {
+		depth := len(t.Path(n.ID))
+		for _, phrase := range append([]string{n.Name}, n.Synonyms...) {
+			p := tokenSet(phrase)
+			if len(p) == 0 || len(q) == 0 {
+				continue
+			}
+			inter := 0
+			for tok := range p {
+				if q[tok] {
+					inter++
+				}
+			}
+			s := 2 * float64(inter) / float64(len(p)+len(q))
+			if s > best || s == best && depth > bestDepth {
+				bestID, best, bestDepth = n.ID, s, depth
+			}
+		}
+	}
+	return bestID, best
+}
+
+func classifyPrompt(t *Taxonomy, raw string) string {
+	var b strings.Builder
+	for _, n := range t.Nodes {
+		fmt.Fprintf(&b, "- %s: %s", n.ID, strings.Join(t.Path(n.ID), " > "))
+		if len(n.Synonyms) > 0 {
+			fmt.Fprintf(&b, " (e.g. %s)", strings.Join(n.Synonyms, ", "))
+		}
+		b.WriteByte('\n')
+	}
+	return fmt.Sprintf(`You classify customer support issues of a B2B marketplace into a fixed taxonomy.
+
+TAXONOMY (id: path):
+%s
+ISSUE:
+%s
+
+Pick the single most specific node that fits. Use "%s" if nothing fits.
+Return ONLY valid JSON: {"node_id": "<id from the list>", "confidence": 0.0-1.0}
+`, b.String(), raw, OtherID)
+}
+
+// Classify normalizes the extraction's primary issue and dominant category. Errors leave the
+// corresponding value unmatched rather than failing the call.
+func Classify(ctx context.Context, x types.KPIExtraction) types.IssueTaxonomy {
+	log := logger.New().WithField("component", "taxonomy")
+	var out types.IssueTaxonomy
+	var err error
+	if out.PrimaryIssue, err = Normalize(ctx, x.CustomerProblem.PrimaryIssue); err != nil {
+		log.WithError(err).Warn("primary issue normalization failed")
+	}
+	if out.DominantCategory, err = Normalize(ctx, x.TrendInsights.DominantIssueCategory); err != nil {
+		log.WithError(err).Warn("dominant category normalization failed")
+	}
+	return out
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true, "in": true,
+	"for": true, "with": true, "from": true, "on": true, "is": true, "are": true, "not": true, "issue": true,
+	"issues": true, "problem": true, "problems": true, "related": true, "regarding": true,
+}
+
+func tokenSet(s string) map[string]bool {
+	out := map[string]bool{}
+	for _, t := range strings.Fields(normalize(s)) {
+		if !stopwords[t] {
+			out[stem(t)] = true
+		}
+	}
+	return out
+}
+
+// stem folds plural and verb endings and the inquiry/enquiry spelling variants.
+func stem(t string) string {
+	t = strings.Replace(t, "inquir", "enquir", 1)
+	for _, suf := range []string{"ies", "ing", "ed", "es", "s", "y"} {
+		if len(t)-len(suf) >= 3 && strings.HasSuffix(t, suf) {
+			return t[:len(t)-len(suf)]
+		}
+	}
+	return t
+}
+
+func normalize(s string) string {
+	s = strings.NewReplacer("'", "", "’", "", "/", " ", "_", " ").Replace(strings.ToLower(s))
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }), " ")
+}
+
+// cacheKey keeps record ids short enough to be file names.
+func cacheKey(key string) string {
+	if len(key) <= 100 {
+		return key
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func clamp01(v float64) float64 {
+	return max(0, min(1, v))
+}
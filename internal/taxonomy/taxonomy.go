@@ -0,0 +1,233 @@
+package taxonomy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/store"
+)
+
+//go:embed taxonomy.json
+var defaultTaxonomy []byte
+
+const (
+	collection = "taxonomy"
+	currentID  = "current"
+	// OtherID is the catch-all node issues fall back to when nothing matches.
+	OtherID = "other"
+)
+
+// Node is one category. IDs are stable slash-separated paths ("lead_quality/fake_enquiries");
+// renaming a node changes Name, never ID, so stored canonical values stay valid.
+type Node struct {
+	ID          string   `json:"id"`
+	Parent      string   `json:"parent,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Synonyms    []string `json:"synonyms"`
+}
+
+// Taxonomy is the versioned category tree. Every edit bumps Version.
+type Taxonomy struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Nodes     []Node    `json:"nodes"`
+}
+
+// Node returns the node with id.
+func (t *Taxonomy) Node(id string) (Node, bool) {
+	for _, n := range t.Nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
+
+// Path returns the node names from the root down to id.
+func (t *Taxonomy) Path(id string) []string {
+	var path []string
+	for seen := 0; id != "" && seen <= len(t.Nodes); seen++ {
+		n, ok := t.Node(id)
+		if !ok {
+			break
+		}
+		path = append([]string{n.Name}, path...)
+		id = n.Parent
+	}
+	return path
+}
+
+// Validate checks ids are unique, parents exist and there are no cycles.
+func (t *Taxonomy) Validate() error {
+	ids := map[string]Node{}
+	for _, n := range t.Nodes {
+		if strings.TrimSpace(n.ID) == "" || strings.TrimSpace(n.Name) == "" {
+			return fmt.Errorf("node %q: id and name are required", n.ID)
+		}
+		if _, dup := ids[n.ID]; dup {
+			return fmt.Errorf("duplicate node id %q", n.ID)
+		}
+		ids[n.ID] = n
+	}
+	for _, n := range t.Nodes {
+		if n.Parent == "" {
+			continue
+		}
+		if _, ok := ids[n.Parent]; !ok {
+			return fmt.Errorf("node %q: unknown parent %q", n.ID, n.Parent)
+		}
+		for p, hops := n.Parent, 0; p != ""; p, hops = ids[p].Parent, hops+1 {
+			if p == n.ID || hops > len(ids) {
+				return fmt.Errorf("node %q: parent cycle", n.ID)
+			}
+		}
+	}
+	if _, ok := ids[OtherID]; !ok {
+		return fmt.Errorf("the %q node is required", OtherID)
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	current *Taxonomy
+)
+
+// Current returns the active taxonomy: the last edited version in the store, else
+// TAXONOMY_PATH, else the embedded default.
+func Current() (*Taxonomy, error) {
+	mu.RLock()
+	t := current
+	mu.RUnlock()
+	if t != nil {
+		return t, nil
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current != nil {
+		return current, nil
+	}
+	log := logger.New().WithField("component", "taxonomy")
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var loaded Taxonomy
+	source := "store"
+	err = st.Get(collection, currentID, &loaded)
+	if errors.Is(err, store.ErrNotFound) {
+		data := defaultTaxonomy
+		source = "embedded"
+		if path := os.Getenv("TAXONOMY_PATH"); path != "" {
+			if data, err = os.ReadFile(path); err != nil {
+				return nil, fmt.Errorf("read taxonomy: %w", err)
+			}
+			source = path
+		}
+		loaded = Taxonomy{}
+		err = json.Unmarshal(data, &loaded)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load taxonomy: %w", err)
+	}
+	if err := loaded.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy from %s: %w", source, err)
+	}
+	log.WithField("source", source).WithField("version", loaded.Version).WithField("nodes", len(loaded.Nodes)).Info("taxonomy loaded")
+	current = &loaded
+	return current, nil
+}
+
+// edit applies fn to a copy of the current taxonomy, validates and persists it.
+func edit(fn func(t *Taxonomy) error) (*Taxonomy, error) {
+	if _, err := Current(); err != nil {
+		return nil, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+
+	next := *current
+	next.Nodes = append([]Node{}, current.Nodes...)
+	if err := fn(&next); err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, apperr.Wrap(apperr.InvalidInput, "", "invalid taxonomy edit", err)
+	}
+	next.Version++
+	next.UpdatedAt = time.Now().UTC()
+
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	if err := st.Put(collection, currentID, &next); err != nil {
+		return nil, fmt.Errorf("save taxonomy: %w", err)
+	}
+	current = &next
+	return current, nil
+}
+
+// AddNode adds a category.
+func AddNode(n Node) (*Taxonomy, error) {
+	return edit(func(t *Taxonomy) error {
+		if _, ok := t.Node(n.ID); ok {
+			return apperr.New(apperr.InvalidInput, "", fmt.Sprintf("node %q already exists", n.ID))
+		}
+		if n.Synonyms == nil {
+			n.Synonyms = []string{}
+		}
+		t.Nodes = append(t.Nodes, n)
+		return nil
+	})
+}
+
+// UpdateNode replaces the name, parent, description and synonyms of node id.
+func UpdateNode(id string, n Node) (*Taxonomy, error) {
+	return edit(func(t *Taxonomy) error {
+		for i := range t.Nodes {
+			if t.Nodes[i].ID == id {
+				n.ID = id
+				if n.Synonyms == nil {
+					n.Synonyms = []string{}
+				}
+				t.Nodes[i] = n
+				return nil
+			}
+		}
+		return apperr.New(apperr.NotFound, "", fmt.Sprintf("node %q not found", id))
+	})
+}
+
+// DeleteNode removes a leaf category. Nodes with children must be emptied first.
+func DeleteNode(id string) (*Taxonomy, error) {
+	return edit(func(t *Taxonomy) error {
+		if id == OtherID {
+			return apperr.New(apperr.InvalidInput, "", "the other node cannot be deleted")
+		}
+		idx := -1
+		for i, n := range t.Nodes {
+			if n.Parent == id {
+				return apperr.New(apperr.InvalidInput, "", fmt.Sprintf("node %q has children", id))
+			}
+			if n.ID == id {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return apperr.New(apperr.NotFound, "", fmt.Sprintf("node %q not found", id))
+		}
+		t.Nodes = append(t.Nodes[:idx], t.Nodes[idx+1:]...)
+		return nil
+	})
+}
@@ -0,0 +1,22 @@
+// Package testutil holds fixtures shared by package tests.
+package testutil
+
+import (
+	"testing"
+
+	"voice-insights-go/internal/store"
+)
+
+// DataDir gives t an empty data directory: DATA_DIR points at it and the default store is
+// rooted there until t ends, so a test sees only what it stores itself.
+func DataDir(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("DATA_DIR", dir)
+	s, err := store.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(store.SetDefault(s))
+	return dir
+}
@@ -0,0 +1,251 @@
+package ticketing
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const httpTimeout = 15 * time.Second
+
+//go:embed rest_template.json
+var defaultRESTTemplate string
+
+// jira opens issues through the Jira REST API (v2), authenticating with an email and API
+// token. Each issue carries the label vi-<ticket id>, which is searched for before creating
+// so a ticket whose record was lost is found rather than opened twice.
+type jira struct {
+	base, email, token, project, issueType string
+}
+
+func jiraFromEnv() (Backend, error) {
+	j := &jira{
+		base:      strings.TrimRight(os.Getenv("TICKETS_JIRA_URL"), "/"),
+		email:     os.Getenv("TICKETS_JIRA_EMAIL"),
+		token:     os.Getenv("TICKETS_JIRA_TOKEN"),
+		project:   os.Getenv("TICKETS_JIRA_PROJECT"),
+		issueType: os.Getenv("TICKETS_JIRA_ISSUE_TYPE"),
+	}
+	if j.issueType == "" {
+		j.issueType = "Task"
+	}
+	if j.base == "" || j.project == "" {
+		return nil, errors.New("jira ticketing needs TICKETS_JIRA_URL and TICKETS_JIRA_PROJECT")
+	}
+	return j, nil
+}
+
+func (j *jira) Name() string { return BackendJira }
+
+func (j *jira) Create(ctx context.Context, t Ticket) (Ref, error) {
+	var found struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	q := url.Values{
+		"jql":        {fmt.Sprintf(`project = "%s" AND labels = "vi-%s"`, j.project, t.ID)},
+		"fields":     {"key"},
+		"maxResults": {"1"},
+	}
+	if err := j.do(ctx, http.MethodGet, "/rest/api/2/search?"+q.Encode(), nil, &found); err != nil {
+		return Ref{}, fmt.Errorf("jira search: %w", err)
+	}
+	if len(found.Issues) > 0 {
+		return j.ref(found.Issues[0].Key), nil
+	}
+
+	fields := map[string]any{
+		"project":     map[string]string{"key": j.project},
+		"issuetype":   map[string]string{"name": j.issueType},
+		"summary":     t.Title,
+		"description": t.Description,
+		"labels":      t.Labels,
+		"duedate":     t.DueAt.Format("2006-01-02"),
+	}
+	if t.Assignee != "" {
+		fields["assignee"] = map[string]string{"accountId": t.Assignee}
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(ctx, http.MethodPost, "/rest/api/2/issue", map[string]any{"fields": fields}, &created); err != nil {
+		return Ref{}, fmt.Errorf("jira create: %w", err)
+	}
+	return j.ref(created.Key), nil
+}
+
+func (j *jira) ref(key string) Ref {
+	return Ref{Key: key, URL: j.base + "/browse/" + key}
+}
+
+func (j *jira) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, j.base+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	if j.email != "" || j.token != "" {
+		req.SetBasicAuth(j.email, j.token)
+	}
+	resp, err := send(req)
+	if err != nil || out == nil || len(resp) == 0 {
+		return err
+	}
+	if err := json.Unmarshal(resp, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// rest POSTs a ticket rendered through a text/template to a generic endpoint. The template
+// sees the Ticket; {{json .Field}} writes a JSON-encoded value. The Idempotency-Key header
+// carries the ticket id so receivers can drop repeats. Any 2xx is success; the key is read
+// from a JSON response when there is one, else it is the ticket id.
+type rest struct {
+	url, auth, keyField string
+	tmpl                *template.Template
+}
+
+func restFromEnv() (Backend, error) {
+	r := &rest{
+		url:      os.Getenv("TICKETS_REST_URL"),
+		auth:     os.Getenv("TICKETS_REST_AUTH"),
+		keyField: os.Getenv("TICKETS_REST_KEY_FIELD"),
+	}
+	if r.url == "" {
+		return nil, errors.New("rest ticketing needs TICKETS_REST_URL")
+	}
+	if r.keyField == "" {
+		r.keyField = "id"
+	}
+	src := defaultRESTTemplate
+	if path := os.Getenv("TICKETS_REST_TEMPLATE_PATH"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read ticket template: %w", err)
+		}
+		src = string(b)
+	}
+	tmpl, err := template.New("ticket").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+	}).Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("parse ticket template: %w", err)
+	}
+	r.tmpl = tmpl
+	return r, nil
+}
+
+func (r *rest) Name() string { return BackendREST }
+
+func (r *rest) Create(ctx context.Context, t Ticket) (Ref, error) {
+	var body bytes.Buffer
+	if err := r.tmpl.Execute(&body, t); err != nil {
+		return Ref{}, fmt.Errorf("render ticket: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, &body)
+	if err != nil {
+		return Ref{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", t.ID)
+	if r.auth != "" {
+		req.Header.Set("Authorization", r.auth)
+	}
+	resp, err := send(req)
+	if err != nil {
+		return Ref{}, err
+	}
+	var out map[string]any
+	_ = json.Unmarshal(resp, &out)
+	ref := Ref{Key: t.ID}
+	if v, ok := out[r.keyField]; ok && v != nil {
+		ref.Key = fmt.Sprint(v)
+	}
+	if v, ok := out["url"].(string); ok {
+		ref.URL = v
+	}
+	return ref, nil
+}
+
+// file writes each ticket as <id>.json into a queue directory for another process to pick
+// up. An existing file means the ticket was already queued.
+type file struct {
+	dir string
+}
+
+func fileFromEnv() (Backend, error) {
+	dir := os.Getenv("TICKETS_QUEUE_DIR")
+	if dir == "" {
+		dir = "ticket_queue"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create ticket queue: %w", err)
+	}
+	return &file{dir: dir}, nil
+}
+
+func (f *file) Name() string { return BackendFile }
+
+func (f *file) Create(_ context.Context, t Ticket) (Ref, error) {
+	path := filepath.Join(f.dir, t.ID+".json")
+	ref := Ref{Key: t.ID, URL: "file://" + path}
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil
+	}
+	b, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return Ref{}, err
+	}
+	// write aside and rename so consumers never see a partial ticket
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return Ref{}, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return Ref{}, err
+	}
+	return ref, nil
+}
+
+// send sends req and returns the response body; non-2xx statuses are errors.
+func send(req *http.Request) ([]byte, error) {
+	resp, err := (&http.Client{Timeout: httpTimeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode >= 300 {
+		msg := string(body)
+		if len(msg) > 512 {
+			msg = msg[:512]
+		}
+		return nil, fmt.Errorf("%s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(msg))
+	}
+	return bytes.TrimSpace(body), nil
+}
@@ -0,0 +1,108 @@
+package ticketing
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	"voice-insights-go/internal/jobs"
+)
+
+// sameAction is the token overlap (Jaccard) above which two actions of a call are taken to
+// be the same follow-up worded differently, as happens when a call is extracted again.
+const sameAction = 0.6
+
+// callKey identifies the call a job processed, stable across reprocessing: the external
+// call id when the caller gave one, else the audio URL without its fragment and with the
+// host lowercased.
+func callKey(j *jobs.Job) string {
+	if j.CallID != "" {
+		return "call:" + j.CallID
+	}
+	u, err := url.Parse(strings.TrimSpace(j.AudioURL))
+	if err != nil {
+		return "audio:" + strings.TrimSpace(j.AudioURL)
+	}
+	u.Fragment, u.RawFragment = "", ""
+	u.Scheme, u.Host = strings.ToLower(u.Scheme), strings.ToLower(u.Host)
+	return "audio:" + u.String()
+}
+
+// actionStopwords carry no meaning of their own in an action item, in English or Hinglish.
+var actionStopwords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "of": true, "to": true, "in": true,
+	"for": true, "with": true, "from": true, "on": true, "at": true, "by": true, "is": true, "be": true,
+	"it": true, "this": true, "that": true, "their": true, "his": true, "her": true, "them": true,
+	"please": true, "ensure": true, "make": true, "sure": true, "need": true, "needs": true,
+	"should": true, "must": true, "asap": true, "immediately": true, "customer": true, "customers": true,
+	"ka": true, "ki": true, "ke": true, "ko": true, "se": true, "hai": true, "karo": true, "kare": true,
+	"karna": true, "karein": true,
+}
+
+// actionTokens is the normalized content of an action: lowercased words without stopwords,
+// with plural and verb endings folded, so "Refund the customer's payment" and "refunding
+// payment to customer" come out the same.
+func actionTokens(action string) []string {
+	action = strings.NewReplacer("'", "", "’", "").Replace(strings.ToLower(action))
+	set := map[string]bool{}
+	for _, w := range strings.FieldsFunc(action, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsNumber(r) }) {
+		if !actionStopwords[w] {
+			set[stemWord(w)] = true
+		}
+	}
+	out := make([]string, 0, len(set))
+	for w := range set {
+		out = append(out, w)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// actionKey is actionTokens as one string; an action made only of stopwords keys on its
+// lowercased text.
+func actionKey(action string) string {
+	if toks := actionTokens(action); len(toks) > 0 {
+		return strings.Join(toks, " ")
+	}
+	return strings.ToLower(strings.Join(strings.Fields(action), " "))
+}
+
+func stemWord(w string) string {
+	for _, suf := range []string{"ies", "ing", "ed", "es", "s"} {
+		if len(w)-len(suf) >= 3 && strings.HasSuffix(w, suf) {
+			return w[:len(w)-len(suf)]
+		}
+	}
+	return w
+}
+
+// similar reports whether two action keys overlap enough to be the same follow-up.
+func similar(a, b string) bool {
+	if a == b {
+		return true
+	}
+	ta, tb := strings.Fields(a), strings.Fields(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return false
+	}
+	in := map[string]bool{}
+	for _, t := range ta {
+		in[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if in[t] {
+			shared++
+		}
+	}
+	return float64(shared)/float64(len(ta)+len(tb)-shared) >= sameAction
+}
+
+// ticketID is stable across reprocessing: same call, kind and normalized action, same ticket.
+func ticketID(call, kind, key string) string {
+	sum := sha256.Sum256([]byte(call + "\x1f" + kind + "\x1f" + key))
+	return hex.EncodeToString(sum[:10])
+}
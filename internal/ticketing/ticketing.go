@@ -0,0 +1,401 @@
+// Package ticketing turns the executive and system actions of a processed call into tickets
+// on a configurable backend (Jira, a templated REST endpoint or a local file queue). Every
+// ticket id is derived from the call and the normalized action, so reprocessing a call, even
+// as a new job with reworded actions, never opens a duplicate.
+package ticketing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/logger"
+	"voice-insights-go/internal/redact"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+)
+
+const collection = "tickets"
+
+// Action kinds that become tickets. Customer actions are the customer's to do.
+const (
+	KindExecutive = "executive"
+	KindSystem    = "system"
+)
+
+// Ticket statuses. A pending ticket is being opened by a sync that is still running.
+const (
+	StatusPending = "pending"
+	StatusCreated = "created"
+	StatusFailed  = "failed"
+)
+
+// maxTitle bounds ticket titles; the full action is in the description.
+const maxTitle = 120
+
+// Ticket is one follow-up to open. ID is derived from CallKey, Kind and ActionKey; JobID is
+// the job that opened it.
+type Ticket struct {
+	ID          string    `json:"id"`
+	CallKey     string    `json:"call_key"`
+	ActionKey   string    `json:"action_key"`
+	JobID       string    `json:"job_id"`
+	CallID      string    `json:"call_id,omitempty"`
+	CallURL     string    `json:"call_url"`
+	AudioURL    string    `json:"audio_url"`
+	Kind        string    `json:"kind"`
+	Title       string    `json:"title"`
+	Action      string    `json:"action"`
+	Description string    `json:"description"`
+	Priority    string    `json:"priority"`
+	Owner       string    `json:"owner,omitempty"`
+	Assignee    string    `json:"assignee,omitempty"`
+	Issue       string    `json:"issue"`
+	AgentID     string    `json:"agent_id,omitempty"`
+	Team        string    `json:"team,omitempty"`
+	City        string    `json:"city,omitempty"`
+	DueAt       time.Time `json:"due_at"`
+	Labels      []string  `json:"labels"`
+}
+
+// Ref identifies a ticket on the backend.
+type Ref struct {
+	Key string `json:"key"`
+	URL string `json:"url,omitempty"`
+}
+
+// Backend opens tickets. Create must be safe to call again for a ticket id it has already
+// seen, where the backend allows finding it.
+type Backend interface {
+	Name() string
+	Create(ctx context.Context, t Ticket) (Ref, error)
+}
+
+// Record is the stored outcome of opening one ticket.
+type Record struct {
+	Ticket
+	Backend   string    `json:"backend"`
+	Ref       Ref       `json:"ref"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Attempts  int       `json:"attempts"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Backend names for TICKETS_BACKEND.
+const (
+	BackendJira = "jira"
+	BackendREST = "rest"
+	BackendFile = "file"
+)
+
+// FromEnv returns the backend selected by TICKETS_BACKEND (jira|rest|file). It returns nil
+// when ticketing is off (unset).
+func FromEnv() (Backend, error) {
+	switch name := os.Getenv("TICKETS_BACKEND"); name {
+	case "":
+		return nil, nil
+	case BackendJira:
+		return jiraFromEnv()
+	case BackendREST:
+		return restFromEnv()
+	case BackendFile:
+		return fileFromEnv()
+	default:
+		return nil, fmt.Errorf("unknown TICKETS_BACKEND %q (want jira, rest or file)", name)
+	}
+}
+
+var (
+	backendOnce sync.Once
+	backend     Backend
+	backendErr  error
+)
+
+// Configured returns the backend from FromEnv, built once per process.
+func Configured() (Backend, error) {
+	backendOnce.Do(func() {
+		backend, backendErr = FromEnv()
+		if backendErr == nil && backend != nil {
+			logger.New().WithField("component", "ticketing").WithField("backend", backend.Name()).Info("ticketing enabled")
+		}
+	})
+	return backend, backendErr
+}
+
+// syncTimeout bounds one background Sync.
+const syncTimeout = 2 * time.Minute
+
+// Dispatch opens the tickets of a processed job on the configured backend in the
+// background. It does nothing when ticketing is off or the job has no actions.
+func Dispatch(j *jobs.Job) error {
+	if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+		return nil
+	}
+	b, err := Configured()
+	if err != nil || b == nil || len(Plan(j)) == 0 {
+		return err
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+		defer cancel()
+		if _, err := Sync(ctx, b, j); err != nil {
+			logger.New().WithField("component", "ticketing").WithField("job_id", j.ID).WithError(err).Error("ticket sync failed")
+		}
+	}()
+	return nil
+}
+
+// Plan lists the tickets for a processed job, one per distinct executive or system action.
+// Due dates follow the call's priority; assignees come from assignee.
+func Plan(j *jobs.Job) []Ticket {
+	x := j.Result.KPI
+	issue := taxonomy.OtherID
+	if is := j.Result.Issues; is != nil && is.PrimaryIssue.NodeID != "" {
+		issue = is.PrimaryIssue.NodeID
+	}
+	priority := strings.ToLower(strings.TrimSpace(x.Actions.Priority))
+	if priority == "" {
+		priority = "medium"
+	}
+	callURL := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/") + "/jobs/" + j.ID
+	owner := strings.TrimSpace(x.ShouldHaveDone.DepartmentOwner)
+
+	call := callKey(j)
+
+	var out []Ticket
+	for _, group := range []struct {
+		kind    string
+		actions []string
+	}{
+		{KindExecutive, x.Actions.ExecutiveActionsRequired},
+		{KindSystem, x.Actions.SystemActionsRequired},
+	} {
+	actions:
+		for _, a := range group.actions {
+			a = strings.Join(strings.Fields(a), " ")
+			if a == "" {
+				continue
+			}
+			key := actionKey(a)
+			for _, prev := range out {
+				if prev.Kind == group.kind && similar(prev.ActionKey, key) {
+					continue actions
+				}
+			}
+			t := Ticket{
+				CallKey:   call,
+				ActionKey: key,
+				JobID:     j.ID,
+				CallID:    j.CallID,
+				CallURL:   callURL,
+				AudioURL:  j.AudioURL,
+				Kind:      group.kind,
+				Title:     title(a),
+				Action:    a,
+				Priority:  priority,
+				Owner:     owner,
+				Assignee:  assignee(owner, group.kind),
+				Issue:     issue,
+				AgentID:   j.AgentID,
+				Team:      j.Team,
+				City:      j.City,
+				DueAt:     due(j.At(), priority),
+			}
+			t = t.withID(ticketID(call, group.kind, key))
+			t.Description = describe(t, x.CustomerProblem.PrimaryIssue, x.Actions.EscalationReason)
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// withID sets the ticket id and the labels derived from it; vi-<id> is what backends that
+// can be searched use to find a ticket opened before.
+func (t Ticket) withID(id string) Ticket {
+	t.ID = id
+	t.Labels = []string{"voice-insights", "vi-" + id, t.Kind + "-action", "priority-" + strings.ReplaceAll(t.Priority, " ", "-")}
+	return t
+}
+
+// assignee picks who a ticket goes to: the TICKETS_OWNERS entry for the department owner
+// the extraction named ("billing=alice@example.com,it=bob@example.com", matched without
+// case), else TICKETS_ASSIGNEE_EXECUTIVE or TICKETS_ASSIGNEE_SYSTEM for the action kind.
+func assignee(owner, kind string) string {
+	if owner != "" {
+		for _, pair := range strings.Split(os.Getenv("TICKETS_OWNERS"), ",") {
+			dept, who, ok := strings.Cut(pair, "=")
+			if ok && strings.EqualFold(strings.TrimSpace(dept), owner) {
+				return strings.TrimSpace(who)
+			}
+		}
+	}
+	return os.Getenv("TICKETS_ASSIGNEE_" + strings.ToUpper(kind))
+}
+
+func title(action string) string {
+	if r := []rune(action); len(r) > maxTitle {
+		return string(r[:maxTitle-1]) + "…"
+	}
+	return action
+}
+
+// due gives urgent work a day and everything else up to a week from the call.
+func due(at time.Time, priority string) time.Time {
+	days := 7
+	switch priority {
+	case "critical", "urgent", "high", "p0", "p1":
+		days = 1
+	case "medium", "p2":
+		days = 3
+	}
+	return at.UTC().AddDate(0, 0, days)
+}
+
+// describe is the ticket body. Free text from the call is redacted; if redaction fails it
+// is left out.
+func describe(t Ticket, primaryIssue, reason string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", t.Action)
+	fmt.Fprintf(&b, "Call: %s\nAudio: %s\nIssue: %s\nPriority: %s\n", t.CallURL, t.AudioURL, t.Issue, t.Priority)
+	if t.Owner != "" {
+		fmt.Fprintf(&b, "Owner: %s\n", t.Owner)
+	}
+	if red, _, err := redact.Text(primaryIssue); err == nil && red != "" {
+		fmt.Fprintf(&b, "Customer problem: %s\n", red)
+	}
+	if red, _, err := redact.Text(reason); err == nil && red != "" {
+		fmt.Fprintf(&b, "Escalation reason: %s\n", red)
+	}
+	for _, kv := range [][2]string{{"Agent", t.AgentID}, {"Team", t.Team}, {"City", t.City}} {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\n", kv[0], kv[1])
+		}
+	}
+	return b.String()
+}
+
+// Sync opens the tickets of a processed job on b. A planned ticket matches a stored one of
+// the same call when the id is equal or the action is the same worded differently (see
+// similar); created and in-flight ones are returned as stored, failed ones are attempted
+// again. New tickets are claimed with a create-if-absent write, so concurrent syncs of the
+// same call open each ticket once.
+func Sync(ctx context.Context, b Backend, j *jobs.Job) ([]Record, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	log := logger.New().WithField("component", "ticketing").WithField("job_id", j.ID).WithField("backend", b.Name())
+	existing, err := ForCall(callKey(j))
+	if err != nil {
+		return nil, err
+	}
+	out := []Record{}
+	for _, t := range Plan(j) {
+		now := time.Now().UTC()
+		rec, found := match(existing, t)
+		if !found {
+			rec = Record{Ticket: t, Backend: b.Name(), Status: StatusPending, CreatedAt: now, UpdatedAt: now}
+			err := st.Create(collection, t.ID, rec)
+			if errors.Is(err, store.ErrExists) {
+				// another sync claimed it after ForCall ran
+				if err := st.Get(collection, t.ID, &rec); err != nil {
+					return out, fmt.Errorf("load ticket: %w", err)
+				}
+				found = true
+			} else if err != nil {
+				return out, fmt.Errorf("save ticket: %w", err)
+			}
+		}
+		if found {
+			if rec.Status == StatusCreated || (rec.Status == StatusPending && now.Sub(rec.UpdatedAt) < syncTimeout) {
+				out = append(out, rec)
+				continue
+			}
+			// failed, or pending from a sync that died: open it again under its stored id
+			rec.Ticket, rec.Backend = t.withID(rec.ID), b.Name()
+		}
+		rec.Attempts++
+		ref, err := b.Create(ctx, rec.Ticket)
+		if err != nil {
+			rec.Status, rec.Error = StatusFailed, err.Error()
+			log.WithError(err).WithField("ticket_id", rec.ID).Warn("ticket creation failed")
+		} else {
+			rec.Status, rec.Error, rec.Ref = StatusCreated, "", ref
+			log.WithField("ticket_id", rec.ID).WithField("key", ref.Key).Info("ticket created")
+		}
+		rec.UpdatedAt = time.Now().UTC()
+		if err := st.Put(collection, rec.ID, rec); err != nil {
+			return out, fmt.Errorf("save ticket: %w", err)
+		}
+		out = append(out, rec)
+	}
+	return out, nil
+}
+
+// match finds the stored ticket a planned one stands for: the same id, else the same kind
+// with a similar action.
+func match(existing []Record, t Ticket) (Record, bool) {
+	for _, r := range existing {
+		if r.ID == t.ID {
+			return r, true
+		}
+	}
+	for _, r := range existing {
+		if r.Kind == t.Kind && similar(r.ActionKey, t.ActionKey) {
+			return r, true
+		}
+	}
+	return Record{}, false
+}
+
+// ForCall returns the stored tickets of every job of the call with key call (see callKey),
+// oldest first.
+func ForCall(call string) ([]Record, error) {
+	all, err := List("", "")
+	if err != nil {
+		return nil, err
+	}
+	out := []Record{}
+	for i := len(all) - 1; i >= 0; i-- {
+		if all[i].CallKey == call {
+			out = append(out, all[i])
+		}
+	}
+	return out, nil
+}
+
+// ForJob returns the tickets of the call a job processed, including ones opened by earlier
+// jobs of the same call.
+func ForJob(j *jobs.Job) ([]Record, error) {
+	return ForCall(callKey(j))
+}
+
+// List returns stored tickets, newest first, optionally filtered by job and status.
+func List(jobID, status string) ([]Record, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	out := []Record{}
+	err = st.List(collection, func(_ string, raw json.RawMessage) error {
+		var r Record
+		if err := json.Unmarshal(raw, &r); err != nil {
+			return err
+		}
+		if (jobID == "" || r.JobID == jobID) && (status == "" || r.Status == status) {
+			out = append(out, r)
+		}
+		return nil
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, err
+}
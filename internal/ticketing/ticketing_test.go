@@ -0,0 +1,162 @@
+package ticketing
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/testutil"
+)
+
+func TestCallKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		callID   string
+		audioURL string
+		want     string
+	}{
+		{"call id wins", "C-1", "https://x/a.wav", "call:C-1"},
+		{"fragment dropped", "", "https://x/a.wav#t=10", "audio:https://x/a.wav"},
+		{"host and scheme lowercased", "", " HTTPS://Calls.Example.com/A.wav ", "audio:https://calls.example.com/A.wav"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &jobs.Job{CallID: tt.callID, AudioURL: tt.audioURL}
+			if got := callKey(j); got != tt.want {
+				t.Errorf("callKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Refund the customer's payment", "payment refund"},
+		{"refunding payment to customer", "payment refund"},
+		{"Please verify leads ASAP", "lead verify"},
+		{"Please ensure the", "please ensure the"},
+	}
+	for _, tt := range tests {
+		if got := actionKey(tt.in); got != tt.want {
+			t.Errorf("actionKey(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"payment refund", "payment refund", true},
+		{"issue payment refund", "payment refund", true},
+		{"lead verify", "payment refund", false},
+		{"", "payment refund", false},
+	}
+	for _, tt := range tests {
+		if got := similar(tt.a, tt.b); got != tt.want {
+			t.Errorf("similar(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// fakeBackend counts the tickets it is asked to open and fails while failing is set.
+type fakeBackend struct {
+	created map[string]int
+	failing bool
+}
+
+func (f *fakeBackend) Name() string { return "fake" }
+
+func (f *fakeBackend) Create(_ context.Context, t Ticket) (Ref, error) {
+	if f.failing {
+		return Ref{}, errors.New("backend down")
+	}
+	f.created[t.ID]++
+	return Ref{Key: "T-" + t.ID[:6]}, nil
+}
+
+func processed(callID string, executive, system []string) *jobs.Job {
+	j := jobs.New("https://calls/x.wav", 3, "single")
+	j.CallID = callID
+	j.Result.KPI.Actions.Priority = "high"
+	j.Result.KPI.Actions.ExecutiveActionsRequired = executive
+	j.Result.KPI.Actions.SystemActionsRequired = system
+	return j
+}
+
+func TestPlanIsStableAcrossReprocessing(t *testing.T) {
+	first := Plan(processed("plan-1", []string{"Refund the customer's payment", "refunding payment to customer", "Call back tomorrow"}, []string{"Block fake leads"}))
+	if len(first) != 3 {
+		t.Fatalf("planned %d tickets %+v, want the reworded refund merged", len(first), first)
+	}
+	again := Plan(processed("plan-1", []string{"call back  tomorrow", "Refund payment to the customer"}, []string{"block the fake leads"}))
+	ids := func(ts []Ticket) map[string]bool {
+		out := map[string]bool{}
+		for _, t := range ts {
+			out[t.ID] = true
+		}
+		return out
+	}
+	if !reflect.DeepEqual(ids(first), ids(again)) {
+		t.Errorf("ids changed on reprocessing: %v then %v", ids(first), ids(again))
+	}
+	for _, tk := range first {
+		if tk.CallKey != "call:plan-1" || tk.Priority != "high" || tk.Labels[1] != "vi-"+tk.ID {
+			t.Errorf("ticket = %+v", tk)
+		}
+	}
+}
+
+func TestSyncOpensEachTicketOnce(t *testing.T) {
+	testutil.DataDir(t)
+	b := &fakeBackend{created: map[string]int{}, failing: true}
+	j := processed("sync-1", []string{"Refund the payment"}, []string{"Block fake leads"})
+
+	recs, err := Sync(context.Background(), b, j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range recs {
+		if r.Status != StatusFailed || r.Attempts != 1 {
+			t.Errorf("record while the backend is down = %+v", r)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		job      *jobs.Job
+		attempts int
+	}{
+		{"failed tickets are retried", j, 2},
+		{"same job again opens nothing", j, 2},
+		{"reprocessed call with reworded actions opens nothing", processed("sync-1", []string{"refunding payment"}, []string{"block the fake leads"}), 2},
+	}
+	b.failing = false
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recs, err := Sync(context.Background(), b, tt.job)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(recs) != 2 {
+				t.Fatalf("got %d records, want 2", len(recs))
+			}
+			for _, r := range recs {
+				if r.Status != StatusCreated || r.Attempts != tt.attempts || b.created[r.ID] != 1 || r.JobID != j.ID {
+					t.Errorf("record = %+v, backend created it %d times", r, b.created[r.ID])
+				}
+			}
+		})
+	}
+
+	stored, err := ForJob(j)
+	if err != nil || len(stored) != 2 {
+		t.Errorf("ForJob = %d records, err %v; want 2", len(stored), err)
+	}
+}
@@ -0,0 +1,236 @@
+package transcript
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Turn is one speaker turn of a diarized transcript.
+type Turn struct {
+	Index   int    `json:"index"`
+	Speaker string `json:"speaker"`
+	Text    string `json:"text"`
+}
+
+// speakerLine matches the "Speaker 1: ..." lines produced by the transcription vendor,
+// plus the role-labelled variants used in hand-written transcripts.
+var speakerLine = regexp.MustCompile(`(?i)^\s*(speaker\s*\d+|agent|customer|caller|seller|executive)\s*:\s*(.*)$`)
+
+// Parse splits a transcript into turns. Lines without a speaker label are appended to the
+// previous turn; a transcript with no labels at all becomes a single unlabelled turn per line.
+func Parse(s string) []Turn {
+	var turns []Turn
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if m := speakerLine.FindStringSubmatch(line); m != nil {
+			turns = append(turns, Turn{Index: len(turns), Speaker: normalizeSpeaker(m[1]), Text: strings.TrimSpace(m[2])})
+			continue
+		}
+		if len(turns) > 0 && turns[len(turns)-1].Speaker != "" {
+			turns[len(turns)-1].Text += " " + line
+			continue
+		}
+		turns = append(turns, Turn{Index: len(turns), Text: line})
+	}
+	return turns
+}
+
+// Render turns back into "Speaker: text" lines.
+func Render(turns []Turn) string {
+	var b strings.Builder
+	for _, t := range turns {
+		if t.Speaker != "" {
+			b.WriteString(t.Speaker)
+			b.WriteString(": ")
+		}
+		b.WriteString(t.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// EstimateTokens approximates the tokenizer count without calling the model: roughly four
+// bytes per token for Latin-script text, and about one token per rune for Devanagari and
+// other scripts, which BPE vocabularies split much more finely.
+func EstimateTokens(s string) int {
+	ascii, other := 0, 0
+	for _, r := range s {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return (ascii+3)/4 + other
+}
+
+// Chunk groups turns into consecutive windows whose rendered size stays within maxTokens.
+// Each window after the first repeats the last overlap turns of the previous one so a
+// finding that straddles a boundary is seen whole by at least one chunk. A single turn that
+// exceeds maxTokens (an unlabelled transcript without line breaks, or a long monologue) is
+// first split into pieces that fit; see splitTurn.
+func Chunk(turns []Turn, maxTokens, overlap int) [][]Turn {
+	if len(turns) == 0 {
+		return nil
+	}
+	if overlap < 0 {
+		overlap = 0
+	}
+	var fitted []Turn
+	for _, t := range turns {
+		fitted = append(fitted, splitTurn(t, maxTokens)...)
+	}
+	turns = fitted
+	var chunks [][]Turn
+	start := 0
+	for start < len(turns) {
+		end, size := start, 0
+		for end < len(turns) {
+			t := EstimateTokens(turns[end].Speaker+": "+turns[end].Text) + 1
+			if end > start && size+t > maxTokens {
+				break
+			}
+			size += t
+			end++
+		}
+		chunks = append(chunks, turns[start:end])
+		if end >= len(turns) {
+			break
+		}
+		next := end - overlap
+		if next <= start {
+			next = start + 1
+		}
+		start = next
+	}
+	return chunks
+}
+
+// splitTurn breaks a turn whose rendered size exceeds maxTokens into pieces that fit, packing
+// whole sentences where it can and falling back to words, then runes, for a sentence that is
+// too long by itself. The pieces keep the turn's index and speaker so quotes still cite it.
+func splitTurn(t Turn, maxTokens int) []Turn {
+	budget := maxTokens - EstimateTokens(t.Speaker+": ") - 1
+	if budget < 1 {
+		budget = 1
+	}
+	if EstimateTokens(t.Text) <= budget {
+		return []Turn{t}
+	}
+	var units []string
+	for _, sentence := range sentences(t.Text) {
+		if EstimateTokens(sentence) <= budget {
+			units = append(units, sentence)
+			continue
+		}
+		for _, w := range strings.Fields(sentence) {
+			units = append(units, cutRunes(w, budget)...)
+		}
+	}
+	var out []Turn
+	cur, size := "", 0
+	for _, u := range units {
+		n := EstimateTokens(u)
+		if cur != "" && size+1+n > budget {
+			out = append(out, Turn{Index: t.Index, Speaker: t.Speaker, Text: cur})
+			cur, size = "", 0
+		}
+		if cur == "" {
+			cur, size = u, n
+		} else {
+			cur, size = cur+" "+u, size+1+n
+		}
+	}
+	if cur != "" {
+		out = append(out, Turn{Index: t.Index, Speaker: t.Speaker, Text: cur})
+	}
+	return out
+}
+
+// sentences splits text after sentence-ending punctuation (including the Devanagari danda)
+// that is followed by a space.
+func sentences(text string) []string {
+	var out []string
+	start := 0
+	runes := []rune(text)
+	for i, r := range runes {
+		if !strings.ContainsRune(".!?।", r) || i+1 < len(runes) && !unicode.IsSpace(runes[i+1]) {
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start : i+1])); s != "" {
+			out = append(out, s)
+		}
+		start = i + 1
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// cutRunes splits a word into pieces of at most budget estimated tokens.
+func cutRunes(w string, budget int) []string {
+	var out []string
+	start, ascii, other := 0, 0, 0
+	for i, r := range w {
+		a, o := ascii, other
+		if r < utf8.RuneSelf {
+			a++
+		} else {
+			o++
+		}
+		if (a+3)/4+o > budget && i > start {
+			out = append(out, w[start:i])
+			start, a, o = i, 0, 0
+			if r < utf8.RuneSelf {
+				a = 1
+			} else {
+				o = 1
+			}
+		}
+		ascii, other = a, o
+	}
+	return append(out, w[start:])
+}
+
+func normalizeSpeaker(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "speaker") {
+		return "Speaker " + strings.TrimSpace(s[len("speaker"):])
+	}
+	return strings.ToUpper(s[:1]) + lower[1:]
+}
+
+// Numbered renders the transcript with a [turn] prefix on every turn so a model can cite
+// turns by index. Text without any speaker labels (e.g. a condensed digest) is returned as is.
+func Numbered(s string) string {
+	turns := Parse(s)
+	labelled := false
+	for _, t := range turns {
+		if t.Speaker != "" {
+			labelled = true
+			break
+		}
+	}
+	if !labelled {
+		return s
+	}
+	var b strings.Builder
+	for _, t := range turns {
+		fmt.Fprintf(&b, "[%d] ", t.Index)
+		if t.Speaker != "" {
+			b.WriteString(t.Speaker)
+			b.WriteString(": ")
+		}
+		b.WriteString(t.Text)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
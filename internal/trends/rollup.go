@@ -0,0 +1,252 @@
+// Package trends keeps daily rollups of call KPIs and issue volumes and turns them into time
+// series for the weekly business review.
+package trends
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/scorecard"
+	"voice-insights-go/internal/store"
+	"voice-insights-go/internal/taxonomy"
+)
+
+const collection = "trend_rollups"
+
+// dayLayout keys rollups by calendar day.
+const dayLayout = "2006-01-02"
+
+// ist is the zone days and weeks are cut in, the same as scorecard weeks.
+var ist = scorecard.IST
+
+// Labels for calls without a team (as on scorecards) or a city.
+const (
+	unassignedTeam = "unassigned"
+	unknownCity    = "unknown"
+)
+
+// Stats are summed call KPIs; means are taken when a series is built.
+type Stats struct {
+	Calls                int     `json:"calls"`
+	Escalations          int     `json:"escalations"`
+	Frustration          float64 `json:"frustration_sum"`
+	Confusion            float64 `json:"confusion_sum"`
+	ResolutionLikelihood float64 `json:"resolution_likelihood_sum"`
+	RiskOfChurn          float64 `json:"risk_of_churn_sum"`
+}
+
+func (s *Stats) add(o Stats, sign int) {
+	f := float64(sign)
+	s.Calls += sign * o.Calls
+	s.Escalations += sign * o.Escalations
+	s.Frustration += f * o.Frustration
+	s.Confusion += f * o.Confusion
+	s.ResolutionLikelihood += f * o.ResolutionLikelihood
+	s.RiskOfChurn += f * o.RiskOfChurn
+}
+
+// Cell is the rollup of one issue, city and team on one day.
+type Cell struct {
+	Issue string `json:"issue"`
+	City  string `json:"city"`
+	Team  string `json:"team"`
+	Stats
+}
+
+// entry is what one job contributed to a day, so a retried job replaces its earlier numbers.
+type entry struct {
+	Key   string `json:"key"`
+	Stats Stats  `json:"stats"`
+}
+
+// Day is the stored rollup of one IST calendar day.
+type Day struct {
+	Day   string           `json:"day"`
+	Cells map[string]*Cell `json:"cells"`
+	Jobs  map[string]entry `json:"jobs"`
+}
+
+func newDay(day string) *Day {
+	return &Day{Day: day, Cells: map[string]*Cell{}, Jobs: map[string]entry{}}
+}
+
+// mu serialises read-modify-write of day rollups.
+var mu sync.Mutex
+
+// Record folds a completed (or partial) job into the rollup of the day the call happened.
+// Recording a job again replaces its earlier contribution.
+func Record(j *jobs.Job) error {
+	if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+		return nil
+	}
+	st, err := store.Default()
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	day := j.At().In(ist).Format(dayLayout)
+	d, err := loadDay(st, day)
+	if err != nil {
+		return err
+	}
+	d.record(j)
+	if err := st.Put(collection, day, d); err != nil {
+		return fmt.Errorf("save trend rollup %s: %w", day, err)
+	}
+	return nil
+}
+
+// Rebuild recomputes every rollup from the stored jobs, e.g. for calls processed before
+// rollups existed. It returns how many jobs were recorded.
+func Rebuild() (int, error) {
+	st, err := store.Default()
+	if err != nil {
+		return 0, err
+	}
+	all, err := jobs.List()
+	if err != nil {
+		return 0, fmt.Errorf("list jobs: %w", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	days := map[string]*Day{}
+	n := 0
+	for _, j := range all {
+		if j.Status != jobs.StatusCompleted && j.Status != jobs.StatusPartial {
+			continue
+		}
+		day := j.At().In(ist).Format(dayLayout)
+		if days[day] == nil {
+			days[day] = newDay(day)
+		}
+		days[day].record(j)
+		n++
+	}
+
+	var stale []string
+	err = st.List(collection, func(id string, _ json.RawMessage) error {
+		if days[id] == nil {
+			stale = append(stale, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("list trend rollups: %w", err)
+	}
+	for _, id := range stale {
+		if err := st.Delete(collection, id); err != nil {
+			return 0, fmt.Errorf("delete trend rollup %s: %w", id, err)
+		}
+	}
+	for id, d := range days {
+		if err := st.Put(collection, id, d); err != nil {
+			return 0, fmt.Errorf("save trend rollup %s: %w", id, err)
+		}
+	}
+	return n, nil
+}
+
+func (d *Day) record(j *jobs.Job) {
+	if prev, ok := d.Jobs[j.ID]; ok {
+		if c := d.Cells[prev.Key]; c != nil {
+			c.Stats.add(prev.Stats, -1)
+			if c.Calls <= 0 {
+				delete(d.Cells, prev.Key)
+			}
+		}
+	}
+	c := CellOf(j)
+	key := strings.Join([]string{c.Issue, c.City, c.Team}, "\x1f")
+	if d.Cells[key] == nil {
+		d.Cells[key] = &Cell{Issue: c.Issue, City: c.City, Team: c.Team}
+	}
+	d.Cells[key].Stats.add(c.Stats, 1)
+	d.Jobs[j.ID] = entry{Key: key, Stats: c.Stats}
+}
+
+// CellOf is the single-call cell of j. The issue is the canonical primary issue, falling
+// back to the transcript classification.
+func CellOf(j *jobs.Job) Cell {
+	res := j.Result
+	issue := ""
+	if res.Issues != nil {
+		issue = res.Issues.PrimaryIssue.NodeID
+	}
+	if issue == "" && res.Classification != nil {
+		issue = res.Classification.Category
+	}
+	if issue == "" {
+		issue = taxonomy.OtherID
+	}
+	team := j.Team
+	if team == "" {
+		team = unassignedTeam
+	}
+	x := res.KPI
+	s := Stats{
+		Calls:                1,
+		Frustration:          x.KPI.FrustrationScore,
+		Confusion:            x.KPI.ConfusionLevel,
+		ResolutionLikelihood: x.KPI.ResolutionLikelihood,
+		RiskOfChurn:          res.ChurnRisk(),
+	}
+	if x.Actions.RequiresEscalation {
+		s.Escalations = 1
+	}
+	city := strings.ToLower(strings.TrimSpace(j.City))
+	if city == "" {
+		city = unknownCity
+	}
+	return Cell{Issue: issue, City: city, Team: team, Stats: s}
+}
+
+// Days loads the rollups of every IST calendar day from first to last inclusive, in order;
+// days without calls are empty.
+func Days(first, last time.Time) ([]*Day, error) {
+	st, err := store.Default()
+	if err != nil {
+		return nil, err
+	}
+	var out []*Day
+	for d := DayStart(first); !d.After(last); d = d.AddDate(0, 0, 1) {
+		day, err := loadDay(st, d.Format(dayLayout))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, day)
+	}
+	return out, nil
+}
+
+// JobIDs returns the sorted ids of the jobs counted in cells for which keep is true.
+func (d *Day) JobIDs(keep func(c *Cell) bool) []string {
+	var out []string
+	for id, e := range d.Jobs {
+		if c := d.Cells[e.Key]; c != nil && keep(c) {
+			out = append(out, id)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func loadDay(st *store.Store, day string) (*Day, error) {
+	d := newDay(day)
+	if err := st.Get(collection, day, d); err != nil && !errors.Is(err, store.ErrNotFound) {
+		return nil, fmt.Errorf("load trend rollup %s: %w", day, err)
+	}
+	if d.Cells == nil {
+		d.Cells = map[string]*Cell{}
+	}
+	if d.Jobs == nil {
+		d.Jobs = map[string]entry{}
+	}
+	return d, nil
+}
@@ -0,0 +1,289 @@
+package trends
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/scorecard"
+	"voice-insights-go/internal/store"
+)
+
+// Granularities.
+const (
+	Daily  = "day"
+	Weekly = "week"
+)
+
+// Group-by dimensions. GroupAll gives a single series over every call.
+const (
+	GroupAll   = "all"
+	GroupIssue = "issue"
+	GroupCity  = "city"
+	GroupTeam  = "team"
+)
+
+// maxDays bounds the window of one query.
+const maxDays = 366
+
+// Moving averages may span at most this many periods; each period reads and sums that many
+// extra days of rollups.
+const (
+	maxDailyMovingAverage  = 90
+	maxWeeklyMovingAverage = 52
+)
+
+// metric computes a series value from summed stats over periods periods. Counts are per
+// period; everything else is a per-call mean.
+type metric func(s Stats, periods int) float64
+
+func perCall(sum float64, s Stats) float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return sum / float64(s.Calls)
+}
+
+var metrics = map[string]metric{
+	"calls":                 func(s Stats, p int) float64 { return float64(s.Calls) / float64(p) },
+	"escalations":           func(s Stats, p int) float64 { return float64(s.Escalations) / float64(p) },
+	"escalation_rate":       func(s Stats, _ int) float64 { return perCall(float64(s.Escalations), s) },
+	"frustration":           func(s Stats, _ int) float64 { return perCall(s.Frustration, s) },
+	"confusion":             func(s Stats, _ int) float64 { return perCall(s.Confusion, s) },
+	"resolution_likelihood": func(s Stats, _ int) float64 { return perCall(s.ResolutionLikelihood, s) },
+	"risk_of_churn":         func(s Stats, _ int) float64 { return perCall(s.RiskOfChurn, s) },
+}
+
+// Metrics lists the metric names a query accepts.
+func Metrics() []string {
+	out := make([]string, 0, len(metrics))
+	for m := range metrics {
+		out = append(out, m)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Query selects the series to build. Issue, City and Team filter calls before grouping.
+type Query struct {
+	Window      scorecard.Window
+	Granularity string
+	GroupBy     string
+	Metric      string
+	Issue       string
+	City        string
+	Team        string
+	// MovingAverage is the trailing number of periods averaged (default 7 days or 4 weeks)
+	MovingAverage int
+	// Limit keeps the series with the most calls (default 10)
+	Limit int
+}
+
+// Point is one period of a series. Period is the day, or the Monday starting the week.
+type Point struct {
+	Period    string  `json:"period"`
+	Calls     int     `json:"calls"`
+	Value     float64 `json:"value"`
+	MovingAvg float64 `json:"moving_avg"`
+}
+
+// Change compares the last 7 days of the window with the 7 days before. ChangePct is null
+// when the previous week is zero.
+type Change struct {
+	Current   float64  `json:"current"`
+	Previous  float64  `json:"previous"`
+	Change    float64  `json:"change"`
+	ChangePct *float64 `json:"change_pct"`
+}
+
+// Series is the metric over time for one group.
+type Series struct {
+	Key          string  `json:"key"`
+	Calls        int     `json:"calls"`
+	Points       []Point `json:"points"`
+	WeekOverWeek Change  `json:"week_over_week"`
+}
+
+// Result is the answer to a Query.
+type Result struct {
+	Window        scorecard.Window `json:"window"`
+	Granularity   string           `json:"granularity"`
+	GroupBy       string           `json:"group_by"`
+	Metric        string           `json:"metric"`
+	MovingAverage int              `json:"moving_average"`
+	Series        []Series         `json:"series"`
+}
+
+// Build reads the daily rollups covering q.Window, plus enough earlier days for the first
+// moving averages and the week-over-week baseline, and builds one series per group.
+func Build(q Query) (Result, error) {
+	if err := q.normalize(); err != nil {
+		return Result{}, err
+	}
+	m := metrics[q.Metric]
+	first := DayStart(q.Window.From)
+	last := DayStart(q.Window.To.Add(-time.Nanosecond))
+	if last.Before(first) {
+		return Result{}, apperr.New(apperr.InvalidInput, "", "the window is empty")
+	}
+	if last.Sub(first) > maxDays*24*time.Hour {
+		return Result{}, apperr.New(apperr.InvalidInput, "", fmt.Sprintf("the window may span at most %d days", maxDays))
+	}
+
+	// periods in the window, then the lookback the first moving average needs
+	var periods []time.Time
+	step := 1
+	if q.Granularity == Weekly {
+		step = 7
+		first = weekStart(first)
+		last = weekStart(last)
+	}
+	for p := first; !p.After(last); p = p.AddDate(0, 0, step) {
+		periods = append(periods, p)
+	}
+	loadFrom := first.AddDate(0, 0, -(q.MovingAverage-1)*step)
+	wowFrom := DayStart(q.Window.To.Add(-time.Nanosecond)).AddDate(0, 0, -13)
+	if wowFrom.Before(loadFrom) {
+		loadFrom = wowFrom
+	}
+	loadTo := last.AddDate(0, 0, step-1)
+
+	// per group, per day
+	byGroup := map[string]map[string]Stats{}
+	st, err := store.Default()
+	if err != nil {
+		return Result{}, err
+	}
+	for d := loadFrom; !d.After(loadTo); d = d.AddDate(0, 0, 1) {
+		day, err := loadDay(st, d.Format(dayLayout))
+		if err != nil {
+			return Result{}, err
+		}
+		for _, c := range day.Cells {
+			if !q.matches(c) {
+				continue
+			}
+			key := q.groupOf(c)
+			if byGroup[key] == nil {
+				byGroup[key] = map[string]Stats{}
+			}
+			s := byGroup[key][day.Day]
+			s.add(c.Stats, 1)
+			byGroup[key][day.Day] = s
+		}
+	}
+
+	res := Result{Window: q.Window, Granularity: q.Granularity, GroupBy: q.GroupBy, Metric: q.Metric, MovingAverage: q.MovingAverage, Series: []Series{}}
+	wowEnd := DayStart(q.Window.To.Add(-time.Nanosecond))
+	for key, days := range byGroup {
+		sum := func(from time.Time, n int) Stats {
+			var s Stats
+			for i := 0; i < n; i++ {
+				s.add(days[from.AddDate(0, 0, i).Format(dayLayout)], 1)
+			}
+			return s
+		}
+		s := Series{Key: key, Points: make([]Point, 0, len(periods))}
+		for _, p := range periods {
+			ps := sum(p, step)
+			window := sum(p.AddDate(0, 0, -(q.MovingAverage-1)*step), q.MovingAverage*step)
+			s.Points = append(s.Points, Point{
+				Period:    p.Format(dayLayout),
+				Calls:     ps.Calls,
+				Value:     m(ps, 1),
+				MovingAvg: m(window, q.MovingAverage),
+			})
+			s.Calls += ps.Calls
+		}
+		if s.Calls == 0 {
+			continue // only seen in the lookback
+		}
+		cur, prev := m(sum(wowEnd.AddDate(0, 0, -6), 7), 1), m(sum(wowEnd.AddDate(0, 0, -13), 7), 1)
+		s.WeekOverWeek = Change{Current: cur, Previous: prev, Change: cur - prev}
+		if prev != 0 {
+			pct := (cur - prev) / prev * 100
+			s.WeekOverWeek.ChangePct = &pct
+		}
+		res.Series = append(res.Series, s)
+	}
+	sort.Slice(res.Series, func(i, j int) bool {
+		a, b := res.Series[i], res.Series[j]
+		return a.Calls > b.Calls || a.Calls == b.Calls && a.Key < b.Key
+	})
+	if len(res.Series) > q.Limit {
+		res.Series = res.Series[:q.Limit]
+	}
+	return res, nil
+}
+
+func (q *Query) normalize() error {
+	switch q.Granularity {
+	case "":
+		q.Granularity = Daily
+	case Daily, Weekly:
+	default:
+		return apperr.New(apperr.InvalidInput, "", "granularity must be day or week")
+	}
+	switch q.GroupBy {
+	case "":
+		q.GroupBy = GroupIssue
+	case GroupAll, GroupIssue, GroupCity, GroupTeam:
+	default:
+		return apperr.New(apperr.InvalidInput, "", "group_by must be all, issue, city or team")
+	}
+	if q.Metric == "" {
+		q.Metric = "calls"
+	}
+	if _, ok := metrics[q.Metric]; !ok {
+		return apperr.New(apperr.InvalidInput, "", fmt.Sprintf("metric must be one of %s", strings.Join(Metrics(), ", ")))
+	}
+	if q.MovingAverage <= 0 {
+		q.MovingAverage = 7
+		if q.Granularity == Weekly {
+			q.MovingAverage = 4
+		}
+	}
+	limit := maxDailyMovingAverage
+	if q.Granularity == Weekly {
+		limit = maxWeeklyMovingAverage
+	}
+	if q.MovingAverage > limit {
+		return apperr.New(apperr.InvalidInput, "", fmt.Sprintf("ma may be at most %d at granularity=%s", limit, q.Granularity))
+	}
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+	q.City = strings.ToLower(strings.TrimSpace(q.City))
+	return nil
+}
+
+func (q *Query) matches(c *Cell) bool {
+	return (q.Issue == "" || c.Issue == q.Issue || strings.HasPrefix(c.Issue, q.Issue+"/")) &&
+		(q.City == "" || c.City == q.City) &&
+		(q.Team == "" || c.Team == q.Team)
+}
+
+func (q *Query) groupOf(c *Cell) string {
+	switch q.GroupBy {
+	case GroupIssue:
+		return c.Issue
+	case GroupCity:
+		return c.City
+	case GroupTeam:
+		return c.Team
+	}
+	return GroupAll
+}
+
+// DayStart is the start of the IST calendar day containing t.
+func DayStart(t time.Time) time.Time {
+	y, m, d := t.In(ist).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, ist)
+}
+
+// weekStart is the Monday of the IST week containing day.
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
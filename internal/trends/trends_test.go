@@ -0,0 +1,194 @@
+package trends
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"voice-insights-go/internal/apperr"
+	"voice-insights-go/internal/jobs"
+	"voice-insights-go/internal/scorecard"
+	"voice-insights-go/internal/testutil"
+	"voice-insights-go/internal/types"
+)
+
+func call(at time.Time, issue, city string, frustration float64, escalated bool) *jobs.Job {
+	j := jobs.New("https://calls/x.wav", 3, "single")
+	j.Status, j.CallTime, j.City = jobs.StatusCompleted, at, city
+	j.Result.Classification = &types.Classification{Category: issue}
+	j.Result.KPI.KPI.FrustrationScore = frustration
+	j.Result.KPI.Actions.RequiresEscalation = escalated
+	return j
+}
+
+func TestDayRecordReplacesRetriedJob(t *testing.T) {
+	d := newDay("2026-03-02")
+	j := call(time.Date(2026, 3, 2, 10, 0, 0, 0, ist), "billing/refund", "Pune", 0.5, false)
+	d.record(j)
+	j.Result.Classification.Category = "account/access"
+	d.record(j)
+	if len(d.Cells) != 1 || len(d.Jobs) != 1 {
+		t.Fatalf("cells=%d jobs=%d, want the retry to replace the first record", len(d.Cells), len(d.Jobs))
+	}
+	for _, c := range d.Cells {
+		if c.Issue != "account/access" || c.Calls != 1 || c.City != "pune" || c.Team != unassignedTeam {
+			t.Errorf("cell = %+v", c)
+		}
+	}
+}
+
+func TestCellOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		job   func() *jobs.Job
+		issue string
+		city  string
+	}{
+		{"classification", func() *jobs.Job { return call(time.Now(), "billing/refund", " Delhi ", 0, false) }, "billing/refund", "delhi"},
+		{"canonical issue wins", func() *jobs.Job {
+			j := call(time.Now(), "billing/refund", "", 0, false)
+			j.Result.Issues = &types.IssueTaxonomy{PrimaryIssue: types.CanonicalIssue{NodeID: "account/access"}}
+			return j
+		}, "account/access", unknownCity},
+		{"nothing known", func() *jobs.Job {
+			j := call(time.Now(), "", "", 0, false)
+			j.Result.Classification = nil
+			return j
+		}, "other", unknownCity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := CellOf(tt.job())
+			if c.Issue != tt.issue || c.City != tt.city || c.Calls != 1 {
+				t.Errorf("CellOf = %+v, want issue %s city %s", c, tt.issue, tt.city)
+			}
+		})
+	}
+}
+
+func TestQueryNormalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		q       Query
+		wantMA  int
+		wantErr bool
+	}{
+		{"daily default", Query{}, 7, false},
+		{"weekly default", Query{Granularity: Weekly}, 4, false},
+		{"daily cap", Query{MovingAverage: maxDailyMovingAverage}, maxDailyMovingAverage, false},
+		{"daily over cap", Query{MovingAverage: maxDailyMovingAverage + 1}, 0, true},
+		{"weekly over cap", Query{Granularity: Weekly, MovingAverage: maxWeeklyMovingAverage + 1}, 0, true},
+		{"bad granularity", Query{Granularity: "month"}, 0, true},
+		{"bad group", Query{GroupBy: "agent"}, 0, true},
+		{"bad metric", Query{Metric: "nps"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.q.normalize()
+			if tt.wantErr {
+				if apperr.KindOf(err) != apperr.InvalidInput {
+					t.Errorf("err = %v, want invalid input", err)
+				}
+				return
+			}
+			if err != nil || tt.q.MovingAverage != tt.wantMA {
+				t.Errorf("err=%v ma=%d, want ma %d", err, tt.q.MovingAverage, tt.wantMA)
+			}
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	testutil.DataDir(t)
+	day := func(d int) time.Time { return time.Date(2026, 3, d, 11, 0, 0, 0, ist) }
+	for _, j := range []*jobs.Job{
+		call(day(2), "billing/refund", "Pune", 0.2, false),
+		call(day(2), "billing/refund", "Pune", 0.4, true),
+		call(day(9), "account/access", "Delhi", 0.9, true),
+		call(day(15), "billing/refund", "Delhi", 0.6, false),
+	} {
+		if err := Record(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Monday 2 March to Monday 16 March, IST
+	win := scorecard.Window{From: time.Date(2026, 3, 2, 0, 0, 0, 0, ist), To: time.Date(2026, 3, 16, 0, 0, 0, 0, ist)}
+
+	type point struct {
+		period string
+		value  float64
+		ma     float64
+	}
+	tests := []struct {
+		name   string
+		q      Query
+		series []string
+		points map[string][]point // checked points per series
+		wow    *Change
+	}{
+		{
+			name:   "daily calls over everything",
+			q:      Query{GroupBy: GroupAll, MovingAverage: 2},
+			series: []string{GroupAll},
+			points: map[string][]point{GroupAll: {{"2026-03-02", 2, 1}, {"2026-03-03", 0, 1}, {"2026-03-09", 1, 0.5}}},
+			wow:    &Change{Current: 2, Previous: 2},
+		},
+		{
+			name:   "weekly calls by issue",
+			q:      Query{Granularity: Weekly, MovingAverage: 1},
+			series: []string{"billing/refund", "account/access"},
+			points: map[string][]point{"billing/refund": {{"2026-03-02", 2, 2}, {"2026-03-09", 1, 1}}},
+		},
+		{
+			name:   "mean frustration by city",
+			q:      Query{GroupBy: GroupCity, Metric: "frustration", MovingAverage: 1},
+			series: []string{"delhi", "pune"},
+			points: map[string][]point{"pune": {{"2026-03-02", 0.3, 0.3}}, "delhi": {{"2026-03-09", 0.9, 0.9}}},
+		},
+		{
+			name:   "issue filter covers subtree",
+			q:      Query{GroupBy: GroupAll, Issue: "billing", Metric: "escalation_rate", Granularity: Weekly, MovingAverage: 2},
+			series: []string{GroupAll},
+			points: map[string][]point{GroupAll: {{"2026-03-02", 0.5, 0.5}, {"2026-03-09", 0, 1.0 / 3}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.Window = win
+			res, err := Build(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Series) != len(tt.series) {
+				t.Fatalf("got %d series, want %v", len(res.Series), tt.series)
+			}
+			byKey := map[string]Series{}
+			for i, s := range res.Series {
+				if s.Key != tt.series[i] {
+					t.Errorf("series %d = %s, want %s", i, s.Key, tt.series[i])
+				}
+				byKey[s.Key] = s
+			}
+			for key, want := range tt.points {
+				got := map[string]Point{}
+				for _, p := range byKey[key].Points {
+					got[p.Period] = p
+				}
+				for _, w := range want {
+					p, ok := got[w.period]
+					if !ok || !near(p.Value, w.value) || !near(p.MovingAvg, w.ma) {
+						t.Errorf("%s %s = %+v, want value %v ma %v", key, w.period, p, w.value, w.ma)
+					}
+				}
+			}
+			if tt.wow != nil {
+				wow := res.Series[0].WeekOverWeek
+				if !near(wow.Current, tt.wow.Current) || !near(wow.Previous, tt.wow.Previous) || wow.ChangePct == nil || *wow.ChangePct != 0 {
+					t.Errorf("week over week = %+v, want %+v", wow, *tt.wow)
+				}
+			}
+		})
+	}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
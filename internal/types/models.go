@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // -------------------------
 // CUSTOMER PROBLEM
 // -------------------------
@@ -125,6 +127,30 @@ type KPIExtraction struct {
 	ConversationQuality  ConversationQuality  `json:"conversation_quality"`
 	TrendInsights        TrendInsights        `json:"trend_insights_from_similar_calls"`
 	BusinessImpact       BusinessImpact       `json:"business_impact"`
+
+	// Grounding ties list items above to verbatim transcript quotes
+	Grounding []GroundedFinding `json:"grounding"`
+}
+
+// -------------------------
+// EVIDENCE GROUNDING
+// -------------------------
+
+// Quote is a transcript span cited as evidence. Turn is the 0-based turn index; Score and
+// Verified are filled in by the grounding verifier, never trusted from the model.
+type Quote struct {
+	Turn     int     `json:"turn"`
+	Text     string  `json:"text"`
+	Verified bool    `json:"verified"`
+	Score    float64 `json:"score"`
+}
+
+// GroundedFinding links one list item of the extraction (Field is its JSON path) to quotes.
+type GroundedFinding struct {
+	Field    string  `json:"field"`
+	Item     string  `json:"item"`
+	Quotes   []Quote `json:"quotes"`
+	Verified bool    `json:"verified"`
 }
 
 // -------------------------
@@ -137,20 +163,201 @@ type KPIResult struct {
 	Evidence   map[string]interface{} `json:"evidence"`
 	DurationMs int64                  `json:"duration_ms"`
 	Error      string                 `json:"error,omitempty"`
+
+	// FailedStage names the pipeline stage that aborted the run (empty on success)
+	FailedStage Stage      `json:"failed_stage,omitempty"`
+	ErrorInfo   *ErrorInfo `json:"error_info,omitempty"`
+
+	// AgentID and Team identify who handled the call, for scorecards
+	AgentID string `json:"agent_id,omitempty"`
+	Team    string `json:"team,omitempty"`
+
+	// CallTime is when the call happened (the job creation time when the caller did not say)
+	CallTime time.Time `json:"call_time,omitzero"`
+
+	// JobID identifies the stored job so a failed run can be retried from its last good stage
+	JobID  string                `json:"job_id,omitempty"`
+	Stages map[Stage]StageStatus `json:"stages,omitempty"`
+
+	// Blocks reports per-block outcomes when the extraction ran in multi-pass mode
+	Blocks map[string]BlockStatus `json:"extraction_blocks,omitempty"`
+
+	// Compliance is the deterministic script-rule audit of the transcript
+	Compliance *ComplianceReport `json:"compliance,omitempty"`
+
+	// Issues maps the free-form issue fields of KPI onto the managed taxonomy
+	Issues *IssueTaxonomy `json:"issue_taxonomy,omitempty"`
+
+	// Classification is the transcript-level category and confusion flag from the classifier
+	Classification *Classification `json:"classification,omitempty"`
+
+	// Repeat is the linked caller's earlier contacts, when the call was identified
+	Repeat *RepeatContact `json:"repeat_contact,omitempty"`
+
+	// Churn is the trained churn model's score, when a model has been trained; its Blended
+	// value is the risk to act on, while KPI.BusinessImpact.RiskOfChurn stays the LLM's
+	Churn *ChurnPrediction `json:"churn,omitempty"`
+}
+
+// ChurnRisk is the churn risk to act on: the model's blended score when a trained model
+// scored the call, else the LLM's risk_of_churn. Alerts, rollups and journeys read this.
+func (r *KPIResult) ChurnRisk() float64 {
+	if r.Churn != nil {
+		return r.Churn.Blended
+	}
+	return r.KPI.BusinessImpact.RiskOfChurn
+}
+
+// ChurnPrediction is the calibrated churn probability of the trained model, the LLM's
+// value it was blended with, and the features that pushed the score most.
+type ChurnPrediction struct {
+	Probability  float64       `json:"probability"`
+	LLM          float64       `json:"llm"`
+	Blended      float64       `json:"blended"`
+	ModelWeight  float64       `json:"model_weight"`
+	ModelVersion int           `json:"model_version"`
+	TopFactors   []ChurnFactor `json:"top_factors"`
+}
+
+// ChurnFactor is one feature's contribution to the churn log-odds; positive raises risk.
+type ChurnFactor struct {
+	Feature      string  `json:"feature"`
+	Value        float64 `json:"value"`
+	Contribution float64 `json:"contribution"`
+}
+
+// RepeatContact describes a call from a customer who called before within the repeat window.
+// Unlike CustomerProblem.RepeatIssue, which is the model's guess, it comes from the calls
+// linked to the customer id; SameIssue is set when an earlier call had the same taxonomy node.
+type RepeatContact struct {
+	CustomerID    string   `json:"customer_id"`
+	WindowDays    int      `json:"window_days"`
+	PriorCalls    int      `json:"prior_calls"`
+	DaysSinceLast float64  `json:"days_since_last"`
+	PriorIssues   []string `json:"prior_issues"`
+	SameIssue     bool     `json:"same_issue"`
+}
+
+// CanonicalIssue is a free-form issue string mapped to a taxonomy node. Raw is kept so a
+// mapping can be audited or re-run after the taxonomy changes.
+type CanonicalIssue struct {
+	Raw             string   `json:"raw"`
+	NodeID          string   `json:"node_id"`
+	Path            []string `json:"path"`
+	Method          string   `json:"method"`
+	Confidence      float64  `json:"confidence"`
+	TaxonomyVersion int      `json:"taxonomy_version"`
+}
+
+type IssueTaxonomy struct {
+	PrimaryIssue     CanonicalIssue `json:"primary_issue"`
+	DominantCategory CanonicalIssue `json:"dominant_issue_category"`
+}
+
+// BlockStatus is the outcome of extracting one Schema v2 block.
+type BlockStatus struct {
+	Status   StageStatus `json:"status"`
+	Error    string      `json:"error,omitempty"`
+	Attempts int         `json:"attempts"`
+}
+
+// -------------------------
+// COMPLIANCE
+// -------------------------
+type ComplianceStatus string
+
+const (
+	CompliancePass          ComplianceStatus = "pass"
+	ComplianceFail          ComplianceStatus = "fail"
+	ComplianceNotApplicable ComplianceStatus = "not_applicable"
+)
+
+// ComplianceResult is the outcome of one script rule. Turn is the transcript turn that
+// decided the outcome (-1 when no turn matched, e.g. a required phrase never said).
+type ComplianceResult struct {
+	RuleID      string           `json:"rule_id"`
+	Description string           `json:"description"`
+	Severity    string           `json:"severity"`
+	Status      ComplianceStatus `json:"status"`
+	Turn        int              `json:"turn"`
+	Speaker     string           `json:"speaker,omitempty"`
+	Evidence    string           `json:"evidence,omitempty"`
+	Reason      string           `json:"reason"`
 }
 
+// ComplianceReport is reproducible: the same transcript and ruleset version always give
+// the same results. LLMFlags keeps the model's own compliance flags for comparison.
+type ComplianceReport struct {
+	RulesetVersion string             `json:"ruleset_version"`
+	AgentSpeaker   string             `json:"agent_speaker"`
+	Passed         int                `json:"passed"`
+	Failed         int                `json:"failed"`
+	Score          float64            `json:"score"`
+	Results        []ComplianceResult `json:"results"`
+	LLMFlags       []string           `json:"llm_flags"`
+}
+
+// ErrorInfo is the stable, machine-readable error body returned by the API.
+type ErrorInfo struct {
+	Kind      string `json:"kind"`
+	Stage     Stage  `json:"stage,omitempty"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// -------------------------
+// PIPELINE STAGES
+// -------------------------
+type Stage string
+
+const (
+	StageTranscription Stage = "transcription"
+	StageSearch        Stage = "search"
+	StageLLM           Stage = "llm"
+)
+
+// Stages lists the pipeline stages in execution order.
+var Stages = []Stage{StageTranscription, StageSearch, StageLLM}
+
+type StageStatus string
+
+const (
+	StagePending StageStatus = "pending"
+	StageOK      StageStatus = "ok"
+	StageFailed  StageStatus = "failed"
+	// StagePartial marks a multi-pass extraction where only some blocks succeeded
+	StagePartial StageStatus = "partial"
+)
+
 type CallRecord struct {
 	CallID       string `json:"call_id"`
 	CallType     string `json:"call_type"`
 	AudioURL     string `json:"audio_url"`
 	City         string `json:"city"`
-	VintageMonth int    `json:"vintage_month"`
+	// VintageMonth is nil when the row has no usable vintage
+	VintageMonth *int   `json:"vintage_month,omitempty"`
 	RepeatEsc    int    `json:"repeat_esc"`
+	AgentID      string `json:"agent_id"`
+	Team         string `json:"team"`
+	// CallTime is when the call happened, from the mapped timestamp column
+	CallTime time.Time `json:"call_time,omitzero"`
 }
 
 type EnrichedRecord struct {
-	CallID        string `json:"call_id"`
+	CallID string `json:"call_id"`
+	// VintageBucket is a vintage label of the cohort config (see internal/cohort)
 	VintageBucket string `json:"vintage_bucket"`
 	IsConfused    bool   `json:"is_confused"`
 	Category      string `json:"category"`
 }
+
+// Classification is a transcript-level taxonomy category plus whether the customer was
+// confused. Method names the classifier that produced it (rules, bayes or llm).
+type Classification struct {
+	Category            string   `json:"category"`
+	Path                []string `json:"path"`
+	Confidence          float64  `json:"confidence"`
+	Confused            bool     `json:"confused"`
+	ConfusionConfidence float64  `json:"confusion_confidence"`
+	Method              string   `json:"method"`
+}